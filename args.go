@@ -0,0 +1,123 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// ArgCall is a call found by [ArgValues],
+// with the values of its argument.
+type ArgCall struct {
+	Pkg  *Package
+	Call *ast.CallExpr
+	Pos  token.Position
+
+	// Result holds the values of the argument,
+	// as found by [Scanner.Scan].
+	Result
+}
+
+// ArgResult is the outcome of [ArgValues].
+type ArgResult struct {
+	// Calls are the calls found,
+	// in package and source order.
+	Calls []ArgCall
+
+	// Values is the union of the values of the argument in all the calls,
+	// keyed by their ExactString representations,
+	// as in [Result].
+	Values map[string]constant.Value
+
+	// Complete tells whether Values holds all the possibilities
+	// in the calls found.
+	Complete bool
+}
+
+// ArgValues finds every call in pkgs
+// of a function or method whose full name
+// (see [types.Func.FullName])
+// matches pattern,
+// in which ... matches any string,
+// and the values of argument idx of each,
+// for audits like finding every value ever passed as an SQL query:
+//
+//	ArgValues("(*database/sql.DB).Query", 0, pkgs)
+//
+// Calls of a method through an interface match the interface method,
+// as in "(io.Writer).Write".
+// Calls that do not supply argument idx
+// (as when it is variadic)
+// are left out,
+// and those that pass a slice for a variadic argument idx
+// (as in f(args...))
+// are incomplete.
+// Calls through function values,
+// which may be of any function,
+// are not found.
+func ArgValues(pattern string, idx int, pkgs []*Package) ArgResult {
+	result := ArgResult{Values: make(map[string]constant.Value), Complete: true}
+
+	for _, pkg := range pkgs {
+		s := &Scanner{Files: pkg.Files, Info: pkg.Info}
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				fn := calleeFunc(call, pkg.Info)
+				if fn == nil || !matchPattern(pattern, fn.Origin().FullName()) {
+					return true
+				}
+				res, ok := s.argValues(call, fn, idx)
+				if !ok {
+					return true
+				}
+				result.Calls = append(result.Calls, ArgCall{
+					Pkg:    pkg,
+					Call:   call,
+					Pos:    pkg.Fset.Position(call.Pos()),
+					Result: res,
+				})
+				union(result.Values, res.Values)
+				result.Complete = result.Complete && res.Complete
+				return true
+			})
+		}
+	}
+
+	return result
+}
+
+// argValues finds the values of argument idx of call,
+// a call of fn.
+// It reports false if call does not supply that argument.
+func (s *Scanner) argValues(call *ast.CallExpr, fn *types.Func, idx int) (Result, bool) {
+	sig := fn.Signature()
+	if idx < 0 || (idx >= sig.Params().Len() && !sig.Variadic()) {
+		return Result{}, false
+	}
+
+	if len(call.Args) == 1 {
+		// A call like f(g()) where g returns multiple values.
+		if inner, ok := ast.Unparen(call.Args[0]).(*ast.CallExpr); ok {
+			if tuple, ok := s.Info.TypeOf(inner).(*types.Tuple); ok {
+				if idx >= tuple.Len() {
+					return Result{}, false
+				}
+				return s.ScanCallResult(inner, idx), true
+			}
+		}
+	}
+
+	if idx >= len(call.Args) {
+		return Result{}, false
+	}
+	if call.Ellipsis.IsValid() && idx >= sig.Params().Len()-1 {
+		why := &Reason{Pos: call.Args[len(call.Args)-1].Pos(), Msg: "variadic arguments passed as a slice are not tracked"}
+		return Result{Values: map[string]constant.Value{}, Why: why}, true
+	}
+	return s.Scan(call.Args[idx]), true
+}
@@ -0,0 +1,119 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+)
+
+// auditVar records that the scan depends on the values of the local variable v,
+// for checking by [query.audit] (see [Scanner.Audit]).
+func (q *query) auditVar(v *types.Var) {
+	if q.audited != nil {
+		q.audited[v.Origin()] = true
+	}
+}
+
+// audit checks the variables that a complete scan depended on
+// for uses that may change them in ways the analysis does not see
+// (see [Scanner.Audit]),
+// recording the violations it finds as reasons.
+// It reports whether there are none.
+func (q *query) audit() bool {
+	vars := slices.SortedFunc(maps.Keys(q.audited), func(a, b *types.Var) int { return int(a.Pos() - b.Pos()) })
+
+	ok := true
+	for _, v := range vars {
+		node, msg := q.auditViolation(v)
+		if node != nil {
+			q.incomplete(node, "audit: %s %s", v.Name(), msg)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// auditViolation finds the first use of v
+// that may change it in ways the analysis does not see:
+// taking its address (or that of one of its fields),
+// assigning it in a goroutine,
+// or assigning it in a function literal that may run at any time
+// (one that is neither called in place nor deferred),
+// since that may invalidate what the analysis concludes from conditions on v.
+// It returns the use and a description,
+// or nil if there is none.
+func (q *query) auditViolation(v *types.Var) (ast.Node, string) {
+	scope := q.varScopeNode(v)
+	if scope == nil {
+		return nil, ""
+	}
+
+	isV := func(expr ast.Expr) bool {
+		if expr == nil {
+			return false
+		}
+		_, ok := q.fieldPathOf(expr, v)
+		return ok
+	}
+
+	var (
+		found ast.Node
+		msg   string
+	)
+	inspectWithStack(scope, func(n ast.Node, stack []ast.Node) bool {
+		if found != nil {
+			return false
+		}
+
+		var targets []ast.Expr
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && isV(n.X) {
+				found, msg = n, "has its address taken"
+			}
+			return true
+		case *ast.AssignStmt:
+			targets = n.Lhs
+		case *ast.IncDecStmt:
+			targets = []ast.Expr{n.X}
+		case *ast.RangeStmt:
+			targets = []ast.Expr{n.Key, n.Value}
+		default:
+			return true
+		}
+		if !slices.ContainsFunc(targets, isV) {
+			return true
+		}
+
+		// Find the innermost function literal containing the assignment,
+		// if v is declared outside it.
+		for i := len(stack) - 1; i >= 0; i-- {
+			lit, ok := stack[i].(*ast.FuncLit)
+			if !ok {
+				continue
+			}
+			if lit.Pos() <= v.Pos() && v.Pos() < lit.End() {
+				// v is local to the function literal.
+				break
+			}
+			var call *ast.CallExpr
+			if i > 0 {
+				call, _ = stack[i-1].(*ast.CallExpr)
+			}
+			if call == nil || call.Fun != lit {
+				found, msg = n, "is assigned in a function literal that may run at any time"
+				break
+			}
+			if i > 1 {
+				if _, ok := stack[i-2].(*ast.GoStmt); ok {
+					found, msg = n, "is assigned in a goroutine"
+				}
+			}
+			break
+		}
+		return true
+	})
+	return found, msg
+}
@@ -0,0 +1,264 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// Bits describes the possible values of an integer used as a set of flags
+// without enumerating every combination of them.
+// Every possible value has all the bits in Must set
+// and no bits set outside of May.
+type Bits struct {
+	May, Must constant.Value
+
+	// Complete tells whether the description is known to cover every possible value.
+	// If it is false, May and Must are only what was determined.
+	Complete bool
+
+	// Why explains what made the result incomplete,
+	// as in [Result].
+	Why *Reason
+}
+
+// bitsKey identifies the bits of a variable in query.active.
+type bitsKey struct {
+	v *types.Var
+}
+
+// ScanBits determines the bits that may and must be set in the integer expression node.
+// It is meant for variables built by combining flag constants,
+// e.g. with perm |= FlagRead,
+// whose possible values [Scanner.Scan] would have to enumerate
+// (and cannot, for compound assignments).
+//
+// Assignments with =, |=, &=, and &^= are understood,
+// as are the |, &, and &^ operators.
+// Other integer expressions contribute the bitwise OR (to May)
+// and AND (to Must) of their possible values, as determined by Scan.
+func (s *Scanner) ScanBits(node ast.Expr) Bits {
+	q := s.newQuery()
+	var b bitVals
+	res := q.result(node, "scanning bits of "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+		var ok bool
+		b, ok = q.scanBits(node)
+		return nil, ok
+	})
+	return Bits{May: b.may, Must: b.must, Complete: res.Complete, Why: res.Why}
+}
+
+// bitVals is the may/must abstraction of a set of integers.
+// A nil bitVals (both fields nil) means no values.
+type bitVals struct {
+	may, must constant.Value
+}
+
+// join adds the possibilities in other to b.
+func (b *bitVals) join(other bitVals) {
+	if other.may == nil {
+		return
+	}
+	if b.may == nil {
+		*b = other
+		return
+	}
+	b.may = constant.BinaryOp(b.may, token.OR, other.may)
+	b.must = constant.BinaryOp(b.must, token.AND, other.must)
+}
+
+func exactBits(v constant.Value) bitVals {
+	return bitVals{may: v, must: v}
+}
+
+func (q *query) scanBits(expr ast.Expr) (bitVals, bool) {
+	expr = ast.Unparen(expr)
+
+	if tv, ok := q.info.Types[expr]; ok && tv.Value != nil {
+		if v := constant.ToInt(tv.Value); v.Kind() == constant.Int {
+			return exactBits(v), true
+		}
+	}
+
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if v, ok := q.info.ObjectOf(expr).(*types.Var); ok {
+			var (
+				b  bitVals
+				ok bool
+			)
+			q.nested(expr, "variable "+expr.Name, func() (map[string]constant.Value, bool) {
+				b, ok = q.scanVarBits(v)
+				return nil, ok
+			})
+			return b, ok
+		}
+
+	case *ast.BinaryExpr:
+		switch expr.Op {
+		case token.OR, token.AND, token.AND_NOT:
+			x, xOK := q.scanBits(expr.X)
+			y, yOK := q.scanBits(expr.Y)
+			return applyBits(x, expr.Op, y), xOK && yOK
+		}
+	}
+
+	// Fall back to the possible values.
+	vals, complete := q.scan(expr)
+	var b bitVals
+	for _, v := range vals {
+		if v.Kind() != constant.Int {
+			q.incomplete(expr, "non-integer value %s", v.ExactString())
+			complete = false
+			continue
+		}
+		b.join(exactBits(v))
+	}
+	return b, complete
+}
+
+// applyBits computes x op y, for op one of |, &, and &^.
+func applyBits(x bitVals, op token.Token, y bitVals) bitVals {
+	if x.may == nil || y.may == nil {
+		return bitVals{}
+	}
+	switch op {
+	case token.OR:
+		return bitVals{
+			may:  constant.BinaryOp(x.may, token.OR, y.may),
+			must: constant.BinaryOp(x.must, token.OR, y.must),
+		}
+	case token.AND:
+		return bitVals{
+			may:  constant.BinaryOp(x.may, token.AND, y.may),
+			must: constant.BinaryOp(x.must, token.AND, y.must),
+		}
+	default: // token.AND_NOT
+		return bitVals{
+			may:  constant.BinaryOp(x.may, token.AND_NOT, y.must),
+			must: constant.BinaryOp(x.must, token.AND_NOT, y.may),
+		}
+	}
+}
+
+// scanVarBits determines the bits that may and must be set in v.
+// Since the order of the assignments is not considered,
+// |= can only add to the bits that may be set,
+// and &= and &^= can only remove from the bits that must be set.
+func (q *query) scanVarBits(v *types.Var) (bitVals, bool) {
+	v = v.Origin()
+
+	key := bitsKey{v: v}
+	if !q.enter(key) {
+		return bitVals{}, true
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		return bitVals{}, false
+	}
+
+	var (
+		// Values given to v outright.
+		initial  bitVals
+		complete = true
+
+		// Bits that compound assignments may add and remove.
+		added, removed []bitVals
+	)
+
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.paramArg(v); ok {
+			q.inFrames(depth, func() {
+				b, ok := q.scanBits(arg)
+				initial.join(b)
+				complete = complete && ok
+			})
+		} else {
+			q.incomplete(node, "%s is a parameter and its argument is unknown", v.Name())
+			complete = false
+		}
+	}
+
+	q.inspectReachable(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if !exprIsVar(lhs, v, q.info) {
+					continue
+				}
+				if len(n.Lhs) != len(n.Rhs) {
+					q.incomplete(n, "unsupported assignment to %s", v.Name())
+					complete = false
+					continue
+				}
+				b, ok := q.scanBits(n.Rhs[i])
+				complete = complete && ok
+
+				switch n.Tok {
+				case token.ASSIGN, token.DEFINE:
+					initial.join(b)
+				case token.OR_ASSIGN:
+					added = append(added, b)
+				case token.AND_ASSIGN:
+					removed = append(removed, bitVals{may: constant.UnaryOp(token.XOR, b.must, 0), must: constant.UnaryOp(token.XOR, b.may, 0)})
+				case token.AND_NOT_ASSIGN:
+					removed = append(removed, b)
+				default:
+					q.incomplete(n, "unsupported assignment operator %s", n.Tok)
+					complete = false
+				}
+			}
+
+		case *ast.IncDecStmt:
+			if exprIsVar(n.X, v, q.info) {
+				q.incomplete(n, "unsupported %s of %s", n.Tok, v.Name())
+				complete = false
+			}
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					initial.join(exactBits(constant.MakeInt64(0)))
+				case len(n.Names):
+					b, ok := q.scanBits(n.Values[i])
+					initial.join(b)
+					complete = complete && ok
+				default:
+					q.incomplete(n, "unsupported declaration of %s", v.Name())
+					complete = false
+				}
+			}
+
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && exprIsVar(n.X, v, q.info) {
+				q.incomplete(n, "address of %s is taken", v.Name())
+				complete = false
+			}
+		}
+		return true
+	})
+
+	if initial.may == nil {
+		return bitVals{}, complete
+	}
+
+	result := initial
+	for _, b := range added {
+		if b.may != nil {
+			result.may = constant.BinaryOp(result.may, token.OR, b.may)
+		}
+	}
+	for _, b := range removed {
+		if b.may != nil {
+			result.must = constant.BinaryOp(result.must, token.AND_NOT, b.may)
+		}
+	}
+	return result, complete
+}
@@ -0,0 +1,293 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"maps"
+	"slices"
+)
+
+// maxBuilderStates bounds the number of states a [builderWalker] keeps for an expression.
+// Beyond it,
+// the states are truncated and incomplete.
+const maxBuilderStates = 256
+
+// maxBuilderRounds bounds the number of rounds a [builderWalker] spends
+// finding the states of a variable that is built up from itself,
+// as in q = q.Where(...).
+// A variable whose states are still growing after that
+// is taken to be built up in a loop,
+// and its states are incomplete.
+const maxBuilderRounds = 8
+
+// A builderState is the state of a builder value,
+// such as a query builder or a template,
+// as tracked by a [builderWalker].
+// Its key identifies it among the states of an expression.
+type builderState interface {
+	key() string
+}
+
+// A builderWalker finds the possible states of expressions of a builder type,
+// whose values are built up by chains of method calls,
+// as in sq.Select("id").From("users").Where("active").
+// The chains are treated as concatenations over the strings the builder is given:
+// each step combines every state of its receiver
+// with every combination of the possible values of its arguments.
+//
+// Variables holding builders are followed to the values assigned to them,
+// and calls to functions returning builders to the values they return,
+// as with [query.sources].
+type builderWalker struct {
+	q *query
+
+	// isStep tells whether call is a call of the builder's API,
+	// whose result states step computes.
+	isStep func(call *ast.CallExpr) bool
+	step   func(call *ast.CallExpr) (map[string]builderState, bool)
+
+	// inPlace, if set,
+	// tells whether call changes its receiver in place
+	// (as well as returning it).
+	// Such calls on the variables walked
+	// must be among the steps walked,
+	// or the walker misses their effects.
+	inPlace func(call *ast.CallExpr) bool
+
+	// visited holds the steps walked,
+	// and changes the calls that change walked variables in place
+	// (see inPlace).
+	visited map[*ast.CallExpr]bool
+	changes map[*ast.CallExpr]*types.Var
+
+	// partial holds the states found so far for the variables being walked,
+	// for those built up from themselves.
+	partial map[*types.Var]map[string]builderState
+}
+
+// walk finds the possible states of expr.
+// The boolean result tells whether they are complete.
+func (w *builderWalker) walk(expr ast.Expr) (map[string]builderState, bool) {
+	states, complete := w.states(expr)
+
+	calls := slices.SortedFunc(maps.Keys(w.changes), func(a, b *ast.CallExpr) int { return int(a.Pos() - b.Pos()) })
+	for _, call := range calls {
+		if !w.visited[call] {
+			w.q.incomplete(call, "%s is changed in place by %s", w.changes[call].Name(), types.ExprString(call.Fun))
+			complete = false
+		}
+	}
+	return states, complete
+}
+
+// states finds the possible states of expr,
+// as [builderWalker.walk] does
+// but without checking for unseen changes.
+func (w *builderWalker) states(expr ast.Expr) (map[string]builderState, bool) {
+	q := w.q
+	result := make(map[string]builderState)
+
+	follow := func(e ast.Expr) bool {
+		call, ok := e.(*ast.CallExpr)
+		return ok && !w.isStep(call) && q.followWriter(call)
+	}
+
+	var direct func(e ast.Expr) bool
+	direct = func(e ast.Expr) bool {
+		e = ast.Unparen(e)
+		var (
+			states   map[string]builderState
+			complete bool
+		)
+		switch e := e.(type) {
+		case *ast.Ident:
+			v, ok := q.info.ObjectOf(e).(*types.Var)
+			if !ok {
+				q.incomplete(e, "unsupported builder expression %s", e.Name)
+				return false
+			}
+			states, complete = w.varStates(e, v)
+
+		case *ast.CallExpr:
+			if !w.isStep(e) {
+				q.incomplete(e, "unsupported builder call %s", types.ExprString(e.Fun))
+				return false
+			}
+			if w.visited == nil {
+				w.visited = make(map[*ast.CallExpr]bool)
+			}
+			w.visited[e] = true
+			states, complete = w.step(e)
+
+		default:
+			q.incomplete(e, "unsupported builder expression %s", types.ExprString(e))
+			return false
+		}
+		return w.add(e, result, states) && complete
+	}
+
+	complete := true
+	ok := q.sources(expr, follow, func(src source) {
+		if src.zero {
+			q.incomplete(src.expr, "%s is declared without a value", types.ExprString(src.expr))
+			complete = false
+			return
+		}
+		complete = direct(src.expr) && complete
+	})
+	return result, ok && complete
+}
+
+// varStates finds the possible states of the local variable v,
+// used at ident.
+// A variable built up from itself is walked in rounds
+// until its states stop growing
+// (or [maxBuilderRounds] is reached).
+func (w *builderWalker) varStates(ident *ast.Ident, v *types.Var) (map[string]builderState, bool) {
+	q := w.q
+	v = v.Origin()
+
+	if states, ok := w.partial[v]; ok {
+		// v is built up from itself;
+		// the caller's rounds account for the states still to come.
+		return states, true
+	}
+
+	if w.inPlace != nil {
+		if node := q.varScopeNode(v); node != nil {
+			ast.Inspect(node, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || !w.inPlace(call) {
+					return true
+				}
+				if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok && exprIsVar(sel.X, v, q.info) {
+					if w.changes == nil {
+						w.changes = make(map[*ast.CallExpr]*types.Var)
+					}
+					w.changes[call] = v
+				}
+				return true
+			})
+		}
+	}
+
+	complete := true
+
+	if w.partial == nil {
+		w.partial = make(map[*types.Var]map[string]builderState)
+	}
+	w.partial[v] = nil
+	defer delete(w.partial, v)
+
+	for range maxBuilderRounds {
+		states := make(map[string]builderState)
+		ok := q.nestedOK(ident, "variable "+v.Name(), func() bool {
+			follow := func(e ast.Expr) bool {
+				call, ok := e.(*ast.CallExpr)
+				return ok && !w.isStep(call) && q.followWriter(call)
+			}
+			return q.varSources(v, follow, func(src source) {
+				if src.zero {
+					q.incomplete(src.expr, "%s is declared without a value", v.Name())
+					complete = false
+					return
+				}
+				srcStates, srcComplete := w.states(src.expr)
+				complete = w.add(src.expr, states, srcStates) && srcComplete && complete
+			})
+		})
+		complete = ok && complete
+
+		if sameKeys(states, w.partial[v]) {
+			return states, complete
+		}
+		w.partial[v] = states
+	}
+
+	q.incomplete(ident, "%s is built up in a loop", v.Name())
+	return w.partial[v], false
+}
+
+// add adds states to result,
+// up to [maxBuilderStates] in all.
+// It reports whether all of them fit.
+func (w *builderWalker) add(node ast.Node, result, states map[string]builderState) bool {
+	for _, k := range slices.Sorted(maps.Keys(states)) {
+		if _, ok := result[k]; ok {
+			continue
+		}
+		if len(result) >= maxBuilderStates {
+			w.q.incomplete(node, "more than %d builder states", maxBuilderStates)
+			return false
+		}
+		result[k] = states[k]
+	}
+	return true
+}
+
+// extend applies a step to each of the recv states
+// and each combination of the possible values of args,
+// which must be strings or integers
+// (the latter rendered in decimal).
+// The boolean result tells whether the states are complete.
+func (w *builderWalker) extend(call *ast.CallExpr, recv map[string]builderState, args []ast.Expr, f func(st builderState, vals []string) builderState) (map[string]builderState, bool) {
+	q := w.q
+
+	if call.Ellipsis.IsValid() {
+		q.incomplete(call, "unsupported variadic call of %s", types.ExprString(call.Fun))
+		return nil, false
+	}
+
+	complete := true
+	combos := [][]string{nil}
+	for _, arg := range args {
+		vals, ok := q.scan(arg)
+		complete = ok && complete
+
+		var next [][]string
+		for _, combo := range combos {
+			for _, k := range exactStrings(vals) {
+				var s string
+				switch v := vals[k]; v.Kind() {
+				case constant.String:
+					s = constant.StringVal(v)
+				case constant.Int:
+					s = v.String()
+				default:
+					q.incomplete(arg, "unsupported builder argument %s", k)
+					complete = false
+					continue
+				}
+				next = append(next, append(slices.Clip(combo), s))
+			}
+		}
+		if len(next) > maxBuilderStates {
+			q.incomplete(call, "more than %d combinations of arguments to %s", maxBuilderStates, types.ExprString(call.Fun))
+			next, complete = next[:maxBuilderStates], false
+		}
+		combos = next
+	}
+
+	result := make(map[string]builderState)
+	for _, k := range slices.Sorted(maps.Keys(recv)) {
+		for _, combo := range combos {
+			st := f(recv[k], combo)
+			complete = w.add(call, result, map[string]builderState{st.key(): st}) && complete
+		}
+	}
+	return result, complete
+}
+
+// sameKeys tells whether a and b have the same keys.
+func sameKeys(a, b map[string]builderState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
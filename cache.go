@@ -0,0 +1,168 @@
+package exprvals
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// SummaryCache is a store for the results of functions
+// that [ReportPackages] establishes
+// (see [ReportOptions.Summaries] and [Scanner.Summaries]),
+// for hosts such as build systems, build farms, and editors
+// that keep them in their own caches,
+// distributed or memory-mapped,
+// to share them among processes and runs.
+//
+// The keys name a function and one of its results,
+// as in "example.com/mod/config.Mode#0"
+// or "(*example.com/mod/config.Loader).Mode#1"
+// (see [types.Func.FullName]).
+// The data is opaque,
+// and must be returned by Get exactly as it was given to Put.
+// Since the results depend on the source of the function's package
+// and of the packages it imports,
+// a cache that outlives a build must be scoped to their versions,
+// as by the host's action keys.
+//
+// Get and Put may be called concurrently.
+type SummaryCache interface {
+	// Get returns the data stored under key, if any.
+	Get(key string) ([]byte, bool)
+
+	// Put stores data under key.
+	Put(key string, data []byte)
+}
+
+// summaryKey is the key of result idx of fn in a [SummaryCache].
+func summaryKey(fn *types.Func, idx int) string {
+	return funcName(fn) + "#" + strconv.Itoa(idx)
+}
+
+// encodeFactResult encodes r for a [SummaryCache]:
+// a line with its detail,
+// followed by a line for each value (with [SummaryFull])
+// or a line with the fingerprint and size of the values (with [SummaryHash]).
+func encodeFactResult(r *factResult) []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, r.detail)
+	switch r.detail {
+	case SummaryFull:
+		for _, k := range exactStrings(r.vals) {
+			fmt.Fprintln(buf, encodeValue(r.vals[k]))
+		}
+	case SummaryHash:
+		fmt.Fprintf(buf, "%d %d\n", r.hash, r.n)
+	}
+	return buf.Bytes()
+}
+
+// decodeFactResult decodes the result of [encodeFactResult].
+func decodeFactResult(data []byte) (*factResult, error) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	detail, err := ParseSummaryDetail(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	r := &factResult{detail: detail}
+	switch detail {
+	case SummaryFull:
+		r.vals = make(map[string]constant.Value)
+		for _, line := range lines[1:] {
+			v, err := decodeValue(line)
+			if err != nil {
+				return nil, err
+			}
+			r.vals[v.ExactString()] = v
+		}
+	case SummaryHash:
+		if len(lines) != 2 {
+			return nil, errors.New("malformed fingerprint")
+		}
+		if _, err := fmt.Sscanf(lines[1], "%d %d", &r.hash, &r.n); err != nil {
+			return nil, fmt.Errorf("malformed fingerprint: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected detail %s", detail)
+	}
+	return r, nil
+}
+
+// encodeValue encodes v on one line,
+// as its kind followed by a literal:
+// a boolean, a quoted string, an integer,
+// a fraction of integers (for a float),
+// or two fractions (for a complex number).
+func encodeValue(v constant.Value) string {
+	switch v.Kind() {
+	case constant.Bool:
+		return "bool " + v.ExactString()
+	case constant.String:
+		return "string " + strconv.Quote(constant.StringVal(v))
+	case constant.Int:
+		return "int " + v.ExactString()
+	case constant.Float:
+		return "float " + fraction(v)
+	case constant.Complex:
+		return "complex " + fraction(constant.Real(v)) + " " + fraction(constant.Imag(v))
+	}
+	return "unknown"
+}
+
+// fraction renders the float v exactly, as num/denom.
+func fraction(v constant.Value) string {
+	return constant.Num(v).ExactString() + "/" + constant.Denom(v).ExactString()
+}
+
+// decodeValue decodes the result of [encodeValue].
+func decodeValue(s string) (constant.Value, error) {
+	kind, lit, _ := strings.Cut(s, " ")
+
+	var v constant.Value
+	switch kind {
+	case "bool":
+		v = constant.MakeBool(lit == "true")
+	case "string":
+		v = constant.MakeFromLiteral(lit, token.STRING, 0)
+	case "int":
+		v = parseInt(lit)
+	case "float":
+		v = parseFraction(lit)
+	case "complex":
+		re, im, _ := strings.Cut(lit, " ")
+		v = constant.BinaryOp(parseFraction(re), token.ADD, constant.MakeImag(parseFraction(im)))
+	}
+	if v == nil || v.Kind() == constant.Unknown {
+		return nil, fmt.Errorf("malformed value %q", s)
+	}
+	return v, nil
+}
+
+// parseFraction parses the result of [fraction],
+// giving an unknown value if it is malformed.
+func parseFraction(s string) constant.Value {
+	num, denom, ok := strings.Cut(s, "/")
+	if !ok {
+		return constant.MakeUnknown()
+	}
+	n, d := parseInt(num), parseInt(denom)
+	if n.Kind() != constant.Int || d.Kind() != constant.Int || constant.Sign(d) == 0 {
+		return constant.MakeUnknown()
+	}
+	return constant.ToFloat(constant.BinaryOp(n, token.QUO, d))
+}
+
+// parseInt parses a decimal integer,
+// possibly negative,
+// giving an unknown value if it is malformed.
+func parseInt(s string) constant.Value {
+	if abs, ok := strings.CutPrefix(s, "-"); ok {
+		return constant.UnaryOp(token.SUB, constant.MakeFromLiteral(abs, token.INT, 0), 0)
+	}
+	return constant.MakeFromLiteral(s, token.INT, 0)
+}
@@ -0,0 +1,525 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// NilChannelOp is an operation that blocks forever,
+// a possible deadlock,
+// because its channel is always nil.
+// See [Scanner.CheckNilChannels].
+type NilChannelOp struct {
+	// Op is the send statement, receive expression, range statement,
+	// or select statement.
+	Op ast.Node
+
+	// Chan is the channel.
+	// It is nil for a select statement,
+	// all of whose cases are on nil channels.
+	Chan ast.Expr
+}
+
+// CheckNilChannels finds the sends, receives, and range statements
+// in the Scanner's files
+// on channels that are always nil,
+// and the select statements
+// whose cases are all on such channels and that have no default clause.
+// Each of these blocks forever.
+//
+// A channel is always nil
+// if every value it may have comes from a nil literal
+// or a variable declared without a value.
+// Select cases on such channels
+// (which are never chosen)
+// are not reported individually.
+func (s *Scanner) CheckNilChannels() []NilChannelOp {
+	if missingInfo(s.Info) != "" {
+		return nil
+	}
+
+	q := s.newQuery()
+
+	var result []NilChannelOp
+	check := func(op ast.Node, ch ast.Expr) {
+		if q.alwaysNil(ch) {
+			result = append(result, NilChannelOp{Op: op, Chan: ch})
+		}
+	}
+
+	var inspect func(ast.Node)
+	inspect = func(node ast.Node) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectStmt:
+				if len(n.Body.List) > 0 && q.selectBlocks(n) {
+					result = append(result, NilChannelOp{Op: n})
+				}
+				// Check the bodies of the cases but not their communications.
+				for _, stmt := range n.Body.List {
+					for _, s := range stmt.(*ast.CommClause).Body {
+						inspect(s)
+					}
+				}
+				return false
+
+			case *ast.SendStmt:
+				check(n, n.Chan)
+
+			case *ast.UnaryExpr:
+				if n.Op == token.ARROW {
+					check(n, n.X)
+				}
+
+			case *ast.RangeStmt:
+				if _, ok := underlying(q.info.TypeOf(n.X)).(*types.Chan); ok {
+					check(n, n.X)
+				}
+			}
+			return true
+		})
+	}
+	for _, file := range s.Files {
+		inspect(file)
+	}
+	return result
+}
+
+// commReachable tells whether clause,
+// one of the clauses of a select statement,
+// may be chosen.
+// A case whose channel is always nil never is,
+// since communication on a nil channel blocks forever.
+// The default clause is always reachable:
+// no case may be ready
+// (and none ever is if they are all on nil channels).
+func (q *query) commReachable(clause *ast.CommClause) bool {
+	if clause.Comm == nil {
+		return true
+	}
+	ch := commChan(clause.Comm)
+	return ch == nil || !q.alwaysNil(ch)
+}
+
+// opBlocks tells whether stmt,
+// a statement that is not a select statement,
+// is a send or receive on a channel that is always nil,
+// which blocks forever.
+func (q *query) opBlocks(stmt ast.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *ast.SendStmt:
+		return q.alwaysNil(stmt.Chan)
+	case *ast.ExprStmt, *ast.AssignStmt:
+		ch := commChan(stmt)
+		return ch != nil && q.alwaysNil(ch)
+	}
+	return false
+}
+
+// selectBlocks tells whether sel,
+// a select statement,
+// blocks forever:
+// it has no default clause
+// and all its cases are on channels that are always nil.
+func (q *query) selectBlocks(sel *ast.SelectStmt) bool {
+	for _, stmt := range sel.Body.List {
+		if q.commReachable(stmt.(*ast.CommClause)) {
+			return false
+		}
+	}
+	return true
+}
+
+// commChan returns the channel operand of comm,
+// the communication of a select case,
+// or nil if it has an unexpected form.
+func commChan(comm ast.Stmt) ast.Expr {
+	var recv ast.Expr
+
+	switch comm := comm.(type) {
+	case *ast.SendStmt:
+		return comm.Chan
+	case *ast.ExprStmt:
+		recv = comm.X
+	case *ast.AssignStmt:
+		if len(comm.Rhs) == 1 {
+			recv = comm.Rhs[0]
+		}
+	}
+
+	if u, ok := ast.Unparen(recv).(*ast.UnaryExpr); ok && u.Op == token.ARROW {
+		return u.X
+	}
+	return nil
+}
+
+// alwaysNil tells whether expr,
+// which has a nilable type such as a channel,
+// is provably nil:
+// every value it may have comes from a nil literal
+// or a variable declared without a value.
+func (q *query) alwaysNil(expr ast.Expr) bool {
+	// This is a question asked in passing,
+	// e.g. while finding reachable statements,
+	// so it does not contribute to the reasons of the enclosing scan.
+	saved := q.why
+	q.why = nil
+	defer func() { q.why = saved }()
+
+	var (
+		found  bool
+		nonNil bool
+	)
+	// Timer channels are never nil,
+	// so there is no need to look for their sources.
+	follow := func(expr ast.Expr) bool { return !isTimerChan(expr, q.info) }
+
+	complete := q.sources(expr, follow, func(src source) {
+		found = true
+		if src.zero {
+			return
+		}
+		if tv, ok := q.info.Types[src.expr]; ok && tv.IsNil() {
+			return
+		}
+		nonNil = true
+	})
+	return complete && found && !nonNil
+}
+
+// chanSendsKey identifies the values sent on a channel variable in query.active.
+type chanSendsKey struct {
+	v      *types.Var
+	ranged bool
+}
+
+// chanArgKey identifies the values sent on a channel passed as an argument in query.active.
+type chanArgKey struct {
+	call   *ast.CallExpr
+	arg    int
+	ranged bool
+}
+
+// isTimerChan tells whether ch is a channel of the time package
+// that delivers the time when a timer fires:
+// the result of a call to time.After or time.Tick,
+// or the C field of a time.Timer or time.Ticker.
+// Receiving from one yields an external input,
+// like a value from the environment,
+// and does not depend on anything else in the program.
+func isTimerChan(ch ast.Expr, info *types.Info) bool {
+	switch ch := ast.Unparen(ch).(type) {
+	case *ast.CallExpr:
+		fn := calleeFunc(ch, info)
+		if fn == nil {
+			return false
+		}
+		name := funcName(fn)
+		return name == "time.After" || name == "time.Tick"
+
+	case *ast.SelectorExpr:
+		if ch.Sel.Name != "C" {
+			return false
+		}
+		sel, ok := info.Selections[ch]
+		if !ok || sel.Kind() != types.FieldVal {
+			return false
+		}
+		named, ok := types.Unalias(sel.Recv()).(*types.Named)
+		if ptr, isPtr := types.Unalias(sel.Recv()).(*types.Pointer); isPtr {
+			named, ok = types.Unalias(ptr.Elem()).(*types.Named)
+		}
+		if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "time" {
+			return false
+		}
+		return named.Obj().Name() == "Timer" || named.Obj().Name() == "Ticker"
+	}
+	return false
+}
+
+// scanReceive determines the values that a receive from ch may produce.
+// Only channels held in local variables,
+// made in the same function and not passed elsewhere,
+// are understood.
+// Timer channels (see [isTimerChan]) deliver external inputs.
+// If ranged is true,
+// the receive is that of a range statement,
+// which ends when the channel is closed
+// rather than producing the zero value.
+func (q *query) scanReceive(ch ast.Expr, ranged bool) (map[string]constant.Value, bool) {
+	if isTimerChan(ch, q.info) {
+		q.incomplete(ch, "receive from %s yields the time a timer fires, an external input", types.ExprString(ch))
+		return nil, false
+	}
+	ident, ok := ast.Unparen(ch).(*ast.Ident)
+	if !ok {
+		q.incomplete(ch, "receive from %s is not tracked", types.ExprString(ch))
+		return nil, false
+	}
+	v, ok := q.info.ObjectOf(ident).(*types.Var)
+	if !ok {
+		q.incomplete(ch, "receive from %s is not tracked", types.ExprString(ch))
+		return nil, false
+	}
+	return q.nested(ident, "channel "+ident.Name, func() (map[string]constant.Value, bool) {
+		return q.scanChanSends(v, ranged)
+	})
+}
+
+// chanMayBeClosed tells whether the channel ch may be closed
+// when it is received from.
+// Timer channels (see [isTimerChan]) never are.
+// Otherwise only channels held in local variables,
+// whose uses are all understood (see [query.scanChanSends])
+// and which are neither closed nor passed to functions that might close them,
+// are known to stay open.
+func (q *query) chanMayBeClosed(ch ast.Expr) bool {
+	if isTimerChan(ch, q.info) {
+		return false
+	}
+	ident, ok := ast.Unparen(ch).(*ast.Ident)
+	if !ok {
+		return true
+	}
+	v, ok := q.info.ObjectOf(ident).(*types.Var)
+	if !ok {
+		return true
+	}
+	if _, complete := q.scanChanSends(v, true); !complete {
+		return true
+	}
+	node := q.varScopeNode(v)
+	if node == nil {
+		return true
+	}
+
+	closed := false
+	inspectWithStack(node, func(n ast.Node, stack []ast.Node) bool {
+		if closed {
+			return false
+		}
+		ident, ok := n.(*ast.Ident)
+		if !ok || !identIsVar(ident, v, q.info) {
+			return true
+		}
+		parent, _, _ := parentOf(ident, stack)
+		if call, ok := parent.(*ast.CallExpr); ok && !isBuiltin(call, "len", q.info) && !isBuiltin(call, "cap", q.info) {
+			// A call to close,
+			// or to a function that may close it.
+			closed = true
+		}
+		return true
+	})
+	return closed
+}
+
+// scanChanSends determines the values that may be received from the channel variable v:
+// the values sent on it,
+// and the zero value if it is closed
+// (unless ranged is true; see [query.scanReceive]).
+func (q *query) scanChanSends(v *types.Var, ranged bool) (map[string]constant.Value, bool) {
+	v = v.Origin()
+
+	key := chanSendsKey{v: v, ranged: ranged}
+	if !q.enter(key) {
+		return nil, true
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		return nil, false
+	}
+	if isParam(node, v, q.info) {
+		q.incomplete(node, "%s is a parameter, so other values may be sent on it", v.Name())
+		return nil, false
+	}
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+
+	// made tells whether expr makes a new channel (or is nil),
+	// so that no values have been sent on it elsewhere.
+	made := func(expr ast.Expr) bool {
+		expr = ast.Unparen(expr)
+		if tv, ok := q.info.Types[expr]; ok && tv.IsNil() {
+			return true
+		}
+		call, ok := expr.(*ast.CallExpr)
+		return ok && isBuiltin(call, "make", q.info)
+	}
+
+	inspectWithStack(node, func(n ast.Node, stack []ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if !exprIsVar(lhs, v, q.info) {
+					continue
+				}
+				if len(n.Lhs) != len(n.Rhs) || !made(n.Rhs[i]) {
+					q.incomplete(n, "%s may be assigned a channel made elsewhere", v.Name())
+					complete = false
+				}
+			}
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) || len(n.Values) == 0 {
+					continue
+				}
+				if len(n.Values) != len(n.Names) || !made(n.Values[i]) {
+					q.incomplete(n, "%s may be assigned a channel made elsewhere", v.Name())
+					complete = false
+				}
+			}
+
+		case *ast.Ident:
+			if !identIsVar(n, v, q.info) || q.info.Defs[n] != nil {
+				return true
+			}
+			if !q.checkChanUse(n, stack, v, ranged, result) {
+				q.incomplete(n, "%s is used in a way that may send to it unseen", v.Name())
+				complete = false
+			}
+		}
+		return true
+	})
+
+	return result, complete
+}
+
+// checkChanUse examines a use of the channel variable v,
+// with ancestors in stack,
+// adding any values it sends
+// (or the zero value, if it closes v and ranged is false)
+// to result.
+// It reports false for uses that may send values unseen.
+func (q *query) checkChanUse(ident *ast.Ident, stack []ast.Node, v *types.Var, ranged bool, result map[string]constant.Value) bool {
+	parent, child, _ := parentOf(ident, stack)
+
+	switch parent := parent.(type) {
+	case *ast.SendStmt:
+		if parent.Chan != child {
+			// Sending the channel itself on another channel.
+			return false
+		}
+		vals, ok := q.sentValues(parent)
+		union(result, vals)
+		if !ok {
+			q.incomplete(parent, "values sent on %s are not all known", v.Name())
+		}
+		return ok
+
+	case *ast.UnaryExpr:
+		return parent.Op == token.ARROW
+
+	case *ast.CallExpr:
+		switch {
+		case isBuiltin(parent, "close", q.info):
+			// Receiving from a closed channel yields the zero value,
+			// but ranging over one ends.
+			ch, ok := underlying(v.Type()).(*types.Chan)
+			if !ok {
+				return false
+			}
+			if ranged {
+				return true
+			}
+			zero, ok := zeroValue(ch.Elem())
+			if ok {
+				result[zero.ExactString()] = zero
+			}
+			return ok
+		case isBuiltin(parent, "len", q.info), isBuiltin(parent, "cap", q.info):
+			return true
+		}
+		for i, arg := range parent.Args {
+			if arg == child {
+				// Passing v to a function (often one started as a goroutine),
+				// which may send on it.
+				return q.argChanSends(parent, i, ranged, result)
+			}
+		}
+
+	case *ast.RangeStmt:
+		return parent.X == child
+
+	case *ast.BinaryExpr:
+		// Comparison with nil.
+		return true
+
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == child {
+				// Assigning v itself is handled by the caller.
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sentValues determines the values that send sends.
+// They are those of its value where the send runs,
+// so what is known where the receive runs (see [query.narrowed]) does not apply,
+// but what [Scanner.AnalyzeFunc] has established at the send itself does.
+func (q *query) sentValues(send *ast.SendStmt) (map[string]constant.Value, bool) {
+	if set, ok := q.sent[send]; ok {
+		return cloneVals(set.vals), set.complete
+	}
+	saved := q.narrowed
+	q.narrowed = nil
+	defer func() { q.narrowed = saved }()
+	return q.scan(send.Value)
+}
+
+// argChanSends adds the values sent on the channel that is argument i of call,
+// by the function called,
+// to result,
+// as in [query.checkChanUse].
+// It reports false if the function may send values unseen.
+func (q *query) argChanSends(call *ast.CallExpr, i int, ranged bool, result map[string]constant.Value) bool {
+	key := chanArgKey{call: call, arg: i, ranged: ranged}
+	if !q.enter(key) {
+		return true
+	}
+	defer q.leave(key)
+
+	callees, complete := q.callees(call.Fun)
+	for _, c := range callees {
+		params := c.sig.Params()
+		if call.Ellipsis.IsValid() || (c.sig.Variadic() && i >= params.Len()-1) {
+			q.incomplete(call, "unsupported variadic call of %s", types.ExprString(call.Fun))
+			complete = false
+			continue
+		}
+		if i >= params.Len() {
+			continue
+		}
+		p := params.At(i).Origin()
+		if ch, ok := underlying(p.Type()).(*types.Chan); ok && ch.Dir() == types.RecvOnly {
+			// The function can neither send on it nor close it.
+			continue
+		}
+
+		q.frames = append(q.frames, newFrame(call, call.Fun, c.sig, len(q.frames), q.info))
+		inspectWithStack(c.body, func(n ast.Node, stack []ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok || !identIsVar(ident, p, q.info) {
+				return true
+			}
+			if !q.checkChanUse(ident, stack, p, ranged, result) {
+				q.incomplete(ident, "%s is used in a way that may send to it unseen", p.Name())
+				complete = false
+			}
+			return true
+		})
+		q.frames = q.frames[:len(q.frames)-1]
+	}
+	return complete
+}
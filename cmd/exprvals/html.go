@@ -0,0 +1,145 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"html/template"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bobg/exprvals"
+)
+
+// htmlFile is a source file as rendered by writeHTML.
+type htmlFile struct {
+	Name  string
+	Lines []htmlLine
+}
+
+// htmlLine is a line of source
+// with the values of the variables in scope
+// at the first statement beginning on it, if any.
+type htmlLine struct {
+	Num    int
+	Text   string
+	Values string
+}
+
+var htmlTemplate = template.Must(template.New("").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>exprvals</title>
+<style>
+body { font-family: sans-serif; }
+pre { line-height: 1.3; }
+.num { color: #999; user-select: none; }
+.values { background: #eef6ff; cursor: help; }
+.values:hover { background: #cce4ff; }
+</style>
+</head>
+<body>
+{{- range .}}
+<h2>{{.Name}}</h2>
+<pre>
+{{- range .Lines}}
+{{if .Values}}<span class="values" title="{{.Values}}">{{else}}<span>{{end}}<span class="num">{{printf "%5d" .Num}}</span>  {{.Text}}</span>
+{{- end}}
+</pre>
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTML writes the source of the files in pkgs as an HTML page,
+// annotating each line where a statement begins
+// with the values of the variables there,
+// as determined by [exprvals.Scanner.AnalyzeFunc].
+// The values appear when hovering over the line.
+// If joinLimit is positive,
+// the values are widened to that many where control flow meets
+// (see [exprvals.KLimitJoin]).
+// The values are rendered according to format.
+func writeHTML(w io.Writer, dir string, pkgs []*exprvals.Package, maxValues, joinLimit int, format exprvals.FormatOptions) error {
+	var files []htmlFile
+
+	for _, pkg := range pkgs {
+		s := &exprvals.Scanner{Files: pkg.Files, Info: pkg.Info, MaxValues: maxValues}
+		if joinLimit > 0 {
+			s.Join = exprvals.KLimitJoin(joinLimit)
+		}
+
+		for _, file := range pkg.Files {
+			filename := pkg.Fset.Position(file.Pos()).Filename
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+
+			// Map line numbers to the values at the first statement on them.
+			values := make(map[int]string)
+			for _, decl := range file.Decls {
+				fdecl, ok := decl.(*ast.FuncDecl)
+				if !ok || fdecl.Body == nil {
+					continue
+				}
+				fn, ok := pkg.Info.Defs[fdecl.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				fv := s.AnalyzeFunc(fn)
+				if fv == nil {
+					continue
+				}
+				for _, p := range fv.Points {
+					line := pkg.Fset.Position(p.Stmt.Pos()).Line
+					if _, ok := values[line]; ok || len(p.Values) == 0 {
+						continue
+					}
+					values[line] = describeValues(fv.Vars, p.Values, format)
+				}
+			}
+
+			name := filename
+			if rel, err := filepath.Rel(dir, filename); err == nil {
+				name = rel
+			}
+			hf := htmlFile{Name: name}
+			for i, text := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+				hf.Lines = append(hf.Lines, htmlLine{Num: i + 1, Text: text, Values: values[i+1]})
+			}
+			files = append(files, hf)
+		}
+	}
+
+	return htmlTemplate.Execute(w, files)
+}
+
+// describeValues renders the values of vars,
+// one variable per line,
+// as "name: v1, v2".
+// An incomplete set of values ends with "...".
+// The values are rendered according to format
+// (see [exprvals.FormatValue]).
+func describeValues(vars []*types.Var, values map[*types.Var]exprvals.Result, format exprvals.FormatOptions) string {
+	var lines []string
+	for _, v := range vars {
+		res, ok := values[v]
+		if !ok {
+			continue
+		}
+		vals := make([]string, 0, len(res.Values))
+		for _, k := range slices.Sorted(maps.Keys(res.Values)) {
+			vals = append(vals, exprvals.FormatValue(res.Values[k], v.Type(), format))
+		}
+		if !res.Complete {
+			vals = append(vals, "...")
+		}
+		lines = append(lines, v.Name()+": "+strings.Join(vals, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,472 @@
+// Command exprvals reports the possible values of the variables,
+// parameters, and function results in the Go packages in a directory tree.
+//
+// Usage:
+//
+//	exprvals -report FORMAT [-max-values N] [-j N] [-progress] [-vendor] [-detail RULES] [-join-limit N] [-skip-generated] [-chars] [DIR]
+//	exprvals -gentest FUNC [-result N] [DIR]
+//	exprvals -writers FILE:LINE:COL [DIR]
+//	exprvals -survey [-vendor] [DIR]
+//
+// FORMAT is json, csv, or html.
+// DIR defaults to the current directory.
+//
+// The json and csv formats give the possible values of each variable
+// throughout its scope.
+// The csv format has a row for each value.
+// The html format renders the source code,
+// showing the values of the variables in scope at each statement,
+// as they evolve through each function,
+// when hovering over the statement's line.
+//
+// If N is positive,
+// sets of more than N values are truncated to N,
+// bounding the memory used on large code bases.
+// The json and csv reports analyze up to -j packages at a time
+// (by default, as many as there are CPUs),
+// each one after the packages it imports,
+// so that function results found in one package
+// are known in the packages that import it.
+// With -progress,
+// a line is written to standard error as each package is finished,
+// and at the end,
+// one giving the memory used by the results retained for the packages that import them.
+//
+// RULES reduce that memory:
+// a comma-separated list of PATTERN=DETAIL,
+// where PATTERN is an import path in which ... matches anything
+// and DETAIL is full (the default), hash, or top.
+// See [exprvals.SummaryDetail].
+// For example,
+// -detail 'github.com/...=top' keeps only the results of first-party functions
+// when analyzing vendored dependencies.
+//
+// Packages in the module's vendor directory
+// that the others import
+// are analyzed too,
+// so that their function bodies are available,
+// but are reported only with -vendor.
+// With -skip-generated,
+// the json and csv reports leave out generated files.
+// Positions in generated files are reported
+// as adjusted by their //line directives.
+//
+// With -gentest,
+// exprvals instead writes a Go test
+// that checks that the function FUNC
+// (a full name like example.com/mod/pkg.Func)
+// returns only the values found for its result N
+// (by default, the first).
+// See [exprvals.Scanner.GenerateTest].
+//
+// With -writers,
+// exprvals instead lists the places that may write the value
+// of the expression at the given position
+// (the innermost one there, typically a variable),
+// one per line,
+// in the FILE:LINE:COL: form that editors can navigate.
+// The position may also be in a file that Go code was generated from,
+// as named by //line directives.
+// See [exprvals.Scanner.Writers].
+//
+// With -survey,
+// exprvals instead writes a JSON list
+// of the kinds of statements and expressions in the packages,
+// with how many of each appear,
+// how many the analysis examined,
+// how many of those it could not analyze,
+// and a summary of its support for the kind:
+// full, partial, none, or unexamined.
+// That helps judge whether exprvals will work well on a code base.
+// See [exprvals.Survey].
+//
+// Only values of basic type
+// (strings, numbers, and booleans, and named types based on them)
+// are reported.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+
+	"github.com/bobg/exprvals"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "exprvals: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// usage is the error reported for misuse of the command,
+// listing its forms.
+const usage = `usage:
+  exprvals -report FORMAT [-max-values N] [-j N] [-progress] [-vendor] [-detail RULES] [-join-limit N] [-skip-generated] [-chars] [DIR]
+  exprvals -gentest FUNC [-result N] [DIR]
+  exprvals -writers FILE:LINE:COL [DIR]
+  exprvals -survey [-vendor] [DIR]`
+
+func run() error {
+	var (
+		report    = flag.String("report", "", "report format: json, csv, or html")
+		maxValues = flag.Int("max-values", 0, "if positive, truncate value sets to this size")
+		workers   = flag.Int("j", 0, "number of packages to analyze at a time (default: number of CPUs)")
+		progress  = flag.Bool("progress", false, "report progress on standard error")
+		vendor    = flag.Bool("vendor", false, "also report on vendored packages")
+		gentest   = flag.String("gentest", "", "write a test checking the values of this function's result")
+		resultIdx = flag.Int("result", 0, "with -gentest, the index of the result to check")
+		writers   = flag.String("writers", "", "list the writers of the value of the expression at FILE:LINE:COL")
+		detail    = flag.String("detail", "", "detail of retained function results, as PATTERN=full|hash|top,...")
+		joinLimit = flag.Int("join-limit", 0, "with -report html, if positive, widen to this many values where control flow meets")
+		skipGen   = flag.Bool("skip-generated", false, "with -report json or csv, leave out generated files")
+		survey    = flag.Bool("survey", false, "report the kinds of syntax in the packages and how well they are supported")
+		chars     = flag.Bool("chars", false, "with -report, render byte and rune values as quoted characters")
+	)
+	flag.Parse()
+
+	dir := "."
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		dir = flag.Arg(0)
+	default:
+		return errors.New(usage)
+	}
+
+	if *gentest != "" {
+		return genTest(dir, *gentest, *resultIdx)
+	}
+	if *writers != "" {
+		return listWriters(dir, *writers)
+	}
+	if *survey {
+		return writeSurvey(os.Stdout, dir, *vendor)
+	}
+
+	var write func(io.Writer, []entry) error
+	switch *report {
+	case "json":
+		write = writeJSON
+	case "csv":
+		write = writeCSV
+	case "html":
+	case "":
+		return fmt.Errorf("-report is required")
+	default:
+		return fmt.Errorf("unknown report format %q", *report)
+	}
+
+	format := exprvals.FormatOptions{Chars: *chars}
+
+	rules, err := parseDetailRules(*detail)
+	if err != nil {
+		return err
+	}
+
+	pkgs, err := exprvals.LoadPackages(dir)
+	if err != nil {
+		return err
+	}
+
+	if *report == "html" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if !*vendor {
+			pkgs = slices.DeleteFunc(pkgs, func(pkg *exprvals.Package) bool { return pkg.Vendored })
+		}
+		return writeHTML(os.Stdout, abs, pkgs, *maxValues, *joinLimit, format)
+	}
+
+	var onDone func(*exprvals.Package, int)
+	if *progress {
+		onDone = func(pkg *exprvals.Package, done int) {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", done, len(pkgs), pkg.Path)
+		}
+	}
+	var stats exprvals.SummaryStats
+	reports := exprvals.ReportPackages(pkgs, exprvals.ReportOptions{
+		MaxValues:     *maxValues,
+		Workers:       *workers,
+		Progress:      onDone,
+		Detail:        rules,
+		Stats:         &stats,
+		SkipGenerated: *skipGen,
+		Format:        format,
+	})
+	if *progress {
+		var total int
+		for _, n := range stats.Bytes {
+			total += n
+		}
+		fmt.Fprintf(os.Stderr, "retained %d bytes of function results\n", total)
+	}
+
+	var entries []entry
+	for i, pkg := range pkgs {
+		if pkg.Vendored && !*vendor {
+			continue
+		}
+		for _, e := range reports[i] {
+			entries = append(entries, entry{
+				Package:   pkg.Path,
+				Kind:      e.Kind,
+				Func:      e.Func,
+				Name:      e.Name,
+				Pos:       e.Pos.String(),
+				Values:    e.Values,
+				Complete:  e.Complete,
+				Truncated: e.Truncated,
+			})
+		}
+	}
+
+	return write(os.Stdout, entries)
+}
+
+// surveyEntry is a survey entry as written by this command.
+type surveyEntry struct {
+	Kind     string `json:"kind"`
+	Count    int    `json:"count"`
+	Examined int    `json:"examined"`
+	Punted   int    `json:"punted"`
+	Support  string `json:"support"`
+}
+
+// writeSurvey writes the survey of the packages in dir as JSON,
+// leaving out vendored packages unless vendor is true.
+func writeSurvey(w io.Writer, dir string, vendor bool) error {
+	pkgs, err := exprvals.LoadPackages(dir)
+	if err != nil {
+		return err
+	}
+	if !vendor {
+		pkgs = slices.DeleteFunc(pkgs, func(pkg *exprvals.Package) bool { return pkg.Vendored })
+	}
+
+	var entries []surveyEntry
+	for _, e := range exprvals.Survey(pkgs) {
+		entries = append(entries, surveyEntry{
+			Kind:     e.Kind,
+			Count:    e.Count,
+			Examined: e.Examined,
+			Punted:   e.Punted,
+			Support:  e.Support.String(),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// parseDetailRules parses the value of the -detail flag,
+// a comma-separated list of PATTERN=DETAIL.
+func parseDetailRules(s string) ([]exprvals.DetailRule, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var rules []exprvals.DetailRule
+	for _, item := range strings.Split(s, ",") {
+		pattern, name, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("-detail item %q is not of the form PATTERN=DETAIL", item)
+		}
+		d, err := exprvals.ParseSummaryDetail(name)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, exprvals.DetailRule{Pattern: pattern, Detail: d})
+	}
+	return rules, nil
+}
+
+// genTest writes a test for result idx of the function with the given full name
+// in the packages in dir.
+func genTest(dir, name string, idx int) error {
+	pkgs, err := exprvals.LoadPackages(dir)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range pkgs {
+		for _, obj := range pkg.Info.Defs {
+			fn, ok := obj.(*types.Func)
+			if !ok || fn.FullName() != name {
+				continue
+			}
+			s := &exprvals.Scanner{Files: pkg.Files, Info: pkg.Info}
+			src, err := s.GenerateTest(pkg.Fset, fn, idx)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(src)
+			return err
+		}
+	}
+	return fmt.Errorf("function %s not found", name)
+}
+
+// listWriters writes the writers of the value of the expression at the position at,
+// in the form FILE:LINE:COL,
+// in the packages in dir.
+func listWriters(dir, at string) error {
+	filename, line, col, err := parsePosition(at)
+	if err != nil {
+		return err
+	}
+	filename, err = filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+
+	pkgs, err := exprvals.LoadPackages(dir)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			pos, err := findPos(pkg.Fset.File(file.Pos()), filename, line, col)
+			if err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+			if !pos.IsValid() {
+				continue
+			}
+			path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+			var expr ast.Expr
+			for _, n := range path {
+				if e, ok := n.(ast.Expr); ok {
+					expr = e
+					break
+				}
+			}
+			if expr == nil {
+				return fmt.Errorf("no expression at %s", at)
+			}
+
+			s := &exprvals.Scanner{Files: pkg.Files, Info: pkg.Info}
+			res := s.Writers(expr)
+			for _, w := range res.Writers {
+				desc := types.ExprString(w.Expr)
+				if w.Zero {
+					desc += " (zero value)"
+				}
+				fmt.Printf("%s: %s\n", pkg.Fset.Position(w.Expr.Pos()), desc)
+			}
+			if !res.Complete {
+				fmt.Fprintf(os.Stderr, "writers of %s may be incomplete", types.ExprString(expr))
+				if res.Why != nil {
+					fmt.Fprintf(os.Stderr, ":\n%s", res.Why.Format(pkg.Fset))
+				} else {
+					fmt.Fprintln(os.Stderr)
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("file %s not found", filename)
+}
+
+// findPos finds the position in tf of line and col in filename,
+// which is either tf itself
+// or a file that part of tf was generated from,
+// according to its //line directives.
+// It returns token.NoPos if there is no such position in tf.
+func findPos(tf *token.File, filename string, line, col int) (token.Pos, error) {
+	if tf.Name() == filename {
+		if line > tf.LineCount() {
+			return token.NoPos, fmt.Errorf("no line %d", line)
+		}
+		return tf.LineStart(line) + token.Pos(col-1), nil
+	}
+	for i := 1; i <= tf.LineCount(); i++ {
+		start := tf.LineStart(i)
+		p := tf.PositionFor(start, true)
+		if p.Filename != filename || p.Line != line {
+			continue
+		}
+		if p.Column == 0 {
+			// The directive gives no column,
+			// so columns are as in tf.
+			p.Column = 1
+		}
+		return start + token.Pos(col-p.Column), nil
+	}
+	return token.NoPos, nil
+}
+
+// parsePosition parses a position of the form FILE:LINE:COL.
+func parsePosition(s string) (filename string, line, col int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 3 {
+		return "", 0, 0, fmt.Errorf("position %q is not of the form FILE:LINE:COL", s)
+	}
+	n := len(parts)
+	if line, err = strconv.Atoi(parts[n-2]); err != nil || line < 1 {
+		return "", 0, 0, fmt.Errorf("bad line number in %q", s)
+	}
+	if col, err = strconv.Atoi(parts[n-1]); err != nil || col < 1 {
+		return "", 0, 0, fmt.Errorf("bad column number in %q", s)
+	}
+	return strings.Join(parts[:n-2], ":"), line, col, nil
+}
+
+// entry is a report entry as written by this command.
+type entry struct {
+	Package   string   `json:"package"`
+	Kind      string   `json:"kind"`
+	Func      string   `json:"func,omitempty"`
+	Name      string   `json:"name"`
+	Pos       string   `json:"pos"`
+	Values    []string `json:"values"`
+	Complete  bool     `json:"complete"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+func writeJSON(w io.Writer, entries []entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// writeCSV writes one row per value of each entry,
+// so that values containing spaces or commas stay apart.
+// Values are written as in [exprvals.ReportEntry],
+// where a string value is quoted,
+// and an entry with no values gets a single row with an empty value.
+func writeCSV(w io.Writer, entries []entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"package", "kind", "func", "name", "pos", "value", "complete", "truncated"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		vals := e.Values
+		if len(vals) == 0 {
+			vals = []string{""}
+		}
+		for _, v := range vals {
+			row := []string{e.Package, e.Kind, e.Func, e.Name, e.Pos, v, strconv.FormatBool(e.Complete), strconv.FormatBool(e.Truncated)}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
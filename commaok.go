@@ -0,0 +1,190 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// isCommaOk tells whether expr,
+// the single right-hand side of a two-valued assignment or declaration,
+// is a type assertion, map index, or receive,
+// whose second value is an ok boolean.
+func isCommaOk(expr ast.Expr, info *types.Info) bool {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.TypeAssertExpr:
+		return true
+	case *ast.IndexExpr:
+		_, ok := underlying(info.TypeOf(expr.X)).(*types.Map)
+		return ok
+	case *ast.UnaryExpr:
+		return expr.Op == token.ARROW
+	}
+	return false
+}
+
+// scanCommaOk determines the values of result idx
+// of expr,
+// a comma-ok form (see [isCommaOk]).
+// Result 1 is the ok boolean,
+// and result 0 is the value,
+// which is the zero value when ok is false.
+func (q *query) scanCommaOk(expr ast.Expr, idx int) (map[string]constant.Value, bool) {
+	expr = ast.Unparen(expr)
+
+	mayTrue, mayFalse := q.commaOkMayBe(expr)
+	if idx == 1 {
+		result := make(map[string]constant.Value)
+		for _, b := range []bool{true, false} {
+			if (b && mayTrue) || (!b && mayFalse) {
+				v := constant.MakeBool(b)
+				result[v.ExactString()] = v
+			}
+		}
+		return result, true
+	}
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+	if mayTrue {
+		result, complete = q.scan(expr)
+		if result == nil {
+			result = make(map[string]constant.Value)
+		}
+	}
+	if mayFalse {
+		// The type of a comma-ok expression is recorded as a pair.
+		typ := q.info.TypeOf(expr)
+		if tuple, ok := typ.(*types.Tuple); ok && tuple.Len() == 2 {
+			typ = tuple.At(0).Type()
+		}
+		zero, ok := zeroValue(typ)
+		if ok {
+			result[zero.ExactString()] = zero
+		} else {
+			q.incomplete(expr, "zero value of %s is not a constant", types.ExprString(expr))
+			complete = false
+		}
+	}
+	return result, complete
+}
+
+// commaOkMayBe tells whether the ok boolean of expr,
+// a comma-ok form,
+// may be true and whether it may be false.
+// A type assertion succeeds or fails according to the dynamic types its operand may have.
+// A map lookup fails
+// if its key is never among the keys that may be in the map,
+// and succeeds
+// if its key is always in the map.
+// A receive fails if its channel may be closed
+// (see [query.chanMayBeClosed]).
+func (q *query) commaOkMayBe(expr ast.Expr) (mayTrue, mayFalse bool) {
+	// This is a question asked in passing,
+	// and the answer is complete in any case,
+	// so it does not contribute to the reasons of the enclosing scan.
+	saved := q.why
+	q.why = nil
+	defer func() { q.why = saved }()
+
+	switch expr := expr.(type) {
+	case *ast.TypeAssertExpr:
+		asserted := q.info.TypeOf(expr.Type)
+		if asserted == nil {
+			return true, true
+		}
+		if narrowed, ok := q.narrowedDynTypes(expr.X); ok {
+			for _, typ := range narrowed {
+				if assertable(typ, asserted) {
+					mayTrue = true
+				} else {
+					mayFalse = true
+				}
+			}
+			return mayTrue, mayFalse
+		}
+		isIface := func(e ast.Expr) bool {
+			return isInterface(q.info.TypeOf(e))
+		}
+		complete := q.sources(expr.X, isIface, func(src source) {
+			if src.zero {
+				// A nil interface.
+				mayFalse = true
+				return
+			}
+			if tv, ok := q.info.Types[src.expr]; ok && tv.IsNil() {
+				mayFalse = true
+				return
+			}
+			typ := q.info.TypeOf(src.expr)
+			switch {
+			case typ == nil || types.IsInterface(typ):
+				mayTrue, mayFalse = true, true
+			case assertable(typ, asserted):
+				mayTrue = true
+			default:
+				mayFalse = true
+			}
+		})
+		if !complete {
+			return true, true
+		}
+		return mayTrue, mayFalse
+
+	case *ast.IndexExpr:
+		index, indexComplete := q.scan(expr.Index)
+		if !indexComplete || len(index) == 0 {
+			return true, true
+		}
+
+		mayTrue = true
+		if keys, keysComplete := q.scanMapKeys(expr.X); keysComplete {
+			mayTrue = false
+			for k := range index {
+				if _, ok := keys[k]; ok {
+					mayTrue = true
+					break
+				}
+			}
+		}
+		if !mayTrue {
+			return false, true
+		}
+		if interfaceKeyed(q.info.TypeOf(expr.X)) {
+			// A present key with the same value may still differ in dynamic type.
+			return true, true
+		}
+
+		// The lookup succeeds for certain
+		// if each possible key is always in the map.
+		mv := q.scanMapVals(expr.X)
+		mayFalse = !mv.unkeyed.complete
+		for k := range index {
+			if !mv.present[k] {
+				mayFalse = true
+			}
+		}
+		return mayTrue, mayFalse
+
+	case *ast.UnaryExpr:
+		return true, q.chanMayBeClosed(expr.X)
+	}
+
+	return true, true
+}
+
+// assertable tells whether a value of the dynamic type typ
+// satisfies a type assertion (or a case of a type switch)
+// for the type asserted:
+// whether typ implements it,
+// if it is an interface type,
+// or is identical to it otherwise.
+func assertable(typ, asserted types.Type) bool {
+	if iface, ok := asserted.Underlying().(*types.Interface); ok {
+		return types.Implements(typ, iface)
+	}
+	return types.Identical(typ, asserted)
+}
@@ -0,0 +1,114 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// Inconsistency is a divergence between the values of a call's result
+// and those of the called function's result,
+// as found by [Scanner.CheckConsistency].
+type Inconsistency struct {
+	Call *ast.CallExpr
+	Func *types.Func
+	Idx  int
+
+	// CallResult is the result of [Scanner.ScanCallResult] for the call.
+	CallResult Result
+
+	// FuncResult combines the values of result Idx of Func's return statements,
+	// as [Report] does.
+	FuncResult Result
+
+	// Extra are the values of CallResult that FuncResult lacks,
+	// sorted by their ExactString representations.
+	Extra []constant.Value
+
+	// Msg describes the divergence.
+	Msg string
+}
+
+// CheckConsistency compares the values of result idx of call
+// with the values of that result of the called function
+// (found without regard to any call, as by [Report]),
+// and describes any divergence between them,
+// for debugging tools that scan both and get different answers.
+// The function's result is taken to cover all its calls,
+// so when it is complete,
+// the call's result should be complete too
+// and have no values that the function's result lacks.
+// A divergence is usually an artifact of caching
+// or of the context in which an expression was scanned.
+//
+// The opposite case,
+// a complete call result for an incomplete function result,
+// is expected,
+// since the call's arguments may supply what the function alone lacks,
+// and is not reported.
+// Nor are results that are truncated (see [Scanner.MaxValues])
+// or cut short (see [Scanner.Timeout]).
+//
+// The result is nil if there is no divergence,
+// or if call is not a call of a function or method declared in the Scanner's files.
+func (s *Scanner) CheckConsistency(call *ast.CallExpr, idx int) *Inconsistency {
+	if missingInfo(s.Info) != "" {
+		return nil
+	}
+	fn := calleeFunc(call, s.Info)
+	if fn == nil {
+		return nil
+	}
+	fn = fn.Origin()
+	if results := fn.Signature().Results(); idx < 0 || idx >= results.Len() {
+		return nil
+	}
+	decl, ok := findSmallestEnclosingNode(s.Files, fn.Scope()).(*ast.FuncDecl)
+	if !ok || decl.Body == nil {
+		return nil
+	}
+
+	callRes := s.ScanCallResult(call, idx)
+
+	funcRes := Result{Values: make(map[string]constant.Value), Complete: true}
+	for _, res := range s.returnResults(decl, idx) {
+		union(funcRes.Values, res.Values)
+		if !res.Complete && funcRes.Complete {
+			funcRes.Complete, funcRes.Why = false, res.Why
+		}
+		funcRes.Truncated = funcRes.Truncated || res.Truncated
+		funcRes.TimedOut = funcRes.TimedOut || res.TimedOut
+	}
+
+	if !funcRes.Complete || funcRes.Truncated || funcRes.TimedOut || callRes.Truncated || callRes.TimedOut {
+		return nil
+	}
+
+	var extra []constant.Value
+	for _, k := range exactStrings(callRes.Values) {
+		if _, ok := funcRes.Values[k]; !ok {
+			extra = append(extra, callRes.Values[k])
+		}
+	}
+
+	var msg string
+	switch {
+	case !callRes.Complete:
+		msg = fmt.Sprintf("result %d of the call is incomplete, but that of %s is complete", idx, fn.Name())
+	case len(extra) > 0:
+		msg = fmt.Sprintf("result %d of the call has values that that of %s lacks", idx, fn.Name())
+	default:
+		return nil
+	}
+
+	return &Inconsistency{
+		Call:       call,
+		Func:       fn,
+		Idx:        idx,
+		CallResult: callRes,
+		FuncResult: funcRes,
+		Extra:      extra,
+		Msg:        msg,
+	}
+}
@@ -0,0 +1,316 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sizes is used to determine the sizes of integer types for conversions.
+var sizes = types.SizesFor("gc", build.Default.GOARCH)
+
+// scanConversion determines the possible values of the conversion call to typ.
+//
+// If typ is a type parameter,
+// each value is converted to each type in the parameter's constraint
+// (e.g. to int8 and to int16 for a constraint of ~int8 | ~int16),
+// and values that cannot be converted to any of them are dropped.
+// This makes it possible to analyze the body of a generic function
+// without knowing how it is instantiated.
+// When the body is analyzed for a call,
+// as in convert[MyString]("x"),
+// the type argument of the call is used instead.
+func (q *query) scanConversion(call *ast.CallExpr, typ types.Type) (map[string]constant.Value, bool) {
+	if len(call.Args) != 1 {
+		q.incomplete(call, "unsupported conversion %s", types.ExprString(call))
+		return nil, false
+	}
+
+	if tp, ok := typ.(*types.TypeParam); ok {
+		if targ, ok := q.typeArg(tp); ok {
+			if _, ok := targ.Underlying().(*types.Basic); ok {
+				typ = targ
+			}
+		}
+	}
+
+	targets, ok := termSet(typ)
+	if !ok {
+		q.incomplete(call, "conversion to %s is not supported", typ)
+		return nil, false
+	}
+
+	vals, complete := q.scan(call.Args[0])
+
+	result := make(map[string]constant.Value)
+	for _, v := range vals {
+		for _, target := range targets {
+			if !convertible(v, target) {
+				// Impossible for this term of the constraint.
+				continue
+			}
+			cv, ok := convertValue(v, target)
+			if !ok {
+				q.incomplete(call, "conversion of %s to %s is not supported", v.ExactString(), target)
+				complete = false
+				continue
+			}
+			result[cv.ExactString()] = cv
+		}
+	}
+
+	return result, complete
+}
+
+// termSet returns the basic types to which a value of type typ may belong.
+// For a basic type, that is just the type itself.
+// For a type parameter,
+// it is the underlying types of the terms in its constraint
+// (the intersection of the term sets of the constraint's embedded elements).
+// The boolean result is false if the set is not known to consist only of basic types;
+// for instance, if typ is a type parameter constrained by any.
+func termSet(typ types.Type) ([]*types.Basic, bool) {
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		return []*types.Basic{u}, true
+
+	case *types.Interface:
+		var (
+			result []*types.Basic
+			found  bool
+		)
+		for i := 0; i < u.NumEmbeddeds(); i++ {
+			terms, ok := termSet(u.EmbeddedType(i))
+			if !ok {
+				// Does not restrict the types (e.g. comparable, or a method set).
+				continue
+			}
+			if !found {
+				result, found = terms, true
+				continue
+			}
+			result = intersectBasics(result, terms)
+		}
+		return result, found
+
+	case *types.Union:
+		var result []*types.Basic
+		for i := 0; i < u.Len(); i++ {
+			terms, ok := termSet(u.Term(i).Type())
+			if !ok {
+				return nil, false
+			}
+			result = unionBasics(result, terms)
+		}
+		return result, true
+	}
+
+	return nil, false
+}
+
+func unionBasics(a, b []*types.Basic) []*types.Basic {
+	result := a
+	for _, t := range b {
+		if !containsBasic(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func intersectBasics(a, b []*types.Basic) []*types.Basic {
+	var result []*types.Basic
+	for _, t := range a {
+		if containsBasic(b, t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func containsBasic(list []*types.Basic, t *types.Basic) bool {
+	for _, elt := range list {
+		if elt.Kind() == t.Kind() {
+			return true
+		}
+	}
+	return false
+}
+
+// convertible tells whether a value like v can be converted to the basic type to.
+func convertible(v constant.Value, to *types.Basic) bool {
+	info := to.Info()
+	switch v.Kind() {
+	case constant.Bool:
+		return info&types.IsBoolean != 0
+	case constant.String:
+		return info&types.IsString != 0
+	case constant.Int:
+		return info&(types.IsNumeric|types.IsString) != 0
+	case constant.Float:
+		return info&(types.IsInteger|types.IsFloat) != 0
+	case constant.Complex:
+		return info&types.IsComplex != 0
+	}
+	return false
+}
+
+// convertValue converts v to the basic type to,
+// as a conversion at run time would.
+// An integer converted to a string type
+// is the UTF-8 encoding of the rune with that value
+// (see [intRune]),
+// not its decimal representation.
+// Integers wrap around and floating-point numbers are truncated toward zero
+// when converted to integer types,
+// and are rounded to the precision of the target floating-point type.
+// The boolean result is false if the conversion is not supported.
+func convertValue(v constant.Value, to *types.Basic) (constant.Value, bool) {
+	info := to.Info()
+
+	switch {
+	case info&types.IsString != 0 && v.Kind() == constant.Int:
+		return constant.MakeString(string(intRune(v))), true
+
+	case info&types.IsBoolean != 0, info&types.IsString != 0:
+		return v, true
+
+	case info&types.IsInteger != 0:
+		if v.Kind() == constant.Float {
+			num, denom := constant.Num(v), constant.Denom(v)
+			if num.Kind() != constant.Int || denom.Kind() != constant.Int {
+				return nil, false
+			}
+			v = constant.BinaryOp(num, token.QUO_ASSIGN, denom) // integer division, truncating toward zero
+		}
+		return wrapInt(v, to), true
+
+	case info&types.IsFloat != 0:
+		f, _ := constant.Float64Val(v)
+		if to.Kind() == types.Float32 {
+			f = float64(float32(f))
+		}
+		return constant.MakeFloat64(f), true
+
+	case info&types.IsComplex != 0:
+		// TODO: rounding to the precision of complex64 and complex128.
+		return constant.ToComplex(v), true
+	}
+
+	return nil, false
+}
+
+// wrapInt reduces the integer v to the range of the integer type to,
+// wrapping around as a conversion at run time would.
+func wrapInt(v constant.Value, to *types.Basic) constant.Value {
+	bits := uint(8 * sizes.Sizeof(to))
+	mod := constant.Shift(constant.MakeInt64(1), token.SHL, bits)
+
+	v = constant.BinaryOp(v, token.REM, mod)
+	if constant.Sign(v) < 0 {
+		v = constant.BinaryOp(v, token.ADD, mod)
+	}
+	if to.Info()&types.IsUnsigned == 0 {
+		half := constant.Shift(constant.MakeInt64(1), token.SHL, bits-1)
+		if constant.Compare(v, token.GEQ, half) {
+			v = constant.BinaryOp(v, token.SUB, mod)
+		}
+	}
+	return v
+}
+
+// intRune returns the rune that the integer v denotes
+// when converted to a string:
+// v itself if it is a valid Unicode code point,
+// and otherwise the replacement character U+FFFD.
+func intRune(v constant.Value) rune {
+	n, exact := constant.Int64Val(v)
+	if !exact || n < 0 || n > unicode.MaxRune || !utf8.ValidRune(rune(n)) {
+		return utf8.RuneError
+	}
+	return rune(n)
+}
+
+// StringConversion is a conversion of an integer to a string
+// that may be meant to format the integer as a number,
+// because the integer may have values
+// that do not denote printable characters.
+// See [Scanner.CheckStringConversions].
+type StringConversion struct {
+	// Conv is the conversion, string(x).
+	Conv *ast.CallExpr
+
+	// Values are the values of x that do not denote printable characters:
+	// control characters,
+	// invalid code points
+	// (which are converted to U+FFFD),
+	// and the like.
+	Values []constant.Value
+}
+
+// CheckStringConversions finds the conversions in the Scanner's files
+// of integers to strings
+// that may be mistakes,
+// as when string(n) is meant to produce "7" but produces "\a".
+// It is like the stringintconv check of go vet,
+// but a conversion is reported
+// only if the integer may have values
+// that do not denote printable characters
+// (see [unicode.IsPrint]).
+// As with go vet,
+// conversions of values of type rune or byte are taken to be deliberate
+// and are not reported,
+// nor are conversions of untyped constants.
+func (s *Scanner) CheckStringConversions() []StringConversion {
+	if missingInfo(s.Info) != "" {
+		return nil
+	}
+
+	q := s.newQuery()
+
+	var result []StringConversion
+	for _, file := range s.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			tv, ok := s.Info.Types[call.Fun]
+			if !ok || !tv.IsType() {
+				return true
+			}
+			if to, ok := tv.Type.Underlying().(*types.Basic); !ok || to.Info()&types.IsString == 0 {
+				return true
+			}
+			argType := s.Info.TypeOf(call.Args[0])
+			from, ok := underlying(argType).(*types.Basic)
+			if !ok || from.Info()&types.IsInteger == 0 || from.Info()&types.IsUntyped != 0 {
+				return true
+			}
+			if b, ok := argType.(*types.Basic); ok && (b.Name() == "byte" || b.Name() == "rune") {
+				return true
+			}
+
+			vals, _ := q.scan(call.Args[0])
+			var suspect []constant.Value
+			for _, k := range exactStrings(vals) {
+				v := vals[k]
+				if v.Kind() != constant.Int {
+					continue
+				}
+				if r := intRune(v); r == utf8.RuneError || !unicode.IsPrint(r) {
+					suspect = append(suspect, v)
+				}
+			}
+			if len(suspect) > 0 {
+				result = append(result, StringConversion{Conv: call, Values: suspect})
+			}
+			return true
+		})
+	}
+	return result
+}
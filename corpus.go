@@ -0,0 +1,382 @@
+package exprvals
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Package is a parsed and type-checked Go package,
+// as loaded by [LoadPackages].
+type Package struct {
+	Path  string
+	Dir   string
+	Fset  *token.FileSet
+	Files []*ast.File
+	Types *types.Package
+	Info  *types.Info
+
+	// Imports are the loaded packages that this one imports.
+	// Imports from outside the module are not included,
+	// unless they are vendored.
+	Imports []*Package
+
+	// Vendored tells whether the package was loaded from the module's vendor directory.
+	Vendored bool
+}
+
+// LoadPackages parses and type-checks the Go packages in dir and its subdirectories.
+// Test files are skipped,
+// as are directories named testdata or beginning with . or _.
+// Files are selected according to the build constraints of the default [build.Context].
+//
+// Imports of packages in the same module as dir
+// (according to the nearest go.mod file in dir or one of its parents)
+// are satisfied from the loaded packages.
+// So are imports of packages in the module's vendor directory,
+// if it has one.
+// Other imports are satisfied from compiler export data,
+// located by running "go list" in dir.
+//
+// The resulting packages are sorted by import path.
+// They include the vendored packages imported by the others,
+// marked as such,
+// so that their function bodies are available for analysis.
+func LoadPackages(dir string) ([]*Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	modRoot, modPath, err := findModule(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loader{
+		fset:     token.NewFileSet(),
+		dirs:     make(map[string]string),
+		vendored: make(map[string]bool),
+		pkgs:     make(map[string]*Package),
+		loading:  make(map[string]bool),
+	}
+	l.fallback = importer.ForCompiler(l.fset, "gc", func(path string) (io.ReadCloser, error) {
+		return exportData(dir, path)
+	})
+
+	// In module mode,
+	// the packages in the vendor directory at the module root
+	// have the import paths of the originals.
+	vendorDir := filepath.Join(modRoot, "vendor")
+	if modPath == "" {
+		vendorDir = ""
+	}
+
+	err = l.walk(dir, func(p string) (string, error) {
+		if p == vendorDir {
+			return "", filepath.SkipDir
+		}
+		rel, err := filepath.Rel(modRoot, p)
+		if err != nil {
+			return "", err
+		}
+		importPath := path.Join(modPath, filepath.ToSlash(rel))
+		if importPath == "" || importPath == "." {
+			importPath = "."
+		}
+		return importPath, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if vendorDir != "" {
+		if _, err := os.Stat(vendorDir); err == nil {
+			err = l.walk(vendorDir, func(p string) (string, error) {
+				if p == vendorDir {
+					return "", nil
+				}
+				rel, err := filepath.Rel(vendorDir, p)
+				if err != nil {
+					return "", err
+				}
+				importPath := filepath.ToSlash(rel)
+				l.vendored[importPath] = true
+				return importPath, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Vendored packages are loaded only as needed by the others.
+	var result []*Package
+	for importPath := range l.dirs {
+		if l.vendored[importPath] {
+			continue
+		}
+		pkg, err := l.load(importPath)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != nil {
+			result = append(result, pkg)
+		}
+	}
+	for importPath := range l.vendored {
+		if pkg := l.pkgs[importPath]; pkg != nil {
+			result = append(result, pkg)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+
+	return result, nil
+}
+
+// loader loads the packages for [LoadPackages].
+type loader struct {
+	fset     *token.FileSet
+	dirs     map[string]string // import path -> directory
+	vendored map[string]bool   // import paths of packages in the vendor directory
+	pkgs     map[string]*Package
+	loading  map[string]bool
+	fallback types.Importer
+}
+
+// walk records in l.dirs the directories in the tree rooted at root
+// that may contain packages,
+// skipping testdata directories,
+// directories beginning with . or _,
+// and nested modules.
+// The function importPath gives the import path for each directory,
+// or the empty string to skip it (but not its subdirectories).
+func (l *loader) walk(root string, importPath func(string) (string, error)) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != root {
+			name := d.Name()
+			if name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+				// A nested module.
+				return filepath.SkipDir
+			}
+		}
+		ip, err := importPath(p)
+		if err != nil || ip == "" {
+			return err
+		}
+		l.dirs[ip] = p
+		return nil
+	})
+}
+
+// load loads the package with the given import path,
+// which must be in l.dirs.
+// It returns nil (and no error) if the directory contains no Go files.
+func (l *loader) load(importPath string) (*Package, error) {
+	if pkg, ok := l.pkgs[importPath]; ok {
+		return pkg, nil
+	}
+	if l.loading[importPath] {
+		return nil, fmt.Errorf("import cycle involving %s", importPath)
+	}
+	l.loading[importPath] = true
+	defer delete(l.loading, importPath)
+
+	dir := l.dirs[importPath]
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		file, err := parser.ParseFile(l.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		l.pkgs[importPath] = nil
+		return nil, nil
+	}
+
+	info := newInfo()
+	conf := types.Config{Importer: importerFunc(l.importPkg)}
+	tpkg, err := conf.Check(importPath, l.fset, files, info)
+	if err != nil {
+		return nil, fmt.Errorf("type-checking %s: %w", importPath, err)
+	}
+
+	pkg := &Package{
+		Path:     importPath,
+		Dir:      dir,
+		Fset:     l.fset,
+		Files:    files,
+		Types:    tpkg,
+		Info:     info,
+		Vendored: l.vendored[importPath],
+	}
+	for _, imp := range tpkg.Imports() {
+		if dep := l.pkgs[imp.Path()]; dep != nil {
+			pkg.Imports = append(pkg.Imports, dep)
+		}
+	}
+	l.pkgs[importPath] = pkg
+	return pkg, nil
+}
+
+func (l *loader) importPkg(importPath string) (*types.Package, error) {
+	if _, ok := l.dirs[importPath]; ok {
+		pkg, err := l.load(importPath)
+		if err != nil {
+			return nil, err
+		}
+		if pkg == nil {
+			return nil, fmt.Errorf("no Go files for %s", importPath)
+		}
+		return pkg.Types, nil
+	}
+	return l.fallback.Import(importPath)
+}
+
+type importerFunc func(string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// findModule finds the nearest go.mod file in dir or its parents,
+// returning the module's root directory and module path.
+// If there is none, dir is the root and the module path is empty.
+func findModule(dir string) (root, modPath string, err error) {
+	for d := dir; ; {
+		f, err := os.Open(filepath.Join(d, "go.mod"))
+		if err == nil {
+			defer f.Close()
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				fields := strings.Fields(sc.Text())
+				if len(fields) >= 2 && fields[0] == "module" {
+					return d, strings.Trim(fields[1], `"`), nil
+				}
+			}
+			if err := sc.Err(); err != nil {
+				return "", "", err
+			}
+			return "", "", fmt.Errorf("no module directive in %s", f.Name())
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir, "", nil
+		}
+		d = parent
+	}
+}
+
+// exportData locates and opens the compiler export data for the package with the given import path,
+// using "go list" in dir.
+func exportData(dir, importPath string) (io.ReadCloser, error) {
+	cmd := exec.Command("go", "list", "-export", "-f", "{{.Export}}", importPath)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("locating export data for %s: %w: %s", importPath, err, strings.TrimSpace(stderr.String()))
+	}
+	filename := strings.TrimSpace(string(out))
+	if filename == "" {
+		return nil, fmt.Errorf("no export data for %s", importPath)
+	}
+	return os.Open(filename)
+}
+
+// CorpusResult is the outcome of scanning one expression in [RunCorpus].
+type CorpusResult struct {
+	Pkg      *Package
+	Expr     ast.Expr
+	Pos      token.Position
+	Values   map[string]constant.Value
+	Complete bool
+	Duration time.Duration
+}
+
+// RunCorpus loads the packages in dir (see [LoadPackages])
+// and runs [Scan] on every expression for which sel returns true,
+// timing each one.
+// It is meant for benchmarking exprvals on real code
+// and for producing reproducible reports of slow or imprecise scans.
+//
+// The results are in package order and,
+// within each package,
+// in the order the expressions appear in the source.
+func RunCorpus(dir string, sel func(ast.Expr, *types.Info) bool) ([]CorpusResult, error) {
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []CorpusResult
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				expr, ok := n.(ast.Expr)
+				if !ok || !sel(expr, pkg.Info) {
+					return true
+				}
+				start := time.Now()
+				vals, complete := Scan(expr, pkg.Files, pkg.Info)
+				results = append(results, CorpusResult{
+					Pkg:      pkg,
+					Expr:     expr,
+					Pos:      pkg.Fset.Position(expr.Pos()),
+					Values:   vals,
+					Complete: complete,
+					Duration: time.Since(start),
+				})
+				return true
+			})
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,993 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+)
+
+// FuncValues is the outcome of [Scanner.AnalyzeFunc]:
+// the possible values of a function's variables
+// at each of its statements.
+type FuncValues struct {
+	Func *types.Func
+
+	// Vars are the function's parameters, results, and local variables
+	// that have basic types,
+	// in the order of their declarations.
+	//
+	// Those that have struct types
+	// whose fields all have basic types
+	// (or are structs of the same kind)
+	// are represented by their fields instead,
+	// each as a variable named like cfg.mode.
+	// Assigning the whole struct,
+	// as in cfg = Config{},
+	// replaces the values of all of them.
+	Vars []*types.Var
+
+	// Points are the function's reachable statements,
+	// with the values of Vars just before each,
+	// in source order.
+	Points []ProgramPoint
+}
+
+// ProgramPoint gives the possible values of variables
+// just before a statement executes.
+type ProgramPoint struct {
+	Stmt ast.Stmt
+
+	// Values holds the possible values
+	// of each of the variables (of [FuncValues.Vars])
+	// that are in scope at Stmt.
+	// The Why fields of the results are not set.
+	Values map[*types.Var]Result
+}
+
+// maxLoopIterations bounds the number of times [Scanner.AnalyzeFunc]
+// goes around a loop looking for the values of its variables to settle.
+const maxLoopIterations = 10
+
+// AnalyzeFunc follows the values of the variables of fn
+// from statement to statement,
+// in the manner of a classic dataflow analysis,
+// for tools that show how the values evolve through a function.
+// Unlike [Scanner.Scan],
+// it takes the order of statements into account:
+// after x = 1; x = 2,
+// x can only be 2.
+//
+// Parameters have their values from s.Assume
+// and are otherwise unknown.
+// Variables assigned in function literals or whose addresses are taken
+// are not followed statement by statement;
+// their values are as determined by [Scanner.Scan] throughout.
+// A function containing a goto statement
+// gets results that are all incomplete.
+//
+// The result is nil if fn's body is not in the Scanner's files.
+func (s *Scanner) AnalyzeFunc(fn *types.Func) *FuncValues {
+	if missingInfo(s.Info) != "" {
+		return nil
+	}
+	body := funcBody(fn, s.Files)
+	if body == nil {
+		return nil
+	}
+
+	q := s.newQuery()
+	a := &analysis{
+		q:       q,
+		defs:    make(map[*types.Var]*ast.Ident),
+		tracked: make(map[*types.Var]bool),
+		fields:  make(map[*types.Var][]*types.Var),
+		leaves:  make(map[*types.Var]fieldLeaf),
+		points:  make(map[ast.Stmt]env),
+
+		joinFunc: s.Join,
+	}
+	if a.joinFunc == nil {
+		a.joinFunc = UnionJoin
+	}
+	result := &FuncValues{Func: fn}
+
+	// Find the variables.
+	decl := pathTo(s.Files, body)
+	var ftype *ast.FuncType
+	if len(decl) > 0 {
+		switch d := decl[len(decl)-1].(type) {
+		case *ast.FuncDecl:
+			ftype = d.Type
+		case *ast.FuncLit:
+			ftype = d.Type
+		}
+	}
+	var fields []*ast.Field
+	if ftype != nil {
+		fields = append(fields, ftype.Params.List...)
+		if ftype.Results != nil {
+			fields = append(fields, ftype.Results.List...)
+		}
+	}
+	for _, field := range fields {
+		for _, name := range field.Names {
+			a.addVar(name, result)
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.Ident:
+			a.addVar(n, result)
+		case *ast.CaseClause:
+			a.addImplicit(n, result)
+		}
+		return true
+	})
+	a.untrack(body)
+	q.tracked = a.tracked
+	q.fieldVars = make(map[*types.Var]map[string]*types.Var)
+	q.sent = make(map[*ast.SendStmt]valSet)
+	for v, leaves := range a.fields {
+		for _, leaf := range leaves {
+			if !a.tracked[leaf] {
+				continue
+			}
+			if q.fieldVars[v] == nil {
+				q.fieldVars[v] = make(map[string]*types.Var)
+			}
+			q.fieldVars[v][pathKey(a.leaves[leaf].path)] = leaf
+		}
+	}
+
+	// The initial values.
+	entry := make(env)
+	sig := fn.Signature()
+	for i := 0; i < sig.Params().Len(); i++ {
+		v := sig.Params().At(i)
+		for _, leaf := range a.fields[v] {
+			if a.tracked[leaf] {
+				entry[leaf] = valSet{vals: map[string]constant.Value{}}
+			}
+		}
+		if !a.tracked[v] {
+			continue
+		}
+		if assumed, ok := q.assume[v.Origin()]; ok {
+			entry[v] = valSet{vals: cloneVals(assumed), complete: true}
+		} else {
+			entry[v] = valSet{vals: map[string]constant.Value{}}
+		}
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		v := sig.Results().At(i)
+		if a.tracked[v] {
+			entry[v] = zeroValSet(v)
+		}
+		a.zeroFields(v, entry)
+	}
+
+	a.stmt(body, entry)
+
+	stmts := slices.Collect(maps.Keys(a.points))
+	slices.SortFunc(stmts, func(x, y ast.Stmt) int { return int(x.Pos() - y.Pos()) })
+
+	for _, stmt := range stmts {
+		e := a.points[stmt]
+		point := ProgramPoint{Stmt: stmt, Values: make(map[*types.Var]Result)}
+		for _, v := range result.Vars {
+			scoped := v
+			if leaf, ok := a.leaves[v]; ok {
+				scoped = leaf.v
+			}
+			if scoped.Pos() >= stmt.Pos() || scoped.Parent() == nil || !scoped.Parent().Contains(stmt.Pos()) {
+				continue
+			}
+			var res Result
+			if vs, ok := e[v]; ok {
+				res = Result{Values: cloneVals(vs.vals), Complete: vs.complete}
+			} else if _, ok := a.leaves[v]; ok {
+				// A field of a struct variable that is not followed.
+				continue
+			} else {
+				res = s.Scan(a.defs[v])
+				res.Why = nil
+			}
+			if a.gaveUp {
+				res.Complete = false
+			}
+			point.Values[v] = res
+		}
+		result.Points = append(result.Points, point)
+	}
+
+	return result
+}
+
+// env maps variables to their possible values at some point in a function.
+// A nil env is for a point that cannot be reached.
+type env map[*types.Var]valSet
+
+func (e env) clone() env {
+	if e == nil {
+		return nil
+	}
+	result := make(env, len(e))
+	for v, vs := range e {
+		result[v] = valSet{vals: cloneVals(vs.vals), complete: vs.complete}
+	}
+	return result
+}
+
+// join combines the possible values at two points,
+// with the analysis's [JoinFunc] for the variables that both have.
+func (a *analysis) join(x, y env) env {
+	if x == nil {
+		return y.clone()
+	}
+	if y == nil {
+		return x.clone()
+	}
+	result := x.clone()
+	for v, vs := range y {
+		r, ok := result[v]
+		if !ok {
+			result[v] = valSet{vals: cloneVals(vs.vals), complete: vs.complete}
+			continue
+		}
+		vals, exact := a.joinFunc(v, r.vals, vs.vals)
+		result[v] = valSet{vals: vals, complete: r.complete && vs.complete && exact}
+	}
+	return result
+}
+
+func (e env) equal(other env) bool {
+	if (e == nil) != (other == nil) || len(e) != len(other) {
+		return false
+	}
+	for v, vs := range e {
+		ovs, ok := other[v]
+		if !ok || vs.complete != ovs.complete || len(vs.vals) != len(ovs.vals) {
+			return false
+		}
+		for k := range vs.vals {
+			if _, ok := ovs.vals[k]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// widen returns a copy of e,
+// the values after going around a loop,
+// in which the variables whose values differ from those in prev,
+// the values before,
+// are marked incomplete.
+func (e env) widen(prev env) env {
+	result := e.clone()
+	for v, vs := range result {
+		if !(env{v: vs}).equal(env{v: prev[v]}) {
+			vs.complete = false
+			result[v] = vs
+		}
+	}
+	return result
+}
+
+func zeroValSet(v *types.Var) valSet {
+	zero, ok := zeroValue(v.Type())
+	if !ok {
+		return valSet{vals: map[string]constant.Value{}}
+	}
+	return valSet{vals: map[string]constant.Value{zero.ExactString(): zero}, complete: true}
+}
+
+// analysis is the state of [Scanner.AnalyzeFunc].
+type analysis struct {
+	q *query
+
+	// defs maps each variable to its declaring identifier.
+	defs map[*types.Var]*ast.Ident
+
+	// tracked tells which variables are followed statement by statement.
+	tracked map[*types.Var]bool
+
+	// fields maps each struct variable to the variables representing its fields
+	// (see [FuncValues.Vars]),
+	// and leaves maps each of those back to the struct variable and field path.
+	fields map[*types.Var][]*types.Var
+	leaves map[*types.Var]fieldLeaf
+
+	// points holds the values just before each reachable statement.
+	points map[ast.Stmt]env
+
+	// targets is the stack of statements that break and continue statements may refer to.
+	targets []*branchTarget
+
+	// label is the label of the statement about to be analyzed, if any.
+	label string
+
+	// joinFunc combines values where control-flow paths meet
+	// (see [Scanner.Join]).
+	joinFunc JoinFunc
+
+	// gaveUp tells whether the function has a goto statement,
+	// which the analysis does not follow.
+	gaveUp bool
+}
+
+// branchTarget is a loop, switch, or select statement
+// that break (and, for a loop, continue) statements may refer to.
+type branchTarget struct {
+	label     string
+	loop      bool
+	breaks    env
+	continues env
+}
+
+// fieldLeaf is the field at path of the struct variable v.
+type fieldLeaf struct {
+	v    *types.Var
+	path []int
+}
+
+func (a *analysis) addVar(ident *ast.Ident, result *FuncValues) {
+	v, ok := a.q.info.Defs[ident].(*types.Var)
+	if !ok || v.IsField() || ident.Name == "_" {
+		return
+	}
+	if _, ok := a.defs[v]; ok {
+		return
+	}
+
+	if isBasic(v.Type()) {
+		a.defs[v] = ident
+		a.tracked[v] = true
+		result.Vars = append(result.Vars, v)
+		return
+	}
+
+	st, ok := v.Type().Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	var paths [][]int
+	if !structLeaves(st, nil, &paths) || len(paths) > maxAggregateLeaves {
+		return
+	}
+	a.defs[v] = ident
+	for _, path := range paths {
+		name, typ := ident.Name, types.Type(st)
+		for _, i := range path {
+			f := typ.Underlying().(*types.Struct).Field(i)
+			name += "." + f.Name()
+			typ = f.Type()
+		}
+		leaf := types.NewVar(ident.Pos(), v.Pkg(), name, typ)
+		a.fields[v] = append(a.fields[v], leaf)
+		a.leaves[leaf] = fieldLeaf{v: v, path: path}
+		a.tracked[leaf] = true
+		result.Vars = append(result.Vars, leaf)
+	}
+}
+
+// addImplicit adds the variable that cc declares implicitly,
+// as for x in switch x := y.(type),
+// if it has a basic type.
+func (a *analysis) addImplicit(cc *ast.CaseClause, result *FuncValues) {
+	ts, v := a.q.typeSwitchOf(cc)
+	if ts == nil || !isBasic(v.Type()) {
+		return
+	}
+	if _, ok := a.defs[v]; ok {
+		return
+	}
+	a.defs[v] = ts.Assign.(*ast.AssignStmt).Lhs[0].(*ast.Ident)
+	a.tracked[v] = true
+	result.Vars = append(result.Vars, v)
+}
+
+// fieldTarget tells whether expr is a struct variable,
+// or selects a (possibly nested) field of one directly,
+// and if so returns the variable and the field path.
+func (a *analysis) fieldTarget(expr ast.Expr) (*types.Var, []int, bool) {
+	root := ast.Unparen(expr)
+	for {
+		sel, ok := root.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		selection, ok := a.q.info.Selections[sel]
+		if !ok || selection.Kind() != types.FieldVal || selection.Indirect() {
+			return nil, nil, false
+		}
+		root = ast.Unparen(sel.X)
+	}
+	id, ok := root.(*ast.Ident)
+	if !ok {
+		return nil, nil, false
+	}
+	v, ok := a.q.info.ObjectOf(id).(*types.Var)
+	if !ok || a.fields[v] == nil {
+		return nil, nil, false
+	}
+	path, ok := a.q.fieldPathOf(expr, v)
+	return v, path, ok
+}
+
+// zeroFields gives the fields of the struct variable v, if followed,
+// their zero values in e.
+func (a *analysis) zeroFields(v *types.Var, e env) {
+	for _, leaf := range a.fields[v] {
+		if a.tracked[leaf] {
+			e[leaf] = zeroValSet(leaf)
+		}
+	}
+}
+
+// structUpdate determines the values that the followed fields of lhs,
+// a struct variable or a struct-valued field of one,
+// get from an assignment in e.
+// The function field gives the values of a field of the assigned value.
+// The result is nil if lhs is not such an expression.
+func (a *analysis) structUpdate(lhs ast.Expr, field func(path []int) (map[string]constant.Value, bool), e env) env {
+	if _, ok := underlying(a.q.info.TypeOf(lhs)).(*types.Struct); !ok {
+		return nil
+	}
+	v, prefix, ok := a.fieldTarget(lhs)
+	if !ok {
+		return nil
+	}
+	result := make(env)
+	for _, leaf := range a.fields[v] {
+		path := a.leaves[leaf].path
+		if !a.tracked[leaf] || !hasPrefix(path, prefix) {
+			continue
+		}
+		a.q.narrowed = e
+		vals, complete := field(path[len(prefix):])
+		if vals == nil {
+			vals = make(map[string]constant.Value)
+		}
+		result[leaf] = valSet{vals: vals, complete: complete}
+	}
+	return result
+}
+
+// untrack stops tracking the variables in body
+// that are assigned in function literals
+// or whose addresses are taken,
+// and the fields of struct variables
+// that are, or that have methods with pointer receivers called on them.
+func (a *analysis) untrack(body *ast.BlockStmt) {
+	inspectWithStack(body, func(n ast.Node, stack []ast.Node) bool {
+		var targets []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if slices.ContainsFunc(stack, isFuncLit) {
+				targets = n.Lhs
+			}
+		case *ast.IncDecStmt:
+			if slices.ContainsFunc(stack, isFuncLit) {
+				targets = []ast.Expr{n.X}
+			}
+		case *ast.RangeStmt:
+			if slices.ContainsFunc(stack, isFuncLit) {
+				targets = []ast.Expr{n.Key, n.Value}
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				targets = []ast.Expr{n.X}
+			}
+		case *ast.SelectorExpr:
+			// A method with a pointer receiver may change the fields of a struct variable.
+			if sel, ok := a.q.info.Selections[n]; ok && sel.Kind() == types.MethodVal {
+				if _, ok := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer); ok {
+					targets = []ast.Expr{n.X}
+				}
+			}
+		}
+		for _, target := range targets {
+			if target == nil {
+				continue
+			}
+			if id, ok := ast.Unparen(target).(*ast.Ident); ok {
+				if v, ok := a.q.info.ObjectOf(id).(*types.Var); ok {
+					delete(a.tracked, v)
+				}
+			}
+			if v, _, ok := a.fieldTarget(target); ok {
+				for _, leaf := range a.fields[v] {
+					delete(a.tracked, leaf)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// eval determines the possible values of expr in e.
+func (a *analysis) eval(expr ast.Expr, e env) valSet {
+	a.q.narrowed = e
+	vals, complete := a.q.scan(expr)
+	if vals == nil {
+		vals = make(map[string]constant.Value)
+	}
+	return valSet{vals: vals, complete: complete}
+}
+
+// binary determines the possible values of x op y,
+// the result of node,
+// whose type is typ.
+func (a *analysis) binary(node ast.Node, x valSet, op token.Token, y valSet, typ types.Type) valSet {
+	basic, _ := underlying(typ).(*types.Basic)
+	result := valSet{vals: make(map[string]constant.Value), complete: x.complete && y.complete}
+	for _, xv := range x.vals {
+		for _, yv := range y.vals {
+			v, ok := binaryOp(xv, op, yv, basic)
+			if !ok {
+				a.q.incomplete(node, "cannot compute %s %s %s", xv.ExactString(), op, yv.ExactString())
+				result.complete = false
+				continue
+			}
+			if v != nil {
+				result.vals[v.ExactString()] = v
+			}
+		}
+	}
+	return result
+}
+
+// mayBe tells whether cond may have the value truth in e,
+// and if so returns a copy of e narrowed accordingly for branch.
+func (a *analysis) mayBe(cond ast.Expr, truth bool, e env, branch ast.Node) env {
+	if e == nil {
+		return nil
+	}
+	result := e.clone()
+	a.q.narrowed = result
+	if !a.q.mayBe(cond, truth) {
+		return nil
+	}
+	a.q.narrow(cond, truth, branch)
+	return result
+}
+
+// assign records that the variable denoted by lhs, if tracked, has the values vs.
+// That may be a variable representing a field (see [FuncValues.Vars]).
+func (a *analysis) assign(lhs ast.Expr, vs valSet, e env) {
+	if v, path, ok := a.fieldTarget(lhs); ok {
+		if leaf := a.q.fieldVars[v][pathKey(path)]; leaf != nil {
+			e[leaf] = vs
+		}
+		return
+	}
+	id, ok := ast.Unparen(lhs).(*ast.Ident)
+	if !ok {
+		return
+	}
+	v, ok := a.q.info.ObjectOf(id).(*types.Var)
+	if !ok || !a.tracked[v] {
+		return
+	}
+	e[v] = vs
+}
+
+func (a *analysis) stmts(list []ast.Stmt, e env) env {
+	for _, stmt := range list {
+		e = a.stmt(stmt, e)
+	}
+	return e
+}
+
+// stmt records the values before stmt
+// and returns the values after it,
+// or nil if control does not pass beyond it.
+// It may modify e.
+func (a *analysis) stmt(stmt ast.Stmt, e env) env {
+	if e == nil {
+		return nil
+	}
+	a.points[stmt] = a.join(a.points[stmt], e)
+
+	label := a.label
+	a.label = ""
+
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		return a.stmts(stmt.List, e)
+
+	case *ast.LabeledStmt:
+		a.label = stmt.Label.Name
+		return a.stmt(stmt.Stmt, e)
+
+	case *ast.DeclStmt:
+		gen, ok := stmt.Decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			return e
+		}
+		for _, spec := range gen.Specs {
+			vspec := spec.(*ast.ValueSpec)
+			names := make([]ast.Expr, len(vspec.Names))
+			for i, name := range vspec.Names {
+				names[i] = name
+			}
+			vals := a.rhs(vspec.Values, names, e)
+			updates := a.structUpdates(names, vspec.Values, e)
+			for i, name := range vspec.Names {
+				if len(vspec.Values) == 0 {
+					if v, ok := a.q.info.Defs[name].(*types.Var); ok {
+						if a.tracked[v] {
+							e[v] = zeroValSet(v)
+						}
+						a.zeroFields(v, e)
+					}
+					continue
+				}
+				a.assign(name, vals[i], e)
+			}
+			maps.Copy(e, updates)
+		}
+		return e
+
+	case *ast.AssignStmt:
+		switch stmt.Tok {
+		case token.ASSIGN, token.DEFINE:
+			vals := a.rhs(stmt.Rhs, stmt.Lhs, e)
+			updates := a.structUpdates(stmt.Lhs, stmt.Rhs, e)
+			for i, lhs := range stmt.Lhs {
+				a.assign(lhs, vals[i], e)
+			}
+			maps.Copy(e, updates)
+		default:
+			// An assignment operation, like x += y.
+			// The tokens for those are in the same order as the tokens for the operators.
+			op := stmt.Tok - token.ADD_ASSIGN + token.ADD
+			x := stmt.Lhs[0]
+			a.assign(x, a.binary(stmt, a.eval(x, e), op, a.eval(stmt.Rhs[0], e), a.q.info.TypeOf(x)), e)
+		}
+		if a.q.opBlocks(stmt) {
+			return nil
+		}
+		return e
+
+	case *ast.IncDecStmt:
+		op := token.ADD
+		if stmt.Tok == token.DEC {
+			op = token.SUB
+		}
+		one := constant.MakeInt64(1)
+		oneSet := valSet{vals: map[string]constant.Value{one.ExactString(): one}, complete: true}
+		a.assign(stmt.X, a.binary(stmt, a.eval(stmt.X, e), op, oneSet, a.q.info.TypeOf(stmt.X)), e)
+		return e
+
+	case *ast.ExprStmt:
+		if a.q.stmtNeverCompletes(stmt) {
+			return nil
+		}
+		return e
+
+	case *ast.SendStmt:
+		if a.q.stmtNeverCompletes(stmt) {
+			return nil
+		}
+		if !slices.ContainsFunc(a.targets, func(t *branchTarget) bool { return t.loop }) {
+			// A send outside loops runs at most once,
+			// so its environment here is complete
+			// by the time any receive after it is analyzed.
+			sent, ok := a.q.sent[stmt]
+			if !ok {
+				sent.complete = true
+			}
+			vs := a.eval(stmt.Value, e)
+			sent.add(vs.vals, vs.complete)
+			a.q.sent[stmt] = sent
+		}
+		return e
+
+	case *ast.ReturnStmt:
+		return nil
+
+	case *ast.BranchStmt:
+		switch stmt.Tok {
+		case token.BREAK, token.CONTINUE:
+			t := a.target(stmt)
+			if t == nil {
+				a.gaveUp = true
+				return nil
+			}
+			if stmt.Tok == token.BREAK {
+				t.breaks = a.join(t.breaks, e)
+			} else {
+				t.continues = a.join(t.continues, e)
+			}
+		case token.GOTO:
+			a.gaveUp = true
+		case token.FALLTHROUGH:
+			// Handled by the switch statement.
+			return e
+		}
+		return nil
+
+	case *ast.IfStmt:
+		if stmt.Init != nil {
+			e = a.stmt(stmt.Init, e)
+		}
+		out := a.stmt(stmt.Body, a.mayBe(stmt.Cond, true, e, stmt.Body))
+		if stmt.Else != nil {
+			return a.join(out, a.stmt(stmt.Else, a.mayBe(stmt.Cond, false, e, stmt.Else)))
+		}
+		return a.join(out, a.mayBe(stmt.Cond, false, e, stmt))
+
+	case *ast.ForStmt:
+		if stmt.Init != nil {
+			e = a.stmt(stmt.Init, e)
+		}
+		return a.loop(label, e, func(head env) (body, exit env) {
+			if stmt.Cond == nil {
+				return head.clone(), nil
+			}
+			return a.mayBe(stmt.Cond, true, head, stmt), a.mayBe(stmt.Cond, false, head, stmt)
+		}, func(body env) env {
+			out := a.stmt(stmt.Body, body)
+			if t := a.targets[len(a.targets)-1]; t.continues != nil {
+				out = a.join(out, t.continues)
+				t.continues = nil
+			}
+			if stmt.Post != nil {
+				out = a.stmt(stmt.Post, out)
+			}
+			return out
+		})
+
+	case *ast.RangeStmt:
+		return a.loop(label, e, func(head env) (body, exit env) {
+			body = head.clone()
+			for i, x := range []ast.Expr{stmt.Key, stmt.Value} {
+				if x != nil {
+					vals, complete := a.q.scanRange(stmt, i == 1)
+					a.assign(x, valSet{vals: vals, complete: complete}, body)
+				}
+			}
+			return body, head.clone()
+		}, func(body env) env {
+			out := a.stmt(stmt.Body, body)
+			if t := a.targets[len(a.targets)-1]; t.continues != nil {
+				out = a.join(out, t.continues)
+				t.continues = nil
+			}
+			return out
+		})
+
+	case *ast.SwitchStmt:
+		if stmt.Init != nil {
+			e = a.stmt(stmt.Init, e)
+		}
+		if e == nil {
+			return nil
+		}
+		return a.clauses(label, stmt.Body.List, func(clause ast.Stmt) (env, bool) {
+			cc := clause.(*ast.CaseClause)
+			ce := e.clone()
+			a.q.narrowed = ce
+			if !a.q.clauseReachable(stmt, cc) {
+				return nil, cc.List == nil
+			}
+			return ce, cc.List == nil
+		}, e)
+
+	case *ast.TypeSwitchStmt:
+		if stmt.Init != nil {
+			e = a.stmt(stmt.Init, e)
+		}
+		if e == nil {
+			return nil
+		}
+		return a.clauses(label, stmt.Body.List, func(clause ast.Stmt) (env, bool) {
+			cc := clause.(*ast.CaseClause)
+			ce := e.clone()
+			if _, v := a.q.typeSwitchOf(cc); v != nil && a.tracked[v] {
+				a.q.narrowed = ce
+				vals, complete := a.q.scanTypeSwitchVar(stmt, cc)
+				ce[v] = valSet{vals: vals, complete: complete}
+			}
+			return ce, cc.List == nil
+		}, e)
+
+	case *ast.SelectStmt:
+		return a.clauses(label, stmt.Body.List, func(clause ast.Stmt) (env, bool) {
+			cc := clause.(*ast.CommClause)
+			if !a.q.commReachable(cc) {
+				return nil, false
+			}
+			ce := e.clone()
+			if cc.Comm != nil {
+				ce = a.stmt(cc.Comm, ce)
+			}
+			// A select statement always runs one of its clauses, or blocks.
+			return ce, true
+		}, e)
+	}
+
+	return e
+}
+
+// rhs determines the values of the right-hand side of an assignment or declaration
+// with the given left-hand side.
+// The values for blank identifiers on the left are not determined.
+func (a *analysis) rhs(exprs, lhs []ast.Expr, e env) []valSet {
+	n := len(lhs)
+	result := make([]valSet, n)
+	switch len(exprs) {
+	case n:
+		for i, expr := range exprs {
+			result[i] = a.eval(expr, e)
+		}
+	case 1:
+		call, isCall := ast.Unparen(exprs[0]).(*ast.CallExpr)
+		commaOk := n == 2 && isCommaOk(exprs[0], a.q.info)
+		for i := range result {
+			result[i] = valSet{vals: map[string]constant.Value{}}
+			if isBlank(lhs[i]) {
+				// Nothing receives this result.
+				continue
+			}
+			var (
+				vals     map[string]constant.Value
+				complete bool
+			)
+			a.q.narrowed = e
+			switch {
+			case isCall:
+				vals, complete = a.q.scanCallResult(call, i)
+			case commaOk:
+				vals, complete = a.q.scanCommaOk(exprs[0], i)
+			}
+			if vals != nil {
+				result[i] = valSet{vals: vals, complete: complete}
+			}
+		}
+	default:
+		for i := range result {
+			result[i] = valSet{vals: map[string]constant.Value{}}
+		}
+	}
+	return result
+}
+
+// structUpdates determines the values that the followed fields of struct variables
+// get from an assignment or declaration
+// with the given left- and right-hand sides,
+// evaluated in e.
+func (a *analysis) structUpdates(lhs, rhs []ast.Expr, e env) env {
+	result := make(env)
+	for i, x := range lhs {
+		var field func([]int) (map[string]constant.Value, bool)
+		switch len(rhs) {
+		case len(lhs):
+			field = func(path []int) (map[string]constant.Value, bool) {
+				return a.q.scanField(rhs[i], path)
+			}
+		case 1:
+			field = func(path []int) (map[string]constant.Value, bool) {
+				if call, ok := ast.Unparen(rhs[0]).(*ast.CallExpr); ok {
+					return a.q.scanCallField(call, i, path)
+				}
+				return nil, false
+			}
+		default:
+			continue
+		}
+		maps.Copy(result, a.structUpdate(x, field, e))
+	}
+	return result
+}
+
+// loop analyzes a loop starting with the values in e,
+// going around until the values at the top of the loop settle.
+// The function enter gives the values on entering the body and on leaving the loop
+// from those at the top;
+// the function body gives the values at the end of an iteration
+// from those on entering the body.
+func (a *analysis) loop(label string, e env, enter func(head env) (body, exit env), body func(env) env) env {
+	if e == nil {
+		return nil
+	}
+
+	t := &branchTarget{label: label, loop: true}
+	a.targets = append(a.targets, t)
+	defer func() { a.targets = a.targets[:len(a.targets)-1] }()
+
+	head := e
+	for i := 0; ; i++ {
+		b, _ := enter(head)
+		next := a.join(head, body(b))
+		if next.equal(head) {
+			break
+		}
+		if i == maxLoopIterations {
+			// The values have not settled.
+			head = next.widen(head)
+			b, _ = enter(head)
+			body(b)
+			break
+		}
+		head = next
+	}
+
+	_, exit := enter(head)
+	return a.join(exit, t.breaks)
+}
+
+// clauses analyzes the clauses of a switch or select statement.
+// The function enter gives the values on entering a clause
+// (nil if it cannot be chosen)
+// and tells whether the clause is a default clause,
+// or one that is otherwise always chosen when the others are not.
+// The values after the statement come from the ends of the clauses,
+// from break statements,
+// and (when there is no default clause) from e.
+func (a *analysis) clauses(label string, list []ast.Stmt, enter func(ast.Stmt) (env, bool), e env) env {
+	t := &branchTarget{label: label}
+	a.targets = append(a.targets, t)
+	defer func() { a.targets = a.targets[:len(a.targets)-1] }()
+
+	var (
+		result      env
+		hasDefault  bool
+		fellThrough env
+	)
+	for _, clause := range list {
+		ce, isDefault := enter(clause)
+		hasDefault = hasDefault || isDefault
+		if fellThrough != nil {
+			ce = a.join(ce, fellThrough)
+			fellThrough = nil
+		}
+
+		var body []ast.Stmt
+		switch clause := clause.(type) {
+		case *ast.CaseClause:
+			body = clause.Body
+		case *ast.CommClause:
+			body = clause.Body
+		}
+		out := a.stmts(body, ce)
+
+		if len(body) > 0 {
+			if br, ok := body[len(body)-1].(*ast.BranchStmt); ok && br.Tok == token.FALLTHROUGH {
+				fellThrough = out
+				continue
+			}
+		}
+		result = a.join(result, out)
+	}
+	if !hasDefault {
+		result = a.join(result, e)
+	}
+	return a.join(result, t.breaks)
+}
+
+// target finds the statement that br,
+// a break or continue statement,
+// refers to.
+func (a *analysis) target(br *ast.BranchStmt) *branchTarget {
+	for i := len(a.targets) - 1; i >= 0; i-- {
+		t := a.targets[i]
+		if br.Label != nil {
+			if t.label == br.Label.Name {
+				return t
+			}
+			continue
+		}
+		if br.Tok == token.BREAK || t.loop {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// UnknownCalls tells a [Scanner] how to treat calls to functions
+// whose bodies are not among its files
+// and that have no summary
+// (see [RegisterSummary]).
+type UnknownCalls int
+
+const (
+	// AssumeReturns assumes that unknown calls return normally.
+	// This is the default.
+	AssumeReturns UnknownCalls = iota
+
+	// AssumeMayPanic assumes that unknown calls may panic instead.
+	// That matters for a function that recovers from panics
+	// (with a deferred call to recover):
+	// when it recovers,
+	// it returns the zero values of its results,
+	// or the values its named results have at the time of the panic.
+	AssumeMayPanic
+)
+
+// mayRecover tells whether the function with the given body
+// may return normally after recovering from a panic.
+// That requires a deferred call that recovers,
+// and a statement that may panic
+// (see [query.mayPanicIn]).
+func (q *query) mayRecover(body *ast.BlockStmt) bool {
+	return q.defersRecover(body) && q.mayPanicIn(body)
+}
+
+// defersRecover tells whether the function with the given body
+// defers a call that recovers from panics.
+func (q *query) defersRecover(body *ast.BlockStmt) bool {
+	var recovers bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.DeferStmt:
+			var deferred *ast.BlockStmt
+			if lit, ok := ast.Unparen(n.Call.Fun).(*ast.FuncLit); ok {
+				deferred = lit.Body
+			} else if fn := calleeFunc(n.Call, q.info); fn != nil {
+				deferred = q.calleeBody(fn)
+			}
+			if deferred != nil && q.callsRecover(deferred) {
+				recovers = true
+			}
+		}
+		return !recovers
+	})
+	return recovers
+}
+
+// panicsKey identifies the question of whether a function body may panic in query.active.
+type panicsKey struct {
+	body *ast.BlockStmt
+}
+
+// mayPanicIn tells whether the function with the given body may panic,
+// as far as the analysis is concerned:
+// it has a call to panic,
+// or (with [AssumeMayPanic]) to an unknown function,
+// or to a function in the Scanner's files
+// that may panic and does not recover.
+func (q *query) mayPanicIn(body *ast.BlockStmt) bool {
+	key := panicsKey{body: body}
+	if !q.enter(key) {
+		// Already being examined, as for a recursive function.
+		return false
+	}
+	defer q.leave(key)
+
+	var panics bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.CallExpr:
+			if q.mayPanic(n) {
+				panics = true
+				break
+			}
+			if fn := calleeFunc(n, q.info); fn != nil {
+				if b := q.calleeBody(fn); b != nil && !q.defersRecover(b) && q.mayPanicIn(b) {
+					panics = true
+				}
+			}
+		}
+		return !panics
+	})
+	return panics
+}
+
+// callsRecover tells whether body calls recover directly
+// (and so can stop a panic, when body is that of a deferred function).
+func (q *query) callsRecover(body *ast.BlockStmt) bool {
+	var found bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if isBuiltin(n, "recover", q.info) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// mayPanic tells whether call may panic,
+// as far as the analysis is concerned:
+// it is a call to panic,
+// or (with [AssumeMayPanic]) to an unknown function.
+func (q *query) mayPanic(call *ast.CallExpr) bool {
+	if isBuiltin(call, "panic", q.info) {
+		return true
+	}
+	if q.unknownCalls != AssumeMayPanic {
+		return false
+	}
+	if tv, ok := q.info.Types[call.Fun]; ok && (tv.IsType() || tv.IsBuiltin()) {
+		return false
+	}
+	if _, ok := summaryFor(call, q.info); ok {
+		return false
+	}
+	fn := calleeFunc(call, q.info)
+	return fn == nil || q.calleeBody(fn) == nil
+}
+
+// finalAssignment finds an assignment to the named result v
+// that determines v's value whenever the function with the given body returns.
+// That is an unconditional assignment in a deferred function literal
+// whose defer statement is at the top level of body,
+// before any return statement.
+// Deferred functions run in last-in, first-out order,
+// so the first such defer statement has the last word.
+// The result is the function literal and the assignment,
+// or nils if there is no such assignment.
+//
+// Defer statements in loops and conditionals may run any number of times,
+// including none,
+// so their assignments are merely added to the results of the return statements.
+// That also covers deferred closures in loops
+// that capture the loop's variables,
+// whether those are per-iteration (since Go 1.22) or shared (before):
+// the scan of such a variable includes all the values it may have.
+func (q *query) finalAssignment(body *ast.BlockStmt, v *types.Var) (*ast.FuncLit, *ast.AssignStmt) {
+	for _, stmt := range body.List {
+		if hasReturn(stmt) || q.stmtNeverCompletes(stmt) {
+			return nil, nil
+		}
+		d, ok := stmt.(*ast.DeferStmt)
+		if !ok {
+			continue
+		}
+		lit, ok := ast.Unparen(d.Call.Fun).(*ast.FuncLit)
+		if !ok {
+			continue
+		}
+		for _, s := range lit.Body.List {
+			if assign, ok := s.(*ast.AssignStmt); ok && assign.Tok == token.ASSIGN {
+				if slices.ContainsFunc(assign.Lhs, func(lhs ast.Expr) bool { return exprIsVar(lhs, v, q.info) }) {
+					return lit, assign
+				}
+			}
+			if hasReturn(s) || q.stmtNeverCompletes(s) {
+				break
+			}
+		}
+	}
+	return nil, nil
+}
+
+// scanFinalAssignment determines the values that the named result v
+// has after the deferred function literal lit runs,
+// given that assign,
+// in lit,
+// is the assignment found by [query.finalAssignment].
+// Those are the values of assign and of any later assignments to v in lit.
+func (q *query) scanFinalAssignment(lit *ast.FuncLit, assign *ast.AssignStmt, v *types.Var) (map[string]constant.Value, bool) {
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+	q.inspectReachable(lit.Body, func(n ast.Node) bool {
+		if n == nil || n.Pos() < assign.Pos() {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			vals, ok := q.scanAssignment(n, v)
+			union(result, vals)
+			complete = complete && ok
+
+		case *ast.IncDecStmt:
+			if exprIsVar(n.X, v, q.info) {
+				q.incomplete(n, "unsupported %s of %s", n.Tok, v.Name())
+				complete = false
+			}
+		}
+		return true
+	})
+	return result, complete
+}
+
+// hasReturn tells whether stmt contains a return statement,
+// not counting any in function literals.
+func hasReturn(stmt ast.Stmt) bool {
+	var found bool
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// inDeferred tells whether ident is in a function literal
+// that is deferred by fn,
+// a function declaration or literal.
+func (q *query) inDeferred(ident *ast.Ident, fn ast.Node) bool {
+	path := pathTo(q.files, ident)
+	for i := len(path) - 1; i >= 2; i-- {
+		if path[i] == fn {
+			return false
+		}
+		lit, ok := path[i].(*ast.FuncLit)
+		if !ok {
+			continue
+		}
+		call, ok := path[i-1].(*ast.CallExpr)
+		if !ok || ast.Unparen(call.Fun) != lit {
+			continue
+		}
+		if d, ok := path[i-2].(*ast.DeferStmt); ok && d.Call == call {
+			return true
+		}
+	}
+	return false
+}
+
+// resultIndex returns the index of v among the named results of fn,
+// a function declaration or literal,
+// or -1 if it is not one of them.
+func resultIndex(fn ast.Node, v *types.Var, info *types.Info) int {
+	var typ *ast.FuncType
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		typ = fn.Type
+	case *ast.FuncLit:
+		typ = fn.Type
+	default:
+		return -1
+	}
+	if typ.Results == nil {
+		return -1
+	}
+	var idx int
+	for _, field := range typ.Results.List {
+		for _, name := range field.Names {
+			if identIsVar(name, v, info) {
+				return idx
+			}
+			idx++
+		}
+	}
+	return -1
+}
+
+// scanReturned determines the values that the return statements of fn,
+// a function declaration or literal,
+// give its result idx.
+// Bare returns,
+// which leave the named result as it is,
+// add nothing.
+func (q *query) scanReturned(fn ast.Node, idx int) (map[string]constant.Value, bool) {
+	var (
+		typ  *ast.FuncType
+		body *ast.BlockStmt
+	)
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		typ, body = fn.Type, fn.Body
+	case *ast.FuncLit:
+		typ, body = fn.Type, fn.Body
+	}
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+	if body == nil {
+		return result, complete
+	}
+	q.forEachReturn(body, typ.Results.NumFields(), idx, func(_ *ast.ReturnStmt, expr ast.Expr, call *ast.CallExpr) {
+		var (
+			vals map[string]constant.Value
+			ok   bool
+		)
+		switch {
+		case expr != nil:
+			vals, ok = q.scan(expr)
+		case call != nil:
+			vals, ok = q.scanCallResult(call, idx)
+		default:
+			return
+		}
+		union(result, vals)
+		complete = complete && ok
+	})
+	return result, complete
+}
@@ -0,0 +1,150 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// Deps is the outcome of [Scanner.ScanDeps].
+type Deps struct {
+	// Result holds the values of the expression,
+	// as found by [Scanner.Scan].
+	Result
+
+	// Files are the Scanner's files that the scan consulted,
+	// in the order of [Scanner.Files].
+	Files []*ast.File
+
+	// Funcs are the functions that the scan consulted,
+	// sorted by full name:
+	// those whose calls it examined,
+	// whether it followed them into their bodies,
+	// used their summaries (see [RegisterSummary])
+	// or results established by [ReportPackages],
+	// or found nothing known about them.
+	Funcs []*types.Func
+
+	// Packages are the packages declaring the functions, variables, and constants
+	// that the scan consulted,
+	// sorted by path.
+	Packages []*types.Package
+}
+
+// ScanDeps is like [Scanner.Scan],
+// but also tells what the scan consulted:
+// the files, functions, and packages
+// whose changes may change its result,
+// for build systems and editors that cache results
+// and must know when to recompute them.
+// Unlike Scan,
+// ScanDeps does not use or fill the Scanner's cache.
+func (s *Scanner) ScanDeps(node ast.Expr) Deps {
+	q := s.newQuery()
+	q.deps = &deps{
+		files: make(map[*ast.File]bool),
+		funcs: make(map[*types.Func]bool),
+		pkgs:  make(map[*types.Package]bool),
+	}
+	res := q.result(node, "scanning "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+		return q.scan(node)
+	})
+	res.key = cacheKey{node: node, idx: -1}
+
+	result := Deps{Result: res}
+	for _, file := range s.Files {
+		if q.deps.files[file] {
+			result.Files = append(result.Files, file)
+		}
+	}
+	for fn := range q.deps.funcs {
+		result.Funcs = append(result.Funcs, fn)
+	}
+	sort.Slice(result.Funcs, func(i, j int) bool { return result.Funcs[i].FullName() < result.Funcs[j].FullName() })
+	for pkg := range q.deps.pkgs {
+		result.Packages = append(result.Packages, pkg)
+	}
+	sort.Slice(result.Packages, func(i, j int) bool { return result.Packages[i].Path() < result.Packages[j].Path() })
+	return result
+}
+
+// deps records what a query consults,
+// for [Scanner.ScanDeps].
+type deps struct {
+	files map[*ast.File]bool
+	funcs map[*types.Func]bool
+	pkgs  map[*types.Package]bool
+}
+
+// consult records that the query consulted n,
+// with the file containing it
+// and what it refers to.
+func (q *query) consult(n ast.Node) {
+	if q.deps == nil || n == nil {
+		return
+	}
+
+	if file, ok := n.(*ast.File); ok {
+		q.deps.files[file] = true
+	} else {
+		q.consultPos(n.Pos())
+	}
+
+	if expr, ok := n.(ast.Expr); ok {
+		if tv, ok := q.info.Types[expr]; ok && tv.Value != nil {
+			// A constant expression is not scanned further,
+			// but the constants in it matter.
+			ast.Inspect(expr, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok && ident != expr {
+					q.consult(ident)
+				}
+				return true
+			})
+		}
+	}
+
+	var obj types.Object
+	switch n := n.(type) {
+	case *ast.Ident:
+		obj = q.info.ObjectOf(n)
+	case *ast.SelectorExpr:
+		obj = q.info.ObjectOf(n.Sel)
+	case *ast.CallExpr:
+		if fn := calleeFunc(n, q.info); fn != nil {
+			q.consultFunc(fn)
+		}
+	}
+	if obj != nil && obj.Pkg() != nil {
+		// The declaration of obj,
+		// as of a constant,
+		// matters too.
+		q.deps.pkgs[obj.Pkg()] = true
+		q.consultPos(obj.Pos())
+	}
+}
+
+// consultPos records that the query consulted the file containing pos,
+// if it is among the query's files.
+func (q *query) consultPos(pos token.Pos) {
+	for _, file := range q.files {
+		if file.FileStart <= pos && pos < file.FileEnd {
+			q.deps.files[file] = true
+			return
+		}
+	}
+}
+
+// consultFunc records that the query consulted fn.
+func (q *query) consultFunc(fn *types.Func) {
+	if q.deps == nil {
+		return
+	}
+	fn = fn.Origin()
+	q.deps.funcs[fn] = true
+	if fn.Pkg() != nil {
+		q.deps.pkgs[fn.Pkg()] = true
+	}
+	q.consultPos(fn.Pos())
+}
@@ -0,0 +1,139 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/constant"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// SummaryDetail tells how much [ReportPackages] retains
+// about the results of a package's functions
+// for the analysis of the packages that import it.
+type SummaryDetail int
+
+const (
+	// SummaryFull retains the complete value sets.
+	// This is the default.
+	SummaryFull SummaryDetail = iota
+
+	// SummaryHash retains only a fingerprint of each value set,
+	// and its size.
+	// That is enough to tell whether the results of a function
+	// differ between two analyses,
+	// but not what they are,
+	// so calls to the function have unknown results.
+	SummaryHash
+
+	// SummaryTop retains nothing,
+	// as if the package were not analyzed first.
+	SummaryTop
+)
+
+func (d SummaryDetail) String() string {
+	switch d {
+	case SummaryFull:
+		return "full"
+	case SummaryHash:
+		return "hash"
+	case SummaryTop:
+		return "top"
+	}
+	return fmt.Sprintf("SummaryDetail(%d)", int(d))
+}
+
+// ParseSummaryDetail parses the name of a [SummaryDetail]:
+// "full", "hash", or "top".
+func ParseSummaryDetail(s string) (SummaryDetail, error) {
+	for _, d := range []SummaryDetail{SummaryFull, SummaryHash, SummaryTop} {
+		if s == d.String() {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown summary detail %q", s)
+}
+
+// DetailRule sets the [SummaryDetail] for the packages matching Pattern,
+// an import path in which ... matches any string,
+// as in "example.com/mod/..." or "github.com/...".
+// A pattern ending in /... also matches the path without it.
+type DetailRule struct {
+	Pattern string
+	Detail  SummaryDetail
+}
+
+// match tells whether the import path matches the rule's pattern.
+func (r DetailRule) match(path string) bool {
+	return matchPattern(r.Pattern, path)
+}
+
+// matchPattern tells whether s matches pattern,
+// in which ... matches any string
+// and a trailing /... also matches nothing.
+func matchPattern(pattern, s string) bool {
+	re := regexp.QuoteMeta(pattern)
+	if strings.HasSuffix(re, `/\.\.\.`) {
+		re = strings.TrimSuffix(re, `/\.\.\.`) + `(/\.\.\.)?`
+	}
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	ok, _ := regexp.MatchString("^"+re+"$", s)
+	return ok
+}
+
+// detailFor returns the detail set by the first of rules matching the import path,
+// or [SummaryFull] if none does.
+func detailFor(rules []DetailRule, path string) SummaryDetail {
+	for _, r := range rules {
+		if r.match(path) {
+			return r.Detail
+		}
+	}
+	return SummaryFull
+}
+
+// factResult is what [facts] retains about one result of a function.
+type factResult struct {
+	detail SummaryDetail
+
+	// vals is the value set, with SummaryFull.
+	vals map[string]constant.Value
+
+	// hash and n are the fingerprint and size of the value set, with SummaryHash.
+	hash uint64
+	n    int
+}
+
+// newFactResult retains vals at the given detail.
+// The result is nil for SummaryTop.
+func newFactResult(vals map[string]constant.Value, detail SummaryDetail) *factResult {
+	switch detail {
+	case SummaryFull:
+		return &factResult{detail: detail, vals: cloneVals(vals)}
+	case SummaryHash:
+		h := fnv.New64a()
+		for _, k := range exactStrings(vals) {
+			h.Write([]byte(k))
+			h.Write([]byte{0})
+		}
+		return &factResult{detail: detail, hash: h.Sum64(), n: len(vals)}
+	}
+	return nil
+}
+
+// size approximates the number of bytes that r retains:
+// the ExactString representations of the values and 8 bytes for each,
+// or 16 bytes for a fingerprint.
+func (r *factResult) size() int {
+	if r == nil {
+		return 0
+	}
+	if r.detail == SummaryHash {
+		return 16
+	}
+	n := 0
+	for k := range r.vals {
+		n += len(k) + 8
+	}
+	return n
+}
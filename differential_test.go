@@ -0,0 +1,138 @@
+package exprvals
+
+import (
+	"flag"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// When -differential is given,
+// testdata files whose function f takes no arguments and returns a single value
+// are compiled and run,
+// and the value f actually returns is checked against the statically computed set.
+// A complete result that omits the observed value is a soundness bug.
+//
+// Contributors adding support for new kinds of statement or expression
+// should run the tests with this flag:
+//
+//	go test -differential
+var differential = flag.Bool("differential", false, "run self-contained testdata functions and check their results against the scanned values")
+
+// differentialDriver is added to a copy of a testdata file to print the value returned by its function f.
+const differentialDriver = `package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+func main() {
+	v := reflect.ValueOf(f())
+	switch v.Kind() {
+	case reflect.Bool:
+		fmt.Print("bool ", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Print("int ", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Print("int ", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		fmt.Print("float ", strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.String:
+		fmt.Print("string ", strconv.Quote(v.String()))
+	default:
+		fmt.Print("unsupported")
+	}
+}
+`
+
+// checkDifferential runs the function f in the given testdata file, if -differential is set and f is eligible,
+// and reports an error if the scan was complete but did not include the observed value.
+func checkDifferential(t *testing.T, filename string, file *ast.File, vals map[string]constant.Value, complete bool) {
+	t.Helper()
+
+	if !*differential || !complete || !differentialEligible(file) {
+		return
+	}
+
+	observed, ok := runDifferential(t, filename)
+	if !ok {
+		return
+	}
+	for _, v := range vals {
+		if valuesEqual(observed, v) {
+			return
+		}
+	}
+	t.Errorf("f returned %s at run time, which is not among the scanned values %v", observed.ExactString(), vals)
+}
+
+// differentialEligible tells whether file has a function f
+// taking no arguments and returning one result,
+// and no function main.
+func differentialEligible(file *ast.File) bool {
+	var found bool
+	for _, decl := range file.Decls {
+		fdecl, ok := decl.(*ast.FuncDecl)
+		if !ok || fdecl.Recv != nil {
+			continue
+		}
+		switch fdecl.Name.Name {
+		case "main":
+			return false
+		case "f":
+			typ := fdecl.Type
+			found = typ.TypeParams == nil && typ.Params.NumFields() == 0 && typ.Results.NumFields() == 1
+		}
+	}
+	return found
+}
+
+// runDifferential builds and runs the testdata file in a temporary module
+// and returns the value its function f produced.
+func runDifferential(t *testing.T, filename string) (constant.Value, bool) {
+	t.Helper()
+
+	src, err := testdataFS.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module differential\n\ngo 1.23\n",
+		"f.go":      string(src),
+		"driver.go": differentialDriver,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running %s: %s", filename, err)
+	}
+
+	kind, lit, _ := strings.Cut(string(out), " ")
+	switch kind {
+	case "bool":
+		return constant.MakeBool(lit == "true"), true
+	case "int":
+		return constant.MakeFromLiteral(lit, token.INT, 0), true
+	case "float":
+		return constant.MakeFromLiteral(lit, token.FLOAT, 0), true
+	case "string":
+		return constant.MakeFromLiteral(lit, token.STRING, 0), true
+	}
+	return nil, false
+}
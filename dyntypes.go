@@ -0,0 +1,187 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// DynamicType is a possible dynamic type of an interface value.
+type DynamicType struct {
+	Type types.Type
+
+	// Expr is the expression of type Type
+	// whose value becomes the interface's dynamic value.
+	Expr ast.Expr
+}
+
+// DynamicTypes is the outcome of [Scanner.DynamicTypes].
+type DynamicTypes struct {
+	// Types are the possible dynamic types,
+	// each with an expression it comes from.
+	// A type may appear more than once, with different expressions.
+	Types []DynamicType
+
+	// Complete tells whether all the possible dynamic types were found.
+	Complete bool
+
+	// Why explains what made the result incomplete,
+	// as in [Result].
+	Why *Reason
+}
+
+// DynamicTypes determines the possible dynamic types of the interface-valued expression node,
+// following its values back through variables, calls, and conversions
+// to the expressions of concrete type they come from.
+// A nil interface value has no dynamic type and contributes nothing.
+func (s *Scanner) DynamicTypes(node ast.Expr) DynamicTypes {
+	var result DynamicTypes
+	q := s.newQuery()
+	res := q.result(node, "finding dynamic types of "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+		ok := q.dynamicValues(node, func(expr ast.Expr, typ types.Type) {
+			result.Types = append(result.Types, DynamicType{Type: typ, Expr: expr})
+		})
+		return nil, ok
+	})
+	result.Complete, result.Why = res.Complete, res.Why
+	return result
+}
+
+// dynamicValues finds the expressions of concrete type
+// whose values the interface-valued expression node may hold,
+// and passes each of them, with its type, to f.
+// The result tells whether all of them were found.
+// If node is a variable narrowed by an enclosing switch on it,
+// only the expressions of the types it is narrowed to are passed.
+func (q *query) dynamicValues(node ast.Expr, f func(ast.Expr, types.Type)) bool {
+	isIface := func(expr ast.Expr) bool {
+		return isInterface(q.info.TypeOf(expr))
+	}
+	if narrowed, ok := q.narrowedDynTypes(node); ok {
+		inner := f
+		f = func(expr ast.Expr, typ types.Type) {
+			if slices.ContainsFunc(narrowed, func(t types.Type) bool { return types.Identical(t, typ) }) {
+				inner(expr, typ)
+			}
+		}
+	}
+	complete := true
+	ok := q.sources(node, isIface, func(src source) {
+		if src.zero {
+			// A nil interface.
+			return
+		}
+		typ := q.info.TypeOf(src.expr)
+		if typ == nil || types.IsInterface(typ) {
+			q.incomplete(src.expr, "dynamic type of %s is not tracked", types.ExprString(src.expr))
+			complete = false
+			return
+		}
+		if tv, ok := q.info.Types[src.expr]; ok && tv.IsNil() {
+			// An untyped nil converted to an interface type is a nil interface.
+			return
+		}
+		f(src.expr, typ)
+	})
+	return complete && ok
+}
+
+// narrowedDynTypes returns the possible dynamic types of expr
+// if it is an interface variable narrowed by an enclosing switch on it.
+func (q *query) narrowedDynTypes(expr ast.Expr) ([]types.Type, bool) {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	v, ok := q.info.ObjectOf(ident).(*types.Var)
+	if !ok {
+		return nil, false
+	}
+	n, ok := q.narrowed[v.Origin()]
+	if !ok || n.dynTypes == nil {
+		return nil, false
+	}
+	return n.dynTypes, true
+}
+
+// IncomparableComparison is a comparison of interface values
+// that may panic at run time,
+// because both may hold the same dynamic type
+// and that type is not comparable
+// (e.g. a slice, map, or function type,
+// or a struct or array containing one).
+type IncomparableComparison struct {
+	// Cmp is the comparison, x == y or x != y.
+	Cmp *ast.BinaryExpr
+
+	// Witnesses are the incomparable dynamic types that the operands may hold,
+	// each with an expression it comes from.
+	Witnesses []DynamicType
+}
+
+// CheckComparisons finds the comparisons of interface values in the Scanner's files
+// that may panic because of an incomparable dynamic type
+// (see [IncomparableComparison]).
+// An operand whose dynamic types are not all known
+// is taken to possibly hold any of the other operand's types.
+func (s *Scanner) CheckComparisons() []IncomparableComparison {
+	if missingInfo(s.Info) != "" {
+		return nil
+	}
+
+	var result []IncomparableComparison
+	for _, file := range s.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			cmp, ok := n.(*ast.BinaryExpr)
+			if !ok || (cmp.Op != token.EQL && cmp.Op != token.NEQ) {
+				return true
+			}
+			if !isInterface(s.Info.TypeOf(cmp.X)) || !isInterface(s.Info.TypeOf(cmp.Y)) {
+				// Comparing with a value of concrete type can't panic,
+				// since that type must be comparable.
+				return true
+			}
+			x, y := s.DynamicTypes(cmp.X), s.DynamicTypes(cmp.Y)
+			if w := incomparableWitnesses(x, y); len(w) > 0 {
+				result = append(result, IncomparableComparison{Cmp: cmp, Witnesses: w})
+			}
+			return true
+		})
+	}
+	return result
+}
+
+// incomparableWitnesses returns the incomparable dynamic types that x and y may both hold.
+func incomparableWitnesses(x, y DynamicTypes) []DynamicType {
+	var (
+		result []DynamicType
+		seen   = make(map[DynamicType]bool)
+	)
+
+	add := func(these, others DynamicTypes) {
+		for _, dt := range these.Types {
+			if seen[dt] || types.Comparable(dt.Type) {
+				continue
+			}
+			if !others.Complete || hasDynamicType(others, dt.Type) {
+				seen[dt] = true
+				result = append(result, dt)
+			}
+		}
+	}
+	add(x, y)
+	add(y, x)
+
+	return result
+}
+
+func hasDynamicType(dts DynamicTypes, typ types.Type) bool {
+	for _, dt := range dts.Types {
+		if types.Identical(dt.Type, typ) {
+			return true
+		}
+	}
+	return false
+}
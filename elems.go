@@ -0,0 +1,562 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// elemsKey identifies the elements of a variable in query.active.
+type elemsKey struct {
+	v *types.Var
+}
+
+// elemVals holds the possible values of the elements of a slice or array.
+// Elements at known indexes are tracked separately,
+// so that an unknown element does not spoil the others.
+type elemVals struct {
+	// byIndex holds the possible values of the elements at known indexes.
+	byIndex map[int64]valSet
+
+	// dflt holds the possible values of the elements at indexes not in byIndex.
+	// This is the zero value for arrays not fully initialized,
+	// and nothing for slices.
+	dflt valSet
+
+	// unindexed holds the possible values of elements that may be at any index,
+	// such as the ones added by append.
+	unindexed valSet
+}
+
+// valSet is a set of values, together with whether it is known to be complete.
+type valSet struct {
+	vals     map[string]constant.Value
+	complete bool
+
+	// dynTypes, if not nil,
+	// holds the possible dynamic types of an interface variable
+	// narrowed by a switch on it
+	// (see [query.clauseReachable]).
+	dynTypes []types.Type
+}
+
+func newElemVals() *elemVals {
+	return &elemVals{
+		byIndex:   make(map[int64]valSet),
+		dflt:      valSet{complete: true},
+		unindexed: valSet{complete: true},
+	}
+}
+
+// unknownElems returns an elemVals about which nothing is known,
+// recording the reason described by format and args.
+func (q *query) unknownElems(node ast.Node, format string, args ...any) *elemVals {
+	q.incomplete(node, format, args...)
+	ev := newElemVals()
+	ev.unindexed.complete = false
+	return ev
+}
+
+func (s *valSet) add(vals map[string]constant.Value, complete bool) {
+	if len(vals) > 0 && s.vals == nil {
+		s.vals = make(map[string]constant.Value)
+	}
+	union(s.vals, vals)
+	s.complete = s.complete && complete
+}
+
+// at returns the possible values of the element at index idx.
+func (ev *elemVals) at(idx int64) (map[string]constant.Value, bool) {
+	set, ok := ev.byIndex[idx]
+	if !ok {
+		set = ev.dflt
+	}
+	result := cloneVals(set.vals)
+	union(result, ev.unindexed.vals)
+	return result, set.complete && ev.unindexed.complete
+}
+
+// all returns the possible values of any element.
+func (ev *elemVals) all() (map[string]constant.Value, bool) {
+	var (
+		result   = cloneVals(ev.unindexed.vals)
+		complete = ev.unindexed.complete
+	)
+	for _, set := range ev.byIndex {
+		union(result, set.vals)
+		complete = complete && set.complete
+	}
+	union(result, ev.dflt.vals)
+	return result, complete && ev.dflt.complete
+}
+
+// merge adds the possibilities in other to ev.
+func (ev *elemVals) merge(other *elemVals) {
+	for idx, set := range ev.byIndex {
+		if _, ok := other.byIndex[idx]; !ok {
+			set.add(other.dflt.vals, other.dflt.complete)
+			ev.byIndex[idx] = set
+		}
+	}
+	for idx, otherSet := range other.byIndex {
+		set, ok := ev.byIndex[idx]
+		if !ok {
+			set = valSet{vals: cloneVals(ev.dflt.vals), complete: ev.dflt.complete}
+		}
+		set.add(otherSet.vals, otherSet.complete)
+		ev.byIndex[idx] = set
+	}
+	ev.dflt.add(other.dflt.vals, other.dflt.complete)
+	ev.unindexed.add(other.unindexed.vals, other.unindexed.complete)
+}
+
+// scanIndex determines the possible values of expr[index],
+// where expr has slice or array type.
+// If index is a constant, only the element at that index is considered.
+func (q *query) scanIndex(expr, index ast.Expr) (map[string]constant.Value, bool) {
+	return q.nested(expr, "elements of "+types.ExprString(expr), func() (map[string]constant.Value, bool) {
+		ev := q.scanElems(expr)
+		if tv, ok := q.info.Types[index]; ok && tv.Value != nil {
+			if idx, ok := constant.Int64Val(constant.ToInt(tv.Value)); ok {
+				return ev.at(idx)
+			}
+		}
+		return ev.all()
+	})
+}
+
+// scanElems determines the possible values of the elements of expr,
+// which has slice or array type.
+func (q *query) scanElems(expr ast.Expr) *elemVals {
+	expr = ast.Unparen(expr)
+
+	switch expr := expr.(type) {
+	case *ast.CompositeLit:
+		return q.scanCompositeElems(expr)
+
+	case *ast.Ident:
+		v, ok := q.info.ObjectOf(expr).(*types.Var)
+		if !ok {
+			return q.unknownElems(expr, "unsupported reference to %s", expr.Name)
+		}
+		return q.scanVarElems(v)
+
+	case *ast.SliceExpr:
+		// Indexes shift, so all elements become unindexed.
+		vals, complete := q.scanElems(expr.X).all()
+		ev := newElemVals()
+		ev.unindexed.add(vals, complete)
+		return ev
+
+	case *ast.CallExpr:
+		if isBuiltin(expr, "make", q.info) {
+			// The elements of a new slice are zero.
+			ev := newElemVals()
+			if slice, ok := underlying(q.info.TypeOf(expr)).(*types.Slice); ok {
+				if zero, ok := zeroValue(slice.Elem()); ok {
+					ev.dflt.add(map[string]constant.Value{zero.ExactString(): zero}, true)
+					return ev
+				}
+			}
+			return q.unknownElems(expr, "elements of %s are not tracked", types.ExprString(expr))
+		}
+		if !isBuiltin(expr, "append", q.info) || len(expr.Args) == 0 {
+			return q.unknownElems(expr, "elements of %s are not tracked", types.ExprString(expr))
+		}
+		ev := q.scanElems(expr.Args[0])
+		if expr.Ellipsis.IsValid() {
+			if len(expr.Args) != 2 {
+				return q.unknownElems(expr, "unsupported call form %s", types.ExprString(expr))
+			}
+			if tv, ok := q.info.Types[expr.Args[1]]; ok && isString(tv.Type) {
+				// append(b, s...) with s a string.
+				return q.unknownElems(expr, "bytes appended from string %s are not tracked", types.ExprString(expr.Args[1]))
+			}
+			ev.unindexed.add(q.scanElems(expr.Args[1]).all())
+			return ev
+		}
+		for _, arg := range expr.Args[1:] {
+			ev.unindexed.add(q.scan(arg))
+		}
+		return ev
+	}
+
+	return q.unknownElems(expr, "elements of %s are not tracked", types.ExprString(expr))
+}
+
+// scanCompositeElems determines the possible values of the elements
+// of a slice or array composite literal.
+func (q *query) scanCompositeElems(lit *ast.CompositeLit) *elemVals {
+	ev := newElemVals()
+
+	var idx int64
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			tv, ok := q.info.Types[kv.Key]
+			if !ok || tv.Value == nil {
+				return q.unknownElems(kv.Key, "index %s is not a constant", types.ExprString(kv.Key))
+			}
+			idx, ok = constant.Int64Val(constant.ToInt(tv.Value))
+			if !ok {
+				return q.unknownElems(kv.Key, "index %s is out of range", types.ExprString(kv.Key))
+			}
+			elt = kv.Value
+		}
+		var set valSet
+		set.complete = true
+		set.add(q.scan(elt))
+		ev.byIndex[idx] = set
+		idx++
+	}
+
+	switch typ := underlying(q.info.TypeOf(lit)).(type) {
+	case *types.Slice:
+		// Indexes not in the literal are out of range.
+
+	case *types.Array:
+		if int64(len(ev.byIndex)) < typ.Len() {
+			// Some elements are implicitly zero.
+			if zero, ok := zeroValue(typ.Elem()); ok {
+				ev.dflt.add(map[string]constant.Value{zero.ExactString(): zero}, true)
+			} else {
+				q.incomplete(lit, "zero value of %s is not a constant", typ.Elem())
+				ev.dflt.complete = false
+			}
+		}
+
+	default:
+		return q.unknownElems(lit, "unsupported composite literal of type %s", q.info.TypeOf(lit))
+	}
+
+	return ev
+}
+
+// scanVarElems determines the possible values of the elements of v,
+// which has slice or array type.
+// Values stored into its elements,
+// as by v[0] = x,
+// are included.
+// The result is incomplete if v is used in any way
+// that might let its elements change unseen,
+// such as passing a slice to a function.
+func (q *query) scanVarElems(v *types.Var) *elemVals {
+	v = v.Origin()
+
+	key := elemsKey{v: v}
+	if !q.enter(key) {
+		return newElemVals()
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		ev := newElemVals()
+		ev.unindexed.complete = false
+		return ev
+	}
+
+	var (
+		// ev starts out with no possibilities;
+		// each assignment to v adds some.
+		ev   *elemVals
+		safe = true
+
+		// stores are the stores into elements of v,
+		// applied once all the assignments to v are known.
+		stores []*ast.AssignStmt
+	)
+
+	add := func(other *elemVals) {
+		if ev == nil {
+			ev = other
+		} else {
+			ev.merge(other)
+		}
+	}
+
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.paramArg(v); ok {
+			q.inFrames(depth, func() { add(q.scanElems(arg)) })
+		} else {
+			add(q.unknownElems(node, "%s is a parameter and its argument is unknown", v.Name()))
+		}
+	}
+
+	inspectWithStack(node, func(n ast.Node, stack []ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			idx := -1
+			for i, lhs := range n.Lhs {
+				if exprIsVar(lhs, v, q.info) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				return true
+			}
+			if (n.Tok != token.ASSIGN && n.Tok != token.DEFINE) || len(n.Lhs) != len(n.Rhs) {
+				add(q.unknownElems(n, "unsupported assignment to %s", v.Name()))
+				return true
+			}
+			add(q.scanElems(n.Rhs[idx]))
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					zeroed := newElemVals()
+					if arr, ok := v.Type().Underlying().(*types.Array); ok && arr.Len() > 0 {
+						if zero, ok := zeroValue(arr.Elem()); ok {
+							zeroed.dflt.add(map[string]constant.Value{zero.ExactString(): zero}, true)
+						} else {
+							q.incomplete(n, "zero value of %s is not a constant", arr.Elem())
+							zeroed.dflt.complete = false
+						}
+					}
+					// A nil slice has no elements.
+					add(zeroed)
+
+				case len(n.Names):
+					add(q.scanElems(n.Values[i]))
+
+				default:
+					add(q.unknownElems(n, "unsupported declaration of %s", v.Name()))
+				}
+			}
+
+		case *ast.Ident:
+			if !identIsVar(n, v, q.info) || q.info.Defs[n] != nil {
+				return true
+			}
+			if store, ok := elemStore(n, stack); ok {
+				stores = append(stores, store)
+				return true
+			}
+			if !elemsUseIsSafe(n, stack, v, q.info) {
+				q.incomplete(n, "%s is used in a way that may change its elements", v.Name())
+				safe = false
+			}
+		}
+		return true
+	})
+
+	if ev == nil {
+		ev = newElemVals()
+	}
+	for _, store := range stores {
+		q.addElemStore(ev, store, v)
+	}
+	if !safe {
+		ev.unindexed.complete = false
+	}
+	return ev
+}
+
+// elemStore tells whether ident,
+// with ancestors in stack,
+// is the slice or array in a store into one of its elements,
+// as in ident[i] = x,
+// and if so returns the assignment.
+func elemStore(ident *ast.Ident, stack []ast.Node) (*ast.AssignStmt, bool) {
+	parent, child, ancestors := parentOf(ident, stack)
+	idx, ok := parent.(*ast.IndexExpr)
+	if !ok || idx.X != child {
+		return nil, false
+	}
+	parent, child, _ = parentOf(idx, ancestors)
+	assign, ok := parent.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != len(assign.Rhs) || !isLHS(assign, child) {
+		return nil, false
+	}
+	return assign, true
+}
+
+// addElemStore adds to ev the values stored into the elements of v
+// by store,
+// an assignment like v[i] = x.
+// An element at a constant index may have the value stored
+// or the one it had before;
+// a store at any other index may be to any element.
+func (q *query) addElemStore(ev *elemVals, store *ast.AssignStmt, v *types.Var) {
+	for i, lhs := range store.Lhs {
+		idx, ok := ast.Unparen(lhs).(*ast.IndexExpr)
+		if !ok || !exprIsVar(idx.X, v, q.info) {
+			continue
+		}
+		vals, complete := q.scan(store.Rhs[i])
+
+		if tv, ok := q.info.Types[idx.Index]; ok && tv.Value != nil {
+			if n, ok := constant.Int64Val(constant.ToInt(tv.Value)); ok {
+				set, ok := ev.byIndex[n]
+				if !ok {
+					set = valSet{vals: cloneVals(ev.dflt.vals), complete: ev.dflt.complete}
+				}
+				set.add(vals, complete)
+				ev.byIndex[n] = set
+				continue
+			}
+		}
+		ev.unindexed.add(vals, complete)
+	}
+}
+
+// elemsUseIsSafe tells whether the given use of ident,
+// which refers to the slice or array variable v,
+// cannot change the elements of v.
+// The stack holds the ancestors of ident, innermost last.
+func elemsUseIsSafe(ident *ast.Ident, stack []ast.Node, v *types.Var, info *types.Info) bool {
+	parent, child, ancestors := parentOf(ident, stack)
+
+	switch parent := parent.(type) {
+	case *ast.IndexExpr:
+		if parent.X != child {
+			// Used as an index.
+			return true
+		}
+		// Reading an element is safe; storing into one is not.
+		return !isStoredTo(parent, ancestors)
+
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == child {
+				// Assigning v itself is handled by the caller.
+				return true
+			}
+		}
+		// Copying a slice makes an alias.
+		return isArray(v.Type())
+
+	case *ast.SliceExpr:
+		// Only v = v[a:b] is safe.
+		return parent.X == child && assignsBackTo(parent, ancestors, v, info)
+
+	case *ast.CallExpr:
+		if isBuiltin(parent, "len", info) || isBuiltin(parent, "cap", info) {
+			return true
+		}
+		if parent.Fun != child && isArray(v.Type()) {
+			// Passing an array passes a copy.
+			return true
+		}
+		if isBuiltin(parent, "append", info) {
+			if len(parent.Args) > 0 && parent.Args[0] == child {
+				// Only v = append(v, ...) is safe;
+				// appending to v and keeping the result elsewhere may share v's backing array.
+				return assignsBackTo(parent, ancestors, v, info)
+			}
+			// Appending the elements of v to something else copies them.
+			return true
+		}
+		return false
+
+	case *ast.RangeStmt:
+		return parent.X == child
+
+	case *ast.BinaryExpr:
+		// Comparing arrays (or a slice with nil).
+		return true
+	}
+
+	return false
+}
+
+// assignsBackTo tells whether expr is the right-hand side of v = expr.
+// The ancestors of expr are in stack, innermost last.
+func assignsBackTo(expr ast.Expr, stack []ast.Node, v *types.Var, info *types.Info) bool {
+	parent, child, _ := parentOf(expr, stack)
+	assign, ok := parent.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 || assign.Rhs[0] != child {
+		return false
+	}
+	return exprIsVar(assign.Lhs[0], v, info)
+}
+
+// isStoredTo tells whether the index expression idx is the target of an assignment,
+// an increment or decrement,
+// or has its address taken.
+// The ancestors of idx are in stack, innermost last.
+func isStoredTo(idx *ast.IndexExpr, stack []ast.Node) bool {
+	parent, child, _ := parentOf(idx, stack)
+
+	switch parent := parent.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == child {
+				return true
+			}
+		}
+	case *ast.IncDecStmt:
+		return true
+	case *ast.UnaryExpr:
+		return parent.Op == token.AND
+	case *ast.RangeStmt:
+		return parent.Key == child || parent.Value == child
+	}
+	return false
+}
+
+// parentOf finds the nearest ancestor of node that is not a ParenExpr.
+// The ancestors of node are in stack, innermost last.
+// It returns that ancestor;
+// its child that contains node
+// (which is node itself or the outermost ParenExpr around it);
+// and the ancestors of the parent.
+func parentOf(node ast.Node, stack []ast.Node) (parent, child ast.Node, ancestors []ast.Node) {
+	child = node
+	for i := len(stack) - 1; i >= 0; i-- {
+		if p, ok := stack[i].(*ast.ParenExpr); ok {
+			child = p
+			continue
+		}
+		return stack[i], child, stack[:i]
+	}
+	return nil, child, nil
+}
+
+// inspectWithStack is like [ast.Inspect],
+// but also passes f the ancestors of each node, innermost last.
+func inspectWithStack(node ast.Node, f func(n ast.Node, stack []ast.Node) bool) {
+	var stack []ast.Node
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		if !f(n, stack) {
+			return false
+		}
+		stack = append(stack, n)
+		return true
+	})
+}
+
+// isBuiltin tells whether call is a call to the named builtin function.
+func isBuiltin(call *ast.CallExpr, name string, info *types.Info) bool {
+	ident, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := info.ObjectOf(ident).(*types.Builtin)
+	return ok && b.Name() == name
+}
+
+func isArray(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Array)
+	return ok
+}
+
+func isString(typ types.Type) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+func cloneVals(vals map[string]constant.Value) map[string]constant.Value {
+	result := make(map[string]constant.Value, len(vals))
+	union(result, vals)
+	return result
+}
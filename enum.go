@@ -0,0 +1,140 @@
+package exprvals
+
+import (
+	"cmp"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// Enum is the set of constants declared with a given named type,
+// as found by [EnumValues].
+type Enum struct {
+	Type *types.Named
+
+	// Members are the constants of the type,
+	// in declaration order.
+	// Blank members (declared as _, e.g. to skip a value of iota)
+	// are included,
+	// so that Members reflects the declarations;
+	// but their values are not among the enum's [Enum.Values].
+	Members []*EnumMember
+}
+
+// EnumMember is a constant in an [Enum].
+type EnumMember struct {
+	// Obj is the constant.
+	// Its name is "_" for a blank member.
+	Obj *types.Const
+
+	// Blank tells whether this is a blank member.
+	Blank bool
+}
+
+// Value is the member's value.
+func (m *EnumMember) Value() constant.Value {
+	return m.Obj.Val()
+}
+
+// EnumValues finds the constants of type typ
+// declared at package level in files.
+// Constants whose values are computed from iota (e.g. 1 << iota, or iota * 100)
+// are resolved by the type checker and need no special treatment here.
+//
+// If there are none,
+// as for a type from a dependency whose syntax is not loaded
+// (such as [go/token.Token] or [reflect.Kind]),
+// the members are the exported constants of type typ
+// in the scope of typ's package,
+// as the type checker knows it from compiler export data.
+// Those are in declaration order,
+// as far as the positions in the export data tell,
+// and include no blank members.
+//
+// The result is nil if there are no such constants.
+func EnumValues(typ *types.Named, files []*ast.File, info *types.Info) *Enum {
+	var members []*EnumMember
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					obj, ok := info.Defs[name].(*types.Const)
+					if !ok || !types.Identical(obj.Type(), typ) {
+						continue
+					}
+					members = append(members, &EnumMember{Obj: obj, Blank: name.Name == "_"})
+				}
+			}
+		}
+	}
+
+	if len(members) == 0 {
+		members = scopeEnumMembers(typ)
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	return &Enum{Type: typ, Members: members}
+}
+
+// scopeEnumMembers finds the exported constants of type typ
+// in the scope of its package,
+// sorted by position.
+func scopeEnumMembers(typ *types.Named) []*EnumMember {
+	pkg := typ.Obj().Pkg()
+	if pkg == nil {
+		return nil
+	}
+
+	var consts []*types.Const
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !obj.Exported() || !types.Identical(obj.Type(), typ) {
+			continue
+		}
+		consts = append(consts, obj)
+	}
+	slices.SortStableFunc(consts, func(a, b *types.Const) int { return cmp.Compare(a.Pos(), b.Pos()) })
+
+	members := make([]*EnumMember, 0, len(consts))
+	for _, obj := range consts {
+		members = append(members, &EnumMember{Obj: obj})
+	}
+	return members
+}
+
+// Values returns the distinct values of the non-blank members of the enum,
+// keyed by their ExactString representation.
+// Members with the same value (such as an alias, Default = Fast)
+// contribute a single entry.
+func (e *Enum) Values() map[string]constant.Value {
+	result := make(map[string]constant.Value)
+	for _, m := range e.Members {
+		if m.Blank {
+			continue
+		}
+		v := m.Value()
+		result[v.ExactString()] = v
+	}
+	return result
+}
+
+// Lookup returns the non-blank members of the enum with value v.
+// There may be more than one.
+func (e *Enum) Lookup(v constant.Value) []*EnumMember {
+	var result []*EnumMember
+	for _, m := range e.Members {
+		if !m.Blank && valuesEqual(m.Value(), v) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
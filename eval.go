@@ -0,0 +1,98 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+)
+
+// EvalInPackage parses the Go expression expr,
+// type-checks it in the scope of pkg,
+// and scans it,
+// as in:
+//
+//	EvalInPackage(pkg, `prefix + "suffix"`)
+//
+// It is for REPL-like exploration tools
+// and for test assertions written as plain expression strings.
+// The expression may refer to the package-level declarations of pkg
+// and to the packages imported by any one of its files,
+// by the names that file gives them.
+//
+// The type information of pkg is not changed.
+func EvalInPackage(pkg *Package, expr string) (Result, error) {
+	if pkg.Types == nil {
+		return Result{}, fmt.Errorf("no type information for %s", pkg.Path)
+	}
+	if missing := missingInfo(pkg.Info); missing != "" {
+		return Result{}, fmt.Errorf("%s: %s", pkg.Path, missing)
+	}
+
+	e, err := parser.ParseExprFrom(pkg.Fset, "", expr, 0)
+	if err != nil {
+		return Result{}, fmt.Errorf("parsing %q: %w", expr, err)
+	}
+
+	// Imports are in the scopes of files,
+	// so check the expression in each file's scope
+	// until one of them understands it.
+	positions := []token.Pos{token.NoPos}
+	if len(pkg.Files) > 0 {
+		positions = positions[:0]
+		for _, file := range pkg.Files {
+			positions = append(positions, file.Name.Pos())
+		}
+	}
+	var (
+		info     = cloneInfo(pkg.Info)
+		firstErr error
+	)
+	for _, pos := range positions {
+		err := types.CheckExpr(pkg.Fset, pkg.Types, pos, e, info)
+		if err == nil {
+			firstErr = nil
+			break
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return Result{}, fmt.Errorf("type-checking %q: %w", expr, firstErr)
+	}
+
+	s := &Scanner{Files: pkg.Files, Info: info}
+	return s.Scan(e), nil
+}
+
+// cloneInfo returns a copy of info,
+// to which the types of new syntax can be added
+// without changing info.
+func cloneInfo(info *types.Info) *types.Info {
+	result := &types.Info{
+		Types:      maps.Clone(info.Types),
+		Defs:       maps.Clone(info.Defs),
+		Uses:       maps.Clone(info.Uses),
+		Implicits:  maps.Clone(info.Implicits),
+		Instances:  maps.Clone(info.Instances),
+		Scopes:     maps.Clone(info.Scopes),
+		Selections: maps.Clone(info.Selections),
+	}
+	// Missing maps can still record the types of the new syntax.
+	fresh := newInfo()
+	if result.Implicits == nil {
+		result.Implicits = fresh.Implicits
+	}
+	if result.Instances == nil {
+		result.Instances = fresh.Instances
+	}
+	if result.Scopes == nil {
+		result.Scopes = fresh.Scopes
+	}
+	if result.Selections == nil {
+		result.Selections = fresh.Selections
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"maps"
+	"strings"
+)
+
+// Derivation explains how a scanned expression may come to have one particular value.
+// See [Scanner.Explain].
+type Derivation struct {
+	Value constant.Value
+
+	// Steps lead from the scanned expression
+	// through the variables, calls, and expressions the value passes through
+	// to the one where it originates.
+	Steps []Step
+}
+
+// Step is one step of a [Derivation].
+type Step struct {
+	// Node is the expression (or other syntax) the step is about.
+	Node ast.Node
+
+	// Msg describes the step,
+	// e.g. "variable x" or "call to f".
+	Msg string
+}
+
+// Format renders the derivation as text,
+// one step per line,
+// each indented beneath the one before.
+// If fset is non-nil,
+// each line begins with the position of the step.
+func (d *Derivation) Format(fset *token.FileSet) string {
+	buf := new(strings.Builder)
+	for i, step := range d.Steps {
+		buf.WriteString(strings.Repeat("  ", i))
+		if fset != nil && step.Node.Pos().IsValid() {
+			fmt.Fprintf(buf, "%s: ", fset.Position(step.Node.Pos()))
+		}
+		buf.WriteString(step.Msg)
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// Explain tells how the expression scanned to produce res
+// may come to have the value val,
+// answering "why is this value here?"
+// It rescans the expression,
+// so it costs nothing until it is needed.
+//
+// When a value can arrive by more than one route,
+// Explain gives the first one found.
+// A value computed from others
+// (e.g. by n*10 + 1)
+// ends its derivation at the computation.
+//
+// The result is nil if val is not among the values found for the expression,
+// or if res did not come from [Scanner.Scan], [Scanner.ScanCallResult], or [Scanner.MapKeys].
+func (s *Scanner) Explain(res Result, val constant.Value) *Derivation {
+	if res.key.node == nil {
+		return nil
+	}
+
+	q := s.newQuery()
+	root := &traceNode{node: res.key.node}
+	q.trace = []*traceNode{root}
+
+	var msg string
+	switch idx := res.key.idx; {
+	case idx == -1:
+		expr := res.key.node.(ast.Expr)
+		msg = types.ExprString(expr)
+		q.result(expr, "explaining "+msg, func() (map[string]constant.Value, bool) {
+			root.vals, _ = q.scan(expr)
+			return nil, true
+		})
+	case idx == -2:
+		expr := res.key.node.(ast.Expr)
+		msg = "keys of " + types.ExprString(expr)
+		q.result(expr, "explaining "+msg, func() (map[string]constant.Value, bool) {
+			root.vals, _ = q.scanMapKeys(expr)
+			return nil, true
+		})
+	default:
+		call := res.key.node.(*ast.CallExpr)
+		msg = fmt.Sprintf("result %d of %s", idx, types.ExprString(call))
+		q.result(call, "explaining "+msg, func() (map[string]constant.Value, bool) {
+			root.vals, _ = q.scanCallResult(call, idx)
+			return nil, true
+		})
+	}
+	root.msg = msg
+
+	path := root.path(val.ExactString())
+	if path == nil {
+		return nil
+	}
+	d := &Derivation{Value: val}
+	for _, t := range path {
+		d.Steps = append(d.Steps, Step{Node: t.node, Msg: t.msg})
+	}
+	return d
+}
+
+// traceNode records a scan,
+// and its nested scans,
+// when a query is tracing for [Scanner.Explain].
+type traceNode struct {
+	node     ast.Node
+	msg      string
+	vals     map[string]constant.Value
+	children []*traceNode
+}
+
+// traced runs f,
+// which scans node (described by msg),
+// recording it in the query's trace if there is one.
+func (q *query) traced(node ast.Node, msg string, f func() (map[string]constant.Value, bool)) (map[string]constant.Value, bool) {
+	if len(q.trace) == 0 {
+		return f()
+	}
+
+	t := &traceNode{node: node, msg: msg}
+	parent := q.trace[len(q.trace)-1]
+	parent.children = append(parent.children, t)
+
+	q.trace = append(q.trace, t)
+	vals, ok := f()
+	q.trace = q.trace[:len(q.trace)-1]
+
+	// The caller may add to vals.
+	t.vals = maps.Clone(vals)
+	return vals, ok
+}
+
+// path finds a path through the trace rooted at t
+// along which the value with the given ExactString representation passes,
+// down to the node where it originates.
+// A node that merely wraps a scan of the same syntax
+// (as when scanning an identifier scans its variable)
+// is left out in favor of the inner one.
+func (t *traceNode) path(key string) []*traceNode {
+	if _, ok := t.vals[key]; !ok {
+		return nil
+	}
+	for _, c := range t.children {
+		p := c.path(key)
+		if p == nil {
+			continue
+		}
+		if c.node == t.node {
+			return p
+		}
+		return append([]*traceNode{t}, p...)
+	}
+	return []*traceNode{t}
+}
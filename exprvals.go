@@ -1,4 +1,53 @@
 // Package exprvals provides a way to scan Go AST expressions for the values they can represent.
+//
+// # Values
+//
+// Values are represented as [constant.Value]s,
+// which can be compared with [constant.Compare]
+// and keyed (as in the maps this package returns) by their ExactString representation.
+// The constant.Value interface cannot be implemented outside the go/constant package,
+// so domain-specific analyses
+// (of currency amounts, URLs, and so on)
+// should encode their values in the available kinds,
+// usually as strings or integers,
+// and decode them from the results.
+//
+// # Extending
+//
+// Knowledge about functions whose bodies are not available,
+// or whose results are better described some other way,
+// can be added with [RegisterSummary].
+// A summary receives the call
+// and a function for scanning expressions (such as the call's arguments) in its context,
+// and produces the possible values of a result.
+//
+// # Type information
+//
+// Scanning needs the Types, Defs, and Uses maps of a [types.Info]
+// for the files being scanned.
+// Selections should also be present;
+// without it, fields and methods are resolved less precisely.
+// So should Instances;
+// without it, conversions to type parameters in generic functions
+// use every type in the parameter's constraint
+// rather than the type argument of the call.
+// When the information is missing,
+// results are incomplete,
+// with a reason saying what is missing.
+//
+// When loading packages with golang.org/x/tools/go/packages,
+// the mode
+//
+//	packages.NeedName | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
+//
+// suffices for the packages to be scanned.
+// Their dependencies need only types
+// (which go/packages gets cheaply, from export data),
+// not syntax,
+// though calls into dependencies are then not followed.
+// Callers that cannot afford NeedTypesInfo
+// can compute the information for just the packages they scan
+// with [TypeInfo].
 package exprvals
 
 import (
@@ -6,6 +55,8 @@ import (
 	"go/constant"
 	"go/token"
 	"go/types"
+	"slices"
+	"time"
 )
 
 // Scan scans the given AST expression node to determine the values it might represent.
@@ -13,8 +64,46 @@ import (
 // If it is an identifier that refers to a constant, Scan returns that value.
 // If it is an identifier that refers to a variable,
 // Scan looks at all the assignments to that variable to determine the possible values.
+// If it is a call to a function defined in files,
+// Scan looks at the values the function can return,
+// resolving the function's parameters to the call's arguments.
+// If it indexes a slice or array,
+// Scan determines the possible values of the elements,
+// following composite literals, append, and reslicing.
+// When the index is a constant,
+// only what is known about the element at that index matters,
+// so an unknown value elsewhere in the slice does not make the result incomplete.
+// If it selects a struct field,
+// Scan follows the struct back through composite literals,
+// stores to the field,
+// and function and method results,
+// including chains of method calls on builders
+// whose methods store to the fields of their receivers.
+// If it is a unary or binary operation,
+// Scan applies the operator to the possible values of the operands.
+// If it is a conversion to a basic type,
+// Scan converts the possible values of the operand,
+// as a conversion at run time would.
+// Inside a generic function,
+// conversions to a type parameter are handled according to the parameter's constraint.
+// If it dereferences a pointer obtained from the flag package
+// (e.g. *flag.String("mode", "fast", "...")),
+// the flag's default is among the possible values,
+// but the result is never complete, since the value may come from the command line.
+// The components of a URL obtained from url.Parse
+// (such as u.Host or u.Hostname())
+// are determined from the possible values of the string parsed.
+// Likewise, the results of functions in path and path/filepath
+// (such as filepath.Join and filepath.IsLocal)
+// are computed from the possible values of their arguments.
 // In the future, other types of expression may be supported.
 //
+// Code that cannot be reached,
+// because it follows a call that never returns
+// (such as os.Exit, log.Fatal, panic,
+// or a function in files whose every path ends in one of those),
+// is ignored.
+//
 // The result is a map of [constant.Value]s.
 // Each key is the string representation (using ExactString) of the value.
 // This function also returns a boolean indicating whether all possible values were determined.
@@ -30,77 +119,708 @@ import (
 // Scan can determine that, by the time the return statement is reached,
 // x can be only "hello" or "goodbye" and nothing else.
 func Scan(node ast.Expr, files []*ast.File, info *types.Info) (map[string]constant.Value, bool) {
+	res := (&Scanner{Files: files, Info: info}).Scan(node)
+	return res.Values, res.Complete
+}
+
+// ScanCallResult performs a [Scan] on the idx'th result of the given call expression.
+func ScanCallResult(call *ast.CallExpr, idx int, files []*ast.File, info *types.Info) (map[string]constant.Value, bool) {
+	res := (&Scanner{Files: files, Info: info}).ScanCallResult(call, idx)
+	return res.Values, res.Complete
+}
+
+// query holds the state of a single top-level call to [Scan] or [ScanCallResult].
+type query struct {
+	files []*ast.File
+	info  *types.Info
+
+	// active holds the variables and function results
+	// whose values are currently being computed.
+	// It is used to cut off cycles like x = y; y = x.
+	active map[activeKey]activeEntry
+
+	// cycle is the least depth in active
+	// of a computation that a cycle has been cut off at,
+	// or 0 if there is none in progress.
+	// See [query.noCycle].
+	cycle int
+
+	// lossy holds the depths in active of the computations
+	// that [query.noCycle] found values missing from,
+	// recursive holds those of the computations
+	// that a cycle through a recursive call has been cut off at,
+	// and seeds holds the values found so far
+	// for those being recomputed by [query.fixpoint].
+	lossy     map[int]bool
+	recursive map[int]bool
+	seeds     map[activeKey]map[string]constant.Value
+
+	// frames is the stack of calls that scanCallResult has descended into.
+	// It lets the parameters of a callee be resolved to the caller's arguments.
+	frames []*frame
+
+	// why is the stack of reasons for the nested scans in progress.
+	// See [query.nested].
+	why []*Reason
+
+	// noReturn caches the results of [query.inferNeverReturns].
+	noReturn map[*types.Func]bool
+
+	// assume holds the assumed values of variables and fields.
+	// See [Scanner.Assume].
+	assume map[*types.Var]map[string]constant.Value
+
+	// narrowed holds what is known about variables
+	// from the conditions of the statements enclosing the one
+	// whose reachability is being determined.
+	// See [Scanner.Reachable].
+	narrowed map[*types.Var]valSet
+
+	// maxValues is the limit on the size of value sets,
+	// and truncated tells whether it has been exceeded.
+	// See [Scanner.MaxValues].
+	maxValues int
+	truncated bool
+
+	// deadline is when the query times out,
+	// or zero for no deadline,
+	// and timedOut tells whether it has.
+	// See [Scanner.Timeout].
+	deadline time.Time
+	timedOut bool
+
+	// unknownCalls tells how to treat calls to unknown functions.
+	// See [Scanner.UnknownCalls].
+	unknownCalls UnknownCalls
+
+	// facts holds the results of functions in other packages, if known.
+	facts *facts
+
+	// tracked, when non-nil,
+	// holds the variables that [Scanner.AnalyzeFunc] follows statement by statement.
+	tracked map[*types.Var]bool
+
+	// fieldVars, when non-nil,
+	// maps struct variables that [Scanner.AnalyzeFunc] follows field by field
+	// to the variables representing their fields,
+	// keyed by field path (see [pathKey]).
+	fieldVars map[*types.Var]map[string]*types.Var
+
+	// sent, when non-nil,
+	// holds the values that the send statements outside loops
+	// in the function that [Scanner.AnalyzeFunc] is analyzing
+	// send in the environments where they run.
+	// See [query.sentValues].
+	sent map[*ast.SendStmt]valSet
+
+	// audited, when non-nil,
+	// holds the local variables that the scan depends on,
+	// for checking with [query.audit].
+	audited map[*types.Var]bool
+
+	// trace, when non-empty,
+	// is the stack of scans being recorded for [Scanner.Explain].
+	trace []*traceNode
+
+	// survey, when non-nil,
+	// records the syntax the query examines for [Survey].
+	survey *survey
+
+	// intraprocedural tells whether calls of other functions are opaque.
+	// See [Scanner.Intraprocedural].
+	intraprocedural bool
+
+	// deps, when non-nil,
+	// records what the query consults for [Scanner.ScanDeps].
+	deps *deps
+}
+
+// frame records a call that the query has descended into.
+type frame struct {
+	call *ast.CallExpr
+
+	// args maps the callee's parameters (and receiver)
+	// to the corresponding expressions in the caller.
+	args map[*types.Var]ast.Expr
+
+	// depth is the length of query.frames at the call site,
+	// which is the context in which the expressions in args must be scanned.
+	depth int
+
+	// recv is the method's receiver, if any,
+	// and recvX is the expression it is selected from.
+	// Unlike args, this includes pointer receivers,
+	// for the purpose of finding the values of the receiver's fields.
+	recv  *types.Var
+	recvX ast.Expr
+
+	// targs maps the type parameters of a generic function
+	// to the type arguments of the call's instantiation.
+	targs map[*types.TypeParam]types.Type
+}
+
+// activeKey identifies a computation in query.active.
+// The same variable or function result may be computed differently
+// under different calls,
+// so the innermost call (if any) is part of the key.
+type activeKey struct {
+	obj  any
+	call *ast.CallExpr
+}
+
+func newQuery(files []*ast.File, info *types.Info) *query {
+	return &query{
+		files:     files,
+		info:      info,
+		active:    make(map[activeKey]activeEntry),
+		lossy:     make(map[int]bool),
+		recursive: make(map[int]bool),
+		seeds:     make(map[activeKey]map[string]constant.Value),
+		noReturn:  make(map[*types.Func]bool),
+		assume:    make(map[*types.Var]map[string]constant.Value),
+	}
+}
+
+// callResultKey identifies the result of a call in query.active.
+type callResultKey struct {
+	call *ast.CallExpr
+	idx  int
+}
+
+// enter marks obj as being computed in the current context.
+// It returns false if it already is,
+// in which case the caller should not proceed
+// (and must not call leave).
+// The values that the caller then contributes (usually none)
+// are only those that the outer computation of obj would copy unchanged;
+// see [query.noCycle].
+func (q *query) enter(obj any) bool {
+	key := q.activeKey(obj)
+	if e, ok := q.active[key]; ok {
+		if q.cycle == 0 || e.depth < q.cycle {
+			q.cycle = e.depth
+		}
+		if e.frames != len(q.frames) {
+			// The same call, nested in itself.
+			q.recursive[e.depth] = true
+		}
+		return false
+	}
+	q.active[key] = activeEntry{depth: len(q.active) + 1, frames: len(q.frames)}
+	return true
+}
+
+func (q *query) leave(obj any) {
+	key := q.activeKey(obj)
+	depth := q.active[key].depth
+	if q.cycle >= depth {
+		// Every cycle cut off so far ends here.
+		q.cycle = 0
+	}
+	delete(q.lossy, depth)
+	delete(q.recursive, depth)
+	delete(q.active, key)
+}
+
+// activeEntry describes a computation in query.active:
+// its depth in the stack of such computations (counting from 1)
+// and the number of frames when it began.
+type activeEntry struct {
+	depth, frames int
+}
+
+// seed returns the values found so far for obj
+// by the current round of [query.fixpoint],
+// if there is one.
+func (q *query) seed(obj any) (map[string]constant.Value, bool) {
+	key := q.activeKey(obj)
+	vals, ok := q.seeds[key]
+	if !ok || q.active[key].frames != len(q.frames) {
+		return nil, false
+	}
+	return cloneVals(vals), true
+}
+
+// maxRounds limits the rounds of [query.fixpoint].
+const maxRounds = 8
+
+// fixpoint computes the values of obj,
+// which has just been entered in q.active,
+// with f.
+// If some of them depend on others through a cycle,
+// as in x = x + 1 in a loop,
+// f is run again,
+// with the cycle giving the values found so far (see [query.seed]),
+// until no more are found,
+// or for at most maxRounds more rounds.
+// A cycle through a recursive call is not followed this way,
+// since it joins the values of different calls.
+// The node at, if not nil,
+// is where obj is used,
+// for reporting a failure to settle.
+func (q *query) fixpoint(obj any, at ast.Node, f func() (map[string]constant.Value, bool)) (map[string]constant.Value, bool) {
+	var (
+		key   = q.activeKey(obj)
+		depth = q.active[key].depth
+		why   *Reason
+		nwhy  int
+	)
+	if len(q.why) > 0 {
+		why = q.why[len(q.why)-1]
+		nwhy = len(why.Causes)
+	}
+
+	vals, complete := f()
+	if !q.lossy[depth] || q.recursive[depth] {
+		return vals, complete
+	}
+	delete(q.lossy, depth)
+
+	// The result of the first round,
+	// to fall back on.
+	var (
+		first       = cloneVals(vals)
+		firstCauses []*Reason
+	)
+	if why != nil {
+		firstCauses = slices.Clone(why.Causes[nwhy:])
+	}
+
+	defer delete(q.seeds, key)
+	for range maxRounds {
+		if why != nil {
+			// Forget the reasons found in the previous round.
+			why.Causes = why.Causes[:nwhy]
+		}
+		q.seeds[key] = vals
+		next, nextComplete := f()
+		if q.recursive[depth] {
+			break
+		}
+
+		settled := true
+		for k, v := range next {
+			if _, ok := vals[k]; !ok {
+				vals[k] = v
+				settled = false
+			}
+		}
+		if settled {
+			return vals, nextComplete
+		}
+	}
+
+	if why != nil {
+		why.Causes = append(why.Causes[:nwhy], firstCauses...)
+	}
+	if !q.recursive[depth] {
+		q.incomplete(at, "values depending on their own still change after %d rounds", maxRounds)
+	}
+	return first, false
+}
+
+// noCycle runs f,
+// which computes the values of expr from those of its operands.
+// If a cycle is cut off while scanning the operands
+// at a computation that was already in progress when f began,
+// as in x = x + 1,
+// the operands lack the values that the computation has yet to find,
+// and so does the result,
+// which is then incomplete.
+// (A cycle through copies alone, as in x = y; y = x,
+// loses nothing.)
+func (q *query) noCycle(expr ast.Expr, f func() (map[string]constant.Value, bool)) (map[string]constant.Value, bool) {
+	depth, outer := len(q.active), q.cycle
+	q.cycle = 0
+
+	vals, complete := f()
+
+	if q.cycle != 0 && q.cycle <= depth {
+		q.incomplete(expr, "%s depends on its own value, through a cycle that is not followed", types.ExprString(expr))
+		complete = false
+		q.lossy[q.cycle] = true
+	}
+	if outer != 0 && (q.cycle == 0 || outer < q.cycle) {
+		q.cycle = outer
+	}
+	return vals, complete
+}
+
+func (q *query) activeKey(obj any) activeKey {
+	key := activeKey{obj: obj}
+	if len(q.frames) > 0 {
+		key.call = q.frames[len(q.frames)-1].call
+	}
+	return key
+}
+
+// paramArg looks for the argument bound to the parameter (or receiver) v
+// in the innermost frame that binds it.
+// It returns the argument expression and the frame depth in which to scan it.
+func (q *query) paramArg(v *types.Var) (ast.Expr, int, bool) {
+	for i := len(q.frames) - 1; i >= 0; i-- {
+		if arg, ok := q.frames[i].args[v]; ok {
+			return arg, q.frames[i].depth, true
+		}
+	}
+	return nil, 0, false
+}
+
+// inFrames calls f in the context of the first depth frames of the query.
+func (q *query) inFrames(depth int, f func()) {
+	saved := q.frames
+	// Clip, so that pushing frames doesn't overwrite the saved ones.
+	q.frames = slices.Clip(q.frames[:depth])
+	defer func() { q.frames = saved }()
+
+	f()
+}
+
+func (q *query) scan(node ast.Expr) (map[string]constant.Value, bool) {
 	node = ast.Unparen(node)
+	q.examine(node)
+	if len(q.trace) > 0 {
+		return q.traced(node, types.ExprString(node), func() (map[string]constant.Value, bool) {
+			return q.scanExpr(node)
+		})
+	}
+	return q.scanExpr(node)
+}
 
-	tv, ok := info.Types[node]
-	if ok && tv.IsValue() {
+func (q *query) scanExpr(node ast.Expr) (map[string]constant.Value, bool) {
+	if tv, ok := q.info.Types[node]; ok && tv.Value != nil {
 		v := tv.Value
-		if v == nil {
-			return nil, false
-		}
 		return map[string]constant.Value{v.ExactString(): v}, true
 	}
 
 	switch node := node.(type) {
 	case *ast.Ident:
-		return scanIdent(node, files, info)
+		return q.scanIdent(node)
+
+	case *ast.StarExpr:
+		return q.scanPointee(node.X)
+
+	case *ast.BinaryExpr:
+		return q.noCycle(node, func() (map[string]constant.Value, bool) {
+			return q.scanBinary(node)
+		})
+
+	case *ast.UnaryExpr:
+		switch node.Op {
+		case token.ARROW:
+			return q.scanReceive(node.X, false)
+		case token.AND:
+		default:
+			return q.noCycle(node, func() (map[string]constant.Value, bool) {
+				return q.scanUnary(node)
+			})
+		}
+
+	case *ast.IndexExpr:
+		if tv, ok := q.info.Types[node.X]; ok && tv.IsValue() {
+			switch tv.Type.Underlying().(type) {
+			case *types.Slice, *types.Array:
+				return q.scanIndex(node.X, node.Index)
+			case *types.Map:
+				return q.scanMapIndex(node.X, node.Index)
+			}
+		}
+
+	case *ast.SelectorExpr:
+		sel, ok := q.info.Selections[node]
+		if !ok {
+			// A package-qualified identifier.
+			return q.scanIdent(node.Sel)
+		}
+		if sel.Kind() == types.FieldVal {
+			if field, ok := sel.Obj().(*types.Var); ok {
+				if assumed, ok := q.assume[field.Origin()]; ok {
+					return cloneVals(assumed), true
+				}
+			}
+			return q.nested(node, "field "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+				return q.scanField(node.X, sel.Index())
+			})
+		}
+
+	case *ast.CallExpr:
+		if tv, ok := q.info.Types[node.Fun]; ok && tv.IsType() {
+			return q.scanConversion(node, tv.Type)
+		}
+		switch {
+		case isBuiltin(node, "min", q.info):
+			return summarizeMinMax(token.LSS)(q, node, 0)
+		case isBuiltin(node, "max", q.info):
+			return summarizeMinMax(token.GTR)(q, node, 0)
+		}
+		if tv, ok := q.info.Types[node.Fun]; ok && !tv.IsValue() {
+			q.incomplete(node, "unsupported builtin call %s", types.ExprString(node))
+			return nil, false
+		}
+		return q.scanCallResult(node, 0)
+
+	case *ast.TypeAssertExpr:
+		return q.scanTypeAssert(node)
 	}
 
+	q.incomplete(node, "unsupported expression %s", types.ExprString(node))
 	return nil, false
 }
 
-// ScanCallResult performs a [Scan] on the idx'th result of the given call expression.
-func ScanCallResult(call *ast.CallExpr, idx int, files []*ast.File, info *types.Info) (map[string]constant.Value, bool) {
-	// xxx bounds checking
+func (q *query) scanCallResult(call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	return q.nested(call, "call to "+types.ExprString(call.Fun), func() (map[string]constant.Value, bool) {
+		if s, ok := summaryFor(call, q.info); ok {
+			return s(q, call, idx)
+		}
+		if vals, ok, found := q.fact(call, idx); found {
+			return vals, ok
+		}
 
-	var (
-		f      = ast.Unparen(call.Fun)
-		funObj types.Object
-	)
+		key := callResultKey{call: call, idx: idx}
+		if !q.enter(key) {
+			// A recursive call contributes nothing that the outer scan won't find.
+			return nil, true
+		}
+		defer q.leave(key)
+
+		f := ast.Unparen(call.Fun)
+
+		callees, complete := q.callees(f)
+		if len(callees) == 0 && !complete {
+			return nil, false
+		}
+
+		result := make(map[string]constant.Value)
+		for _, c := range callees {
+			vals, ok := q.scanFuncResult(call, f, c, idx)
+			union(result, vals)
+			complete = complete && ok
+		}
+
+		return result, complete
+	})
+}
+
+// callee is a function body that a call may invoke.
+type callee struct {
+	sig  *types.Signature
+	body *ast.BlockStmt
+}
+
+// calleeBody returns the body of fn,
+// a function called by the code being scanned,
+// or nil if it is not among the query's files
+// or calls are not followed (see [Scanner.Intraprocedural]).
+func (q *query) calleeBody(fn *types.Func) *ast.BlockStmt {
+	if q.intraprocedural {
+		return nil
+	}
+	q.consultFunc(fn)
+	return funcBody(fn, q.files)
+}
+
+// funcBody returns the body of fn,
+// or nil if it is not in files.
+func funcBody(fn *types.Func, files []*ast.File) *ast.BlockStmt {
+	scope := fn.Scope()
+	if scope == nil {
+		return nil
+	}
+	switch n := findSmallestEnclosingNode(files, scope).(type) {
+	case *ast.FuncDecl:
+		return n.Body
+	case *ast.FuncLit:
+		return n.Body
+	}
+	return nil
+}
+
+// funcsKey identifies the function values of a variable in query.active.
+type funcsKey struct {
+	v *types.Var
+}
+
+// callees determines the function bodies that the function-valued expression fun may denote.
+// It also reports whether those are all the possibilities.
+func (q *query) callees(fun ast.Expr) ([]callee, bool) {
+	fun = ast.Unparen(fun)
+
+	if lit, ok := fun.(*ast.FuncLit); ok {
+		sig, ok := q.info.TypeOf(lit).(*types.Signature)
+		if !ok {
+			return nil, false
+		}
+		return []callee{{sig: sig, body: lit.Body}}, true
+	}
+
+	var funObj types.Object
+
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		// Explicit instantiation of a generic function, f[T].
+		if _, ok := q.info.TypeOf(f.X).(*types.Signature); ok {
+			return q.callees(f.X)
+		}
+
+	case *ast.IndexListExpr:
+		return q.callees(f.X)
+
+	case *ast.CallExpr:
+		// A conversion to a named function type,
+		// as in http.HandlerFunc(serve).
+		if tv, ok := q.info.Types[f.Fun]; ok && tv.IsType() && len(f.Args) == 1 {
+			return q.callees(f.Args[0])
+		}
 
-	switch f := f.(type) {
 	case *ast.Ident:
-		funObj = info.ObjectOf(f)
+		funObj = q.info.ObjectOf(f)
 
 	case *ast.SelectorExpr:
-		if s, ok := info.Selections[f]; ok {
+		if s, ok := q.info.Selections[f]; ok {
 			funObj = s.Obj()
+		} else {
+			// A package-qualified identifier, like fmt.Println,
+			// is not in Selections.
+			funObj = q.info.ObjectOf(f.Sel)
 		}
 	}
 
-	if funObj == nil {
-		return nil, false
+	switch obj := funObj.(type) {
+	case *types.Func:
+		sig := obj.Signature()
+		if sig == nil {
+			q.incomplete(fun, "no signature for %s", obj.FullName())
+			return nil, false
+		}
+
+		if q.intraprocedural {
+			q.incomplete(fun, "call of %s is not followed in intraprocedural mode", obj.FullName())
+			return nil, false
+		}
+		q.consultFunc(obj)
+		body := funcBody(obj, q.files)
+		if body == nil {
+			q.incomplete(fun, "body of %s is not available", obj.FullName())
+			return nil, false
+		}
+		return []callee{{sig: sig, body: body}}, true
+
+	case *types.Var:
+		if sel, ok := fun.(*ast.SelectorExpr); ok && obj.IsField() {
+			return q.fieldCallees(sel, nil)
+		}
+		if _, ok := fun.(*ast.Ident); !ok {
+			q.incomplete(fun, "function value %s is not tracked", types.ExprString(fun))
+			return nil, false
+		}
+		return q.funcVarCallees(obj)
 	}
 
-	fun, ok := funObj.(*types.Func)
-	if !ok {
-		return nil, false
+	q.incomplete(fun, "cannot determine the function called by %s", types.ExprString(fun))
+	return nil, false
+}
+
+// funcVarCallees determines the function bodies that the variable v,
+// which has function type,
+// may hold.
+func (q *query) funcVarCallees(v *types.Var) ([]callee, bool) {
+	v = v.Origin()
+
+	key := funcsKey{v: v}
+	if !q.enter(key) {
+		return nil, true
 	}
+	defer q.leave(key)
 
-	sig := fun.Signature()
-	if sig == nil {
+	node := q.varScopeNode(v)
+	if node == nil {
 		return nil, false
 	}
 
-	scope := fun.Scope()
-	if scope == nil {
-		return nil, false
+	var (
+		result   []callee
+		complete = true
+	)
+
+	add := func(rhs ast.Expr) {
+		callees, ok := q.callees(rhs)
+		result = append(result, callees...)
+		complete = complete && ok
 	}
 
-	bodyNode := findSmallestEnclosingNode(files, scope)
-	switch n := bodyNode.(type) {
-	case *ast.FuncDecl:
-		bodyNode = n.Body
-	case *ast.FuncLit:
-		bodyNode = n.Body
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.paramArg(v); ok {
+			q.inFrames(depth, func() { add(arg) })
+		} else {
+			q.incomplete(node, "%s is a parameter and its argument is unknown", v.Name())
+			complete = false
+		}
 	}
-	if bodyNode == nil {
+
+	q.inspectReachable(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if !exprIsVar(lhs, v, q.info) {
+					continue
+				}
+				if len(n.Lhs) != len(n.Rhs) {
+					q.incomplete(n, "unsupported assignment to %s", v.Name())
+					complete = false
+					continue
+				}
+				add(n.Rhs[i])
+			}
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					// A nil function can't be called successfully.
+				case len(n.Names):
+					add(n.Values[i])
+				default:
+					q.incomplete(n, "unsupported declaration of %s", v.Name())
+					complete = false
+				}
+			}
+
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && exprIsVar(n.X, v, q.info) {
+				q.incomplete(n, "address of %s is taken", v.Name())
+				complete = false
+			}
+		}
+		return true
+	})
+
+	return result, complete
+}
+
+// scanFuncResult determines the possible values of the idx'th result of c,
+// when invoked by call.
+// The expression f is call's (unparenthesized) function.
+// A nil call means no call in particular,
+// so that c's parameters are not bound to arguments.
+func (q *query) scanFuncResult(call *ast.CallExpr, f ast.Expr, c callee, idx int) (map[string]constant.Value, bool) {
+	sigResults := c.sig.Results()
+	if sigResults == nil || idx < 0 || idx >= sigResults.Len() {
+		q.incomplete(c.body, "function has no result %d", idx)
 		return nil, false
 	}
+	nthResult := sigResults.At(idx)
 
-	body, ok := bodyNode.(*ast.BlockStmt)
-	if !ok {
-		return nil, false
+	if call != nil {
+		q.frames = append(q.frames, newFrame(call, f, c.sig, len(q.frames), q.info))
+		defer func() { q.frames = q.frames[:len(q.frames)-1] }()
+	}
+
+	if lit, assign := q.finalAssignment(c.body, nthResult); assign != nil {
+		// A deferred function has the last word.
+		return q.scanFinalAssignment(lit, assign, nthResult)
 	}
 
 	var (
@@ -108,69 +828,117 @@ func ScanCallResult(call *ast.CallExpr, idx int, files []*ast.File, info *types.
 		complete = true
 	)
 
-	sigResults := sig.Results()
-	if sigResults == nil {
-		return nil, false
+	// incDec handles an increment or decrement of a named result.
+	incDec := func(n *ast.IncDecStmt) {
+		if exprIsVar(n.X, nthResult, q.info) {
+			q.incomplete(n, "unsupported %s of %s", n.Tok, nthResult.Name())
+			complete = false
+		}
 	}
-	// xxx bounds checking
-	nthResult := sigResults.At(idx)
 
-	ast.Inspect(body, func(n ast.Node) bool {
+	q.forEachReturn(c.body, sigResults.Len(), idx, func(_ *ast.ReturnStmt, expr ast.Expr, call *ast.CallExpr) {
+		var (
+			vals map[string]constant.Value
+			ok   bool
+		)
+		switch {
+		case expr != nil:
+			vals, ok = q.scan(expr)
+		case call != nil:
+			vals, ok = q.scanCallResult(call, idx)
+		default:
+			// A bare return leaves the named result with a value found among the assignments below.
+			return
+		}
+		union(result, vals)
+		complete = complete && ok
+	})
+
+	// Assignments to a named result,
+	// including those in function literals (e.g. deferred closures).
+	q.inspectReachable(c.body, func(n ast.Node) bool {
 		if n == nil {
 			return false
 		}
 		switch n := n.(type) {
-		case *ast.ReturnStmt:
-			switch len(n.Results) {
-			case 0:
+		case *ast.FuncLit:
+			q.inspectReachable(n.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.AssignStmt:
+					vals, ok := q.scanAssignment(n, nthResult)
+					union(result, vals)
+					complete = complete && ok
+				case *ast.IncDecStmt:
+					incDec(n)
+				}
 				return true
+			})
+			return false
 
-			case 1:
-				retExpr := ast.Unparen(n.Results[0])
+		case *ast.AssignStmt:
+			vals, ok := q.scanAssignment(n, nthResult)
+			union(result, vals)
+			complete = complete && ok
 
-				// Assign retExpr can produce as many values as sig wants to return.
+		case *ast.IncDecStmt:
+			incDec(n)
+		}
+		return true
+	})
 
-				switch retExpr := retExpr.(type) {
-				case *ast.CallExpr:
-					vals, ok := ScanCallResult(retExpr, idx, files, info)
-					for _, v := range vals {
-						result[v.ExactString()] = v
-					}
-					complete = complete && ok
+	if q.mayRecover(c.body) {
+		// After recovering from a panic,
+		// the function returns the zero value,
+		// or for a named result,
+		// the value it had at the time
+		// (which is among the values assigned to it).
+		if zero, ok := zeroValue(nthResult.Type()); ok {
+			result[zero.ExactString()] = zero
+		} else {
+			q.incomplete(c.body, "function may recover from a panic and return the zero value of %s", nthResult.Type())
+			complete = false
+		}
+	}
 
-				default:
-					vals, ok := Scan(retExpr, files, info)
-					for _, v := range vals {
-						result[v.ExactString()] = v
-					}
-					complete = complete && ok
-				}
+	return result, complete
+}
 
+// forEachReturn calls f for each reachable return statement in body,
+// the body of a function with nresults results,
+// not counting those in function literals,
+// with how the statement gives result idx.
+// Either expr is the result's expression, as y in return x, y;
+// or call is a call whose result idx is returned, as in return g();
+// or both are nil for a bare return,
+// which leaves a named result with the value it has.
+func (q *query) forEachReturn(body *ast.BlockStmt, nresults, idx int, f func(ret *ast.ReturnStmt, expr ast.Expr, call *ast.CallExpr)) {
+	q.inspectReachable(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Return statements in a function literal are not returns from this function.
+			return false
+
+		case *ast.ReturnStmt:
+			switch len(n.Results) {
+			case 0:
+				f(n, nil, nil)
+			case nresults:
+				f(n, n.Results[idx], nil)
 			default:
-				// xxx bounds checking
-				vals, ok := Scan(n.Results[idx], files, info)
-				for _, v := range vals {
-					result[v.ExactString()] = v
+				// The only other form the type checker allows.
+				if call, ok := ast.Unparen(n.Results[0]).(*ast.CallExpr); ok {
+					f(n, nil, call)
 				}
-				complete = complete && ok
 			}
-
-		case *ast.AssignStmt:
-			vals, ok := scanAssignment(n, nthResult, files, info)
-			for _, v := range vals {
-				result[v.ExactString()] = v
-			}
-			complete = complete && ok
 		}
 		return true
 	})
-
-	return result, complete
 }
 
-func scanIdent(ident *ast.Ident, files []*ast.File, info *types.Info) (map[string]constant.Value, bool) {
-	obj := info.ObjectOf(ident)
+func (q *query) scanIdent(ident *ast.Ident) (map[string]constant.Value, bool) {
+	obj := q.info.ObjectOf(ident)
 	if obj == nil {
+		q.incomplete(ident, "no type information for %s", ident.Name)
 		return nil, false
 	}
 
@@ -180,58 +948,210 @@ func scanIdent(ident *ast.Ident, files []*ast.File, info *types.Info) (map[strin
 		return map[string]constant.Value{v.ExactString(): v}, true
 
 	case *types.Var:
-		return scanVar(ident, obj, files, info)
+		if n, ok := q.narrowed[obj.Origin()]; ok {
+			return cloneVals(n.vals), n.complete
+		}
+		return q.nested(ident, "variable "+ident.Name, func() (map[string]constant.Value, bool) {
+			return q.scanVar(ident, obj)
+		})
 	}
 
+	q.incomplete(ident, "unsupported reference to %s", obj)
 	return nil, false
 }
 
 // scanVar inspects the code in the scope of ident, which is a variable,
 // to determine the possible constant values it can have.
-func scanVar(ident *ast.Ident, v *types.Var, files []*ast.File, info *types.Info) (map[string]constant.Value, bool) {
+func (q *query) scanVar(ident *ast.Ident, v *types.Var) (map[string]constant.Value, bool) {
 	v = v.Origin()
 
-	scope := v.Parent()
+	assumed, isAssumed := q.assume[v]
+	if isAssumed && (v.Pkg() == nil || v.Parent() == v.Pkg().Scope()) {
+		// A package-level variable.
+		return cloneVals(assumed), true
+	}
+
+	if seed, ok := q.seed(v); ok {
+		// A later round of computing the values of v further up the stack.
+		return seed, true
+	}
+	if !q.enter(v) {
+		// Already computing the values of v further up the stack.
+		return nil, true
+	}
+	defer q.leave(v)
+
+	var at ast.Node
+	if ident != nil {
+		at = ident
+	}
+	return q.fixpoint(v, at, func() (map[string]constant.Value, bool) {
+		return q.scanVarOnce(ident, v)
+	})
+}
+
+// scanVarOnce does the work of [query.scanVar],
+// once v has been entered in q.active.
+func (q *query) scanVarOnce(ident *ast.Ident, v *types.Var) (map[string]constant.Value, bool) {
+	assumed, isAssumed := q.assume[v]
+
+	if v.Pkg() != nil && v.Parent() == v.Pkg().Scope() {
+		if vals, complete, ok := q.scanLazyGlobal(v); ok {
+			return vals, complete
+		}
+	}
 
-	node := findSmallestEnclosingNode(files, scope)
+	node := q.varScopeNode(v)
 	if node == nil {
 		return nil, false
 	}
 
+	if isAssumed && !isParam(node, v, q.info) {
+		return cloneVals(assumed), true
+	}
+	q.auditVar(v)
+
 	// Find all assignments to v within node.
 	var (
 		vals     = make(map[string]constant.Value)
 		complete = true
 	)
 
-	ast.Inspect(node, func(n ast.Node) bool {
+	if isParam(node, v, q.info) {
+		// The initial value comes from the caller.
+		if isAssumed {
+			union(vals, assumed)
+		} else if arg, depth, ok := q.paramArg(v); ok {
+			var (
+				argVals     map[string]constant.Value
+				argComplete bool
+			)
+			q.inFrames(depth, func() { argVals, argComplete = q.scan(arg) })
+			union(vals, argVals)
+			complete = argComplete
+		} else {
+			q.incomplete(ident, "%s is a parameter and its argument is unknown", v.Name())
+			complete = false
+		}
+	}
+
+	if ident != nil && q.inDeferred(ident, node) {
+		// A deferred function runs after the return statements assign the results.
+		if idx := resultIndex(node, v, q.info); idx >= 0 {
+			vv, ok := q.scanReturned(node, idx)
+			union(vals, vv)
+			complete = complete && ok
+		}
+	}
+
+	if cc, ok := node.(*ast.CaseClause); ok {
+		if ts, implicit := q.typeSwitchOf(cc); implicit == v {
+			// v is x in switch x := y.(type).
+			vv, ok := q.scanTypeSwitchVar(ts, cc)
+			union(vals, vv)
+			complete = complete && ok
+		}
+	}
+
+	// Calls of methods with pointer receivers on v,
+	// whose selectors need no further check.
+	methodCalls := make(map[*ast.SelectorExpr]bool)
+
+	q.inspectReachable(node, func(n ast.Node) bool {
 		if n == nil {
 			return false
 		}
 
 		switch n := n.(type) {
 		case *ast.AssignStmt:
-			vv, ok := scanAssignment(n, v, files, info)
-			for _, val := range vv {
-				vals[val.ExactString()] = val
+			if ident != nil && nodeContains(n, ident) && q.readBefore(n, ident, node) {
+				// As in s = wrap(s) in a deferred function.
+				return true
 			}
+			vv, ok := q.scanAssignment(n, v)
+			union(vals, vv)
 			complete = complete && ok
 
+		case *ast.IncDecStmt:
+			if exprIsVar(n.X, v, q.info) {
+				q.incomplete(n, "unsupported %s of %s", n.Tok, v.Name())
+				complete = false
+			}
+
+		case *ast.RangeStmt:
+			if n.Key != nil && exprIsVar(n.Key, v, q.info) {
+				vv, ok := q.scanRange(n, false)
+				union(vals, vv)
+				complete = complete && ok
+			}
+			if n.Value != nil && exprIsVar(n.Value, v, q.info) {
+				vv, ok := q.scanRange(n, true)
+				union(vals, vv)
+				complete = complete && ok
+			}
+
+		case *ast.CallExpr:
+			if sel, ok := ast.Unparen(n.Fun).(*ast.SelectorExpr); ok && q.takesAddr(sel, v) {
+				// A call like v.set(x), which passes &v to the method.
+				methodCalls[sel] = true
+				if _, ok := summaryFor(n, q.info); ok {
+					// Summarized methods are taken not to change their receivers.
+					return true
+				}
+				q.methodStores(n, nil, true, func(vv map[string]constant.Value, ok bool) {
+					union(vals, vv)
+					complete = complete && ok
+				})
+				return true
+			}
+
+			// Is this flag.StringVar(&v, name, default, usage) or similar?
+			if len(n.Args) == 0 {
+				return true
+			}
+			arg, ok := ast.Unparen(n.Args[0]).(*ast.UnaryExpr)
+			if !ok || arg.Op != token.AND || !exprIsVar(arg.X, v, q.info) {
+				return true
+			}
+			def, ok := flagDefault(n, true, q.info)
+			if !ok {
+				return true
+			}
+			defVals, _ := q.scan(def)
+			union(vals, defVals)
+			q.incomplete(n, "%s may be set on the command line by %s", v.Name(), types.ExprString(n.Fun))
+			complete = false
+			return false
+
+		case *ast.SelectorExpr:
+			if !methodCalls[n] && q.takesAddr(n, v) {
+				q.incomplete(n, "method value %s may change %s", types.ExprString(n), v.Name())
+				complete = false
+			}
+
 		case *ast.UnaryExpr:
 			if n.Op != token.AND {
 				return true
 			}
-			if !exprIsVar(n.X, v, info) {
+			if !exprIsVar(n.X, v, q.info) {
 				return true
 			}
-			complete = false
-			// TODO: try to analyze what is done with the address of v
+			// Follow the stores through the pointer,
+			// as in p := &v; *p = x.
+			add := func(vv map[string]constant.Value, ok bool) {
+				union(vals, vv)
+				complete = complete && ok
+			}
+			if !q.addrStores(n, pathTo(q.files, n), nil, add) {
+				q.incomplete(n, "address of %s is taken", v.Name())
+				complete = false
+			}
 
 		case *ast.ValueSpec:
 			// Is v on the left-hand side?
 			found := -1
 			for i, lhs := range n.Names {
-				if identIsVar(lhs, v, info) {
+				if identIsVar(lhs, v, q.info) {
 					found = i
 					break
 				}
@@ -243,51 +1163,33 @@ func scanVar(ident *ast.Ident, v *types.Var, files []*ast.File, info *types.Info
 			switch len(n.Values) {
 			case 0:
 				// Add the zero value for v to the map.
-				typ := v.Type().Underlying()
-				basic, ok := typ.(*types.Basic)
+				zero, ok := zeroValue(v.Type())
 				if !ok {
+					q.incomplete(n, "zero value of %s is not a constant", v.Name())
 					complete = false
 					return true
 				}
-				switch basic.Kind() {
-				case types.Bool:
-					v := constant.MakeBool(false)
-					vals[v.ExactString()] = v
-
-				case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
-					v := constant.MakeInt64(0)
-					vals[v.ExactString()] = v
-
-				case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
-					v := constant.MakeUint64(0)
-					vals[v.ExactString()] = v
-
-				case types.Float32, types.Float64:
-					v := constant.MakeFloat64(0)
-					vals[v.ExactString()] = v
-
-				case types.Complex64, types.Complex128:
-					v := constant.MakeImag(constant.MakeInt64(0))
-					vals[v.ExactString()] = v
-
-				case types.String:
-					v := constant.MakeString("")
-					vals[v.ExactString()] = v
-
-				default:
-					complete = false
-				}
+				vals[zero.ExactString()] = zero
 				return true
 
 			case len(n.Names):
-				rhsVals, ok := Scan(n.Values[found], files, info)
-				for _, val := range rhsVals {
-					vals[val.ExactString()] = val
+				rhsVals, ok := q.scan(n.Values[found])
+				union(vals, rhsVals)
+				complete = complete && ok
+
+			case 1:
+				if len(n.Names) != 2 || !isCommaOk(n.Values[0], q.info) {
+					q.incomplete(n, "unsupported declaration of %s", v.Name())
+					complete = false
+					return true
 				}
+				rhsVals, ok := q.scanCommaOk(n.Values[0], found)
+				union(vals, rhsVals)
 				complete = complete && ok
 
 			default:
 				// TODO: handle things like nil slices, pointers, etc.?
+				q.incomplete(n, "unsupported declaration of %s", v.Name())
 				complete = false
 				return true
 			}
@@ -299,11 +1201,131 @@ func scanVar(ident *ast.Ident, v *types.Var, files []*ast.File, info *types.Info
 	return vals, complete
 }
 
-func scanAssignment(stmt *ast.AssignStmt, v *types.Var, files []*ast.File, info *types.Info) (map[string]constant.Value, bool) {
+// takesAddr tells whether sel selects a method with a pointer receiver on v,
+// which is not itself a pointer,
+// so that the method gets &v.
+func (q *query) takesAddr(sel *ast.SelectorExpr, v *types.Var) bool {
+	if !exprIsVar(sel.X, v, q.info) {
+		return false
+	}
+	if _, isPtr := v.Type().Underlying().(*types.Pointer); isPtr {
+		return false
+	}
+	selection, ok := q.info.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal {
+		return false
+	}
+	_, ptrRecv := selection.Obj().Type().(*types.Signature).Recv().Type().Underlying().(*types.Pointer)
+	return ptrRecv
+}
+
+// scanPointee determines the possible values of *ptr.
+// Only pointers obtained from the flag package (and simple stores through them) are understood.
+// The result is never complete.
+func (q *query) scanPointee(ptr ast.Expr) (map[string]constant.Value, bool) {
+	if call, ok := ast.Unparen(ptr).(*ast.CallExpr); ok {
+		// E.g. *flag.String("mode", "fast", "...").
+		def, ok := flagDefault(call, false, q.info)
+		if !ok {
+			q.incomplete(ptr, "unsupported pointer expression %s", types.ExprString(ptr))
+			return nil, false
+		}
+		vals, _ := q.scan(def)
+		q.incomplete(call, "value of %s may be set on the command line", types.ExprString(call))
+		return vals, false
+	}
+
+	ident, ok := ast.Unparen(ptr).(*ast.Ident)
+	if !ok {
+		q.incomplete(ptr, "unsupported pointer expression %s", types.ExprString(ptr))
+		return nil, false
+	}
+	p, ok := q.info.ObjectOf(ident).(*types.Var)
+	if !ok {
+		q.incomplete(ptr, "unsupported pointer expression %s", types.ExprString(ptr))
+		return nil, false
+	}
+	p = p.Origin()
+
+	if !q.enter(p) {
+		return nil, false
+	}
+	defer q.leave(p)
+
+	var nodes []ast.Node
+	if p.Parent() == p.Pkg().Scope() {
+		for _, file := range q.files {
+			q.consult(file)
+			nodes = append(nodes, file)
+		}
+	} else if node := findSmallestEnclosingNode(q.files, p.Parent()); node != nil {
+		nodes = append(nodes, node)
+	}
+
+	vals := make(map[string]constant.Value)
+
+	addRHS := func(rhs ast.Expr) {
+		rhs = ast.Unparen(rhs)
+		switch rhs := rhs.(type) {
+		case *ast.CallExpr:
+			if def, ok := flagDefault(rhs, false, q.info); ok {
+				defVals, _ := q.scan(def)
+				union(vals, defVals)
+			}
+
+		case *ast.UnaryExpr:
+			if rhs.Op == token.AND {
+				xVals, _ := q.scan(rhs.X)
+				union(vals, xVals)
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		q.inspectReachable(node, func(n ast.Node) bool {
+			if n == nil {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					return true
+				}
+				for i, lhs := range n.Lhs {
+					if exprIsVar(lhs, p, q.info) {
+						addRHS(n.Rhs[i])
+						continue
+					}
+					star, ok := ast.Unparen(lhs).(*ast.StarExpr)
+					if ok && n.Tok == token.ASSIGN && exprIsVar(star.X, p, q.info) {
+						rhsVals, _ := q.scan(n.Rhs[i])
+						union(vals, rhsVals)
+					}
+				}
+
+			case *ast.ValueSpec:
+				if len(n.Names) != len(n.Values) {
+					return true
+				}
+				for i, name := range n.Names {
+					if identIsVar(name, p, q.info) {
+						addRHS(n.Values[i])
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	q.incomplete(ptr, "*%s may be set in ways that are not tracked, such as on the command line", ident.Name)
+	return vals, false
+}
+
+func (q *query) scanAssignment(stmt *ast.AssignStmt, v *types.Var) (map[string]constant.Value, bool) {
 	// Is v on the left-hand side?
 	idx := -1
 	for i, lhs := range stmt.Lhs {
-		if exprIsVar(lhs, v, info) {
+		if exprIsVar(lhs, v, q.info) {
 			idx = i
 			break
 		}
@@ -311,14 +1333,13 @@ func scanAssignment(stmt *ast.AssignStmt, v *types.Var, files []*ast.File, info
 	if idx < 0 {
 		return nil, true
 	}
+	q.examine(stmt)
 
 	var (
 		result   = make(map[string]constant.Value)
 		complete = true
 	)
 
-	// TODO: handle comma-ok forms
-
 	var (
 		rhsVals     map[string]constant.Value
 		rhsComplete bool
@@ -327,34 +1348,319 @@ func scanAssignment(stmt *ast.AssignStmt, v *types.Var, files []*ast.File, info
 	case token.ASSIGN, token.DEFINE:
 		switch len(stmt.Rhs) {
 		case len(stmt.Lhs):
-			rhsVals, rhsComplete = Scan(stmt.Rhs[idx], files, info)
+			rhsVals, rhsComplete = q.scan(stmt.Rhs[idx])
 
 		case 1:
 			rhs := ast.Unparen(stmt.Rhs[0])
-			call, ok := rhs.(*ast.CallExpr)
-			if !ok {
-				// TODO: also handle comma-ok forms.
+			if call, ok := rhs.(*ast.CallExpr); ok {
+				rhsVals, rhsComplete = q.scanCallResult(call, idx)
+			} else if len(stmt.Lhs) == 2 && isCommaOk(rhs, q.info) {
+				rhsVals, rhsComplete = q.scanCommaOk(rhs, idx)
+			} else {
+				q.incomplete(stmt, "unsupported assignment form")
 				return nil, false
 			}
-			rhsVals, rhsComplete = ScanCallResult(call, idx, files, info)
 
 		default:
+			q.incomplete(stmt, "unsupported assignment form")
 			return nil, false
 		}
 
-		for _, val := range rhsVals {
-			result[val.ExactString()] = val
-		}
+		union(result, rhsVals)
 		complete = complete && rhsComplete
 
 	default:
 		// TODO: handle other assignment operators.
+		q.incomplete(stmt, "unsupported assignment operator %s", stmt.Tok)
 		complete = false
 	}
 
 	return result, complete
 }
 
+// readBefore tells whether ident,
+// a use of a variable declared in node,
+// cannot read a value that assign,
+// which contains ident,
+// gives the variable.
+// That is so when the variable is read before assign assigns it
+// (i.e. not in a function literal that may run later)
+// and assign runs at most once in each lifetime of the variable:
+// it is not in a loop,
+// a function that may be called more than once,
+// or a function with goto statements.
+func (q *query) readBefore(assign *ast.AssignStmt, ident *ast.Ident, node ast.Node) bool {
+	for _, lhs := range assign.Lhs {
+		if ast.Unparen(lhs) == ident {
+			// Not a read at all.
+			return false
+		}
+	}
+
+	path := pathTo(q.files, ident)
+	i := slices.Index(path, ast.Node(assign))
+	j := slices.Index(path, node)
+	if i < 0 || j < 0 || j > i {
+		return false
+	}
+	for _, n := range path[i+1:] {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+	}
+	for k := i - 1; k > j; k-- {
+		switch n := path[k].(type) {
+		case *ast.ForStmt, *ast.RangeStmt, *ast.FuncDecl:
+			return false
+		case *ast.FuncLit:
+			// Only a function literal that is called where it appears,
+			// as in defer func() { ... }(),
+			// runs once.
+			call, ok := path[k-1].(*ast.CallExpr)
+			if !ok || ast.Unparen(call.Fun) != n {
+				return false
+			}
+		}
+	}
+
+	var hasGoto bool
+	ast.Inspect(node, func(n ast.Node) bool {
+		if b, ok := n.(*ast.BranchStmt); ok && b.Tok == token.GOTO {
+			hasGoto = true
+		}
+		return !hasGoto
+	})
+	return !hasGoto
+}
+
+// varScopeNode returns the syntax node for the scope in which v is declared,
+// recording a reason if there isn't one.
+// Package-level variables, in particular, are not tracked.
+func (q *query) varScopeNode(v *types.Var) ast.Node {
+	scope := v.Parent()
+	if scope != nil {
+		if v.Pkg() != nil && scope == v.Pkg().Scope() {
+			q.incomplete(nil, "%s is a package-level variable", v.Name())
+			return nil
+		}
+		if node := findSmallestEnclosingNode(q.files, scope); node != nil {
+			return node
+		}
+	}
+	q.incomplete(nil, "declaration of %s not found", v.Name())
+	return nil
+}
+
+// newFrame creates a frame for a call to a function with the given signature,
+// binding its parameters to the call's arguments where possible.
+// The parameters of variadic functions are not bound,
+// nor are those of calls like f(g()) where g returns multiple values.
+// The type parameters of a generic function are bound to its type arguments.
+func newFrame(call *ast.CallExpr, fun ast.Expr, sig *types.Signature, depth int, info *types.Info) *frame {
+	fr := &frame{
+		call:  call,
+		args:  make(map[*types.Var]ast.Expr),
+		depth: depth,
+		targs: typeArgs(fun, info),
+	}
+
+	if recv := sig.Recv(); recv != nil {
+		if sel, ok := ast.Unparen(fun).(*ast.SelectorExpr); ok {
+			if tv, ok := info.Types[sel.X]; ok && tv.IsValue() {
+				fr.recv, fr.recvX = recv.Origin(), sel.X
+			}
+		}
+
+		// Bind a value receiver to the expression it's selected from,
+		// unless that's a pointer that is implicitly dereferenced.
+		if sel, ok := fun.(*ast.SelectorExpr); ok {
+			if _, isPtr := recv.Type().Underlying().(*types.Pointer); !isPtr {
+				if tv, ok := info.Types[sel.X]; ok && tv.IsValue() {
+					if _, isPtr := tv.Type.Underlying().(*types.Pointer); !isPtr {
+						fr.args[recv.Origin()] = sel.X
+					}
+				}
+			}
+		}
+	}
+
+	params := sig.Params()
+	if sig.Variadic() || params.Len() != len(call.Args) {
+		return fr
+	}
+	for i := 0; i < params.Len(); i++ {
+		fr.args[params.At(i).Origin()] = call.Args[i]
+	}
+	return fr
+}
+
+// typeArgs maps the type parameters of the generic function denoted by fun,
+// as in f[T] or pkg.f
+// (with type arguments inferred),
+// to the type arguments of its instantiation.
+// It returns nil if fun does not denote an instantiated generic function.
+func typeArgs(fun ast.Expr, info *types.Info) map[*types.TypeParam]types.Type {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(fun).(type) {
+	case *ast.IndexExpr:
+		return typeArgs(fun.X, info)
+	case *ast.IndexListExpr:
+		return typeArgs(fun.X, info)
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+
+	inst, ok := info.Instances[ident]
+	if !ok {
+		return nil
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	if !ok {
+		return nil
+	}
+	tparams := fn.Origin().Signature().TypeParams()
+	if tparams.Len() != inst.TypeArgs.Len() {
+		return nil
+	}
+	result := make(map[*types.TypeParam]types.Type, tparams.Len())
+	for i := range tparams.Len() {
+		result[tparams.At(i)] = inst.TypeArgs.At(i)
+	}
+	return result
+}
+
+// typeArg returns the type argument bound to tp by the innermost frame
+// of a call of its generic function,
+// following type parameters passed on from one generic function to another.
+// It reports false if tp is not bound.
+func (q *query) typeArg(tp *types.TypeParam) (types.Type, bool) {
+	for i := len(q.frames) - 1; i >= 0; i-- {
+		t, ok := q.frames[i].targs[tp]
+		if !ok {
+			continue
+		}
+		if next, ok := t.(*types.TypeParam); ok {
+			tp = next
+			continue
+		}
+		return t, true
+	}
+	return nil, false
+}
+
+// flagDefault tells whether call defines a command-line flag using the flag package,
+// either through a package-level function like flag.String
+// or a method on *flag.FlagSet.
+// If it does, the expression for the flag's default value is returned.
+// If isVar is true, the ...Var forms (e.g. flag.StringVar) are recognized,
+// otherwise the pointer-returning forms (e.g. flag.String) are.
+func flagDefault(call *ast.CallExpr, isVar bool, info *types.Info) (ast.Expr, bool) {
+	fun := calleeFunc(call, info)
+	if fun == nil || fun.Pkg() == nil || fun.Pkg().Path() != "flag" {
+		return nil, false
+	}
+
+	var argIdx int
+	switch fun.Name() {
+	case "Bool", "Duration", "Float64", "Int", "Int64", "String", "Uint", "Uint64":
+		if isVar {
+			return nil, false
+		}
+		argIdx = 1
+
+	case "BoolVar", "DurationVar", "Float64Var", "IntVar", "Int64Var", "StringVar", "UintVar", "Uint64Var":
+		if !isVar {
+			return nil, false
+		}
+		argIdx = 2
+
+	default:
+		return nil, false
+	}
+
+	if argIdx >= len(call.Args) {
+		return nil, false
+	}
+	return call.Args[argIdx], true
+}
+
+// zeroValue returns the zero value of typ,
+// if typ's underlying type is a basic type.
+func zeroValue(typ types.Type) (constant.Value, bool) {
+	basic, ok := underlying(typ).(*types.Basic)
+	if !ok {
+		return nil, false
+	}
+	switch basic.Kind() {
+	case types.Bool:
+		return constant.MakeBool(false), true
+
+	case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+		return constant.MakeInt64(0), true
+
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+		return constant.MakeUint64(0), true
+
+	case types.Float32, types.Float64:
+		return constant.MakeFloat64(0), true
+
+	case types.Complex64, types.Complex128:
+		return constant.MakeImag(constant.MakeInt64(0)), true
+
+	case types.String:
+		return constant.MakeString(""), true
+	}
+	return nil, false
+}
+
+// isParam tells whether v is a parameter or receiver of fn,
+// which is a *ast.FuncDecl or *ast.FuncLit.
+func isParam(fn ast.Node, v *types.Var, info *types.Info) bool {
+	var (
+		recv *ast.FieldList
+		typ  *ast.FuncType
+	)
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		recv, typ = fn.Recv, fn.Type
+	case *ast.FuncLit:
+		typ = fn.Type
+	default:
+		return false
+	}
+
+	for _, fl := range []*ast.FieldList{recv, typ.Params} {
+		if fl == nil {
+			continue
+		}
+		for _, field := range fl.List {
+			for _, name := range field.Names {
+				if identIsVar(name, v, info) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// union adds the values in src to dst.
+func union(dst, src map[string]constant.Value) {
+	for _, v := range src {
+		dst[v.ExactString()] = v
+	}
+}
+
+// isBlank tells whether expr is the blank identifier _.
+func isBlank(expr ast.Expr) bool {
+	id, ok := ast.Unparen(expr).(*ast.Ident)
+	return ok && id.Name == "_"
+}
+
 func exprIsVar(expr ast.Expr, v *types.Var, info *types.Info) bool {
 	expr = ast.Unparen(expr)
 	id, ok := expr.(*ast.Ident)
@@ -2,16 +2,24 @@ package exprvals
 
 import (
 	"embed"
+	"fmt"
 	"go/ast"
 	"go/constant"
+	"go/format"
+	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"maps"
+	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type wantPair struct {
@@ -21,10 +29,14 @@ type wantPair struct {
 
 func TestScanVar(t *testing.T) {
 	wants := map[string]wantPair{
-		"address_taken": wantPair{
+		"address_escapes": wantPair{
 			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
 			complete: false,
 		},
+		"address_taken": wantPair{
+			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
+			complete: true,
+		},
 		"call_result": wantPair{
 			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
 			complete: true,
@@ -80,27 +92,7 @@ func TestScanVar(t *testing.T) {
 		name := entry.Name()
 		name = strings.TrimSuffix(name, ".go")
 		t.Run(name, func(t *testing.T) {
-			src, err := testdataFS.ReadFile(filepath.Join(testdata, entry.Name()))
-			if err != nil {
-				t.Fatal(err)
-			}
-			fset := token.NewFileSet()
-			file, err := parser.ParseFile(fset, entry.Name(), src, 0)
-			if err != nil {
-				t.Fatal(err)
-			}
-			info := &types.Info{
-				Defs:       make(map[*ast.Ident]types.Object),
-				Implicits:  make(map[ast.Node]types.Object),
-				Scopes:     make(map[ast.Node]*types.Scope),
-				Selections: make(map[*ast.SelectorExpr]*types.Selection),
-				Types:      make(map[ast.Expr]types.TypeAndValue),
-				Uses:       make(map[*ast.Ident]types.Object),
-			}
-			var conf types.Config
-			if _, err := conf.Check("test", fset, []*ast.File{file}, info); err != nil {
-				t.Fatal(err)
-			}
+			file, info := loadTestFile(t, filepath.Join(testdata, entry.Name()))
 
 			var ident *ast.Ident
 			ast.Inspect(file, func(n ast.Node) bool {
@@ -139,7 +131,7 @@ func TestScanVar(t *testing.T) {
 				t.Fatalf("object for identifier %s is a %T, want *types.Var", ident.Name, identObj)
 			}
 
-			gotVals, gotComplete := scanVar(ident, v, []*ast.File{file}, info)
+			gotVals, gotComplete := newQuery([]*ast.File{file}, info).scanVar(ident, v)
 
 			want := wants[name]
 			if !reflect.DeepEqual(gotVals, want.vals) {
@@ -148,6 +140,8 @@ func TestScanVar(t *testing.T) {
 			if gotComplete != want.complete {
 				t.Errorf("got complete = %v, want %v", gotComplete, want.complete)
 			}
+
+			checkDifferential(t, filepath.Join(testdata, entry.Name()), file, gotVals, gotComplete)
 		})
 	}
 }
@@ -174,27 +168,7 @@ func TestScanCallResult(t *testing.T) {
 		name := entry.Name()
 		name = strings.TrimSuffix(name, ".go")
 		t.Run(name, func(t *testing.T) {
-			src, err := testdataFS.ReadFile(filepath.Join(testdata, entry.Name()))
-			if err != nil {
-				t.Fatal(err)
-			}
-			fset := token.NewFileSet()
-			file, err := parser.ParseFile(fset, entry.Name(), src, parser.ParseComments)
-			if err != nil {
-				t.Fatal(err)
-			}
-			info := &types.Info{
-				Defs:       make(map[*ast.Ident]types.Object),
-				Implicits:  make(map[ast.Node]types.Object),
-				Scopes:     make(map[ast.Node]*types.Scope),
-				Selections: make(map[*ast.SelectorExpr]*types.Selection),
-				Types:      make(map[ast.Expr]types.TypeAndValue),
-				Uses:       make(map[*ast.Ident]types.Object),
-			}
-			var conf types.Config
-			if _, err := conf.Check("test", fset, []*ast.File{file}, info); err != nil {
-				t.Fatal(err)
-			}
+			file, info := loadTestFile(t, filepath.Join(testdata, entry.Name()))
 
 			// Find the last call expression in the file.
 			var call *ast.CallExpr
@@ -248,5 +222,3643 @@ func TestScanCallResult(t *testing.T) {
 	}
 }
 
+func TestScan(t *testing.T) {
+	wants := map[string]wantPair{
+		"alias_const": wantPair{
+			vals: map[string]constant.Value{
+				`0`:  constant.MakeInt64(0),
+				`3`:  constant.MakeInt64(3),
+				`4`:  constant.MakeInt64(4),
+				`5`:  constant.MakeInt64(5),
+				`8`:  constant.MakeInt64(8),
+				`12`: constant.MakeInt64(12),
+			},
+			complete: true,
+		},
+		"array_arg_store": wantPair{
+			vals: map[string]constant.Value{
+				`1`: constant.MakeInt64(1),
+			},
+			complete: true,
+		},
+		"array_copy_store": wantPair{
+			vals: map[string]constant.Value{
+				`1`: constant.MakeInt64(1),
+			},
+			complete: true,
+		},
+		"array_equal": wantPair{
+			vals:     map[string]constant.Value{`true`: constant.MakeBool(true)},
+			complete: true,
+		},
+		"array_store": wantPair{
+			vals: map[string]constant.Value{
+				`0`:  constant.MakeInt64(0),
+				`42`: constant.MakeInt64(42),
+			},
+			complete: true,
+		},
+		"array_zero": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`1`: constant.MakeInt64(1),
+				`2`: constant.MakeInt64(2),
+			},
+			complete: true,
+		},
+		"binary_op": wantPair{
+			vals: map[string]constant.Value{
+				`21`: constant.MakeInt64(21),
+				`31`: constant.MakeInt64(31),
+			},
+			complete: true,
+		},
+		"blank_call_first": wantPair{
+			vals: map[string]constant.Value{
+				`"b"`:    constant.MakeString("b"),
+				`"none"`: constant.MakeString("none"),
+			},
+			complete: true,
+		},
+		"blank_call_middle": wantPair{
+			vals: map[string]constant.Value{
+				`2`: constant.MakeInt64(2),
+			},
+			complete: true,
+		},
+		"blank_commaok_first": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+			},
+			complete: true,
+		},
+		"blank_commaok_second": wantPair{
+			vals: map[string]constant.Value{
+				`"none"`: constant.MakeString("none"),
+				`"v"`:    constant.MakeString("v"),
+			},
+			complete: true,
+		},
+		"blank_parallel": wantPair{
+			vals: map[string]constant.Value{
+				`""`:  constant.MakeString(""),
+				`"b"`: constant.MakeString("b"),
+			},
+			complete: true,
+		},
+		"block_decls": wantPair{
+			vals: map[string]constant.Value{
+				`""`:   constant.MakeString(""),
+				`"x"`:  constant.MakeString("x"),
+				`"xa"`: constant.MakeString("xa"),
+				`"xb"`: constant.MakeString("xb"),
+				`"z"`:  constant.MakeString("z"),
+			},
+			complete: true,
+		},
+		"builder_chain": wantPair{
+			vals: map[string]constant.Value{
+				`"default"`: constant.MakeString("default"),
+				`"fast"`:    constant.MakeString("fast"),
+			},
+			complete: true,
+		},
+		"builder_var": wantPair{
+			vals: map[string]constant.Value{
+				`""`:     constant.MakeString(""),
+				`"fast"`: constant.MakeString("fast"),
+			},
+			complete: true,
+		},
+		"callback_field": wantPair{
+			vals: map[string]constant.Value{
+				`"admin"`: constant.MakeString("admin"),
+				`"index"`: constant.MakeString("index"),
+			},
+			complete: true,
+		},
+		"callback_field_escape": wantPair{
+			vals: map[string]constant.Value{
+				`"index"`: constant.MakeString("index"),
+			},
+			complete: false,
+		},
+		"callback_field_method_store": wantPair{
+			vals: map[string]constant.Value{
+				`"index"`: constant.MakeString("index"),
+			},
+			complete: false,
+		},
+		"callback_field_pointer": wantPair{
+			vals: map[string]constant.Value{
+				`"served"`: constant.MakeString("served"),
+			},
+			complete: true,
+		},
+		"callback_field_receiver": wantPair{
+			vals: map[string]constant.Value{
+				`"index"`: constant.MakeString("index"),
+			},
+			complete: true,
+		},
+		"callback_method_on_func": wantPair{
+			vals: map[string]constant.Value{
+				`"index"`: constant.MakeString("index"),
+			},
+			complete: true,
+		},
+		"callback_named_type": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+			},
+			complete: true,
+		},
+		"chan_buffered": wantPair{
+			vals: map[string]constant.Value{
+				`3`: constant.MakeInt64(3),
+			},
+			complete: true,
+		},
+		"chan_closed": wantPair{
+			vals: map[string]constant.Value{
+				`""`:      constant.MakeString(""),
+				`"ok"`:    constant.MakeString("ok"),
+				`"retry"`: constant.MakeString("retry"),
+			},
+			complete: true,
+		},
+		"chan_worker": wantPair{
+			vals: map[string]constant.Value{
+				`"even"`: constant.MakeString("even"),
+				`"none"`: constant.MakeString("none"),
+				`"odd"`:  constant.MakeString("odd"),
+			},
+			complete: true,
+		},
+		"closure_call": wantPair{
+			vals: map[string]constant.Value{
+				`"hello"`: constant.MakeString("hello"),
+				`"HELLO"`: constant.MakeString("HELLO"),
+			},
+			complete: true,
+		},
+		"closure_immediate": wantPair{
+			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
+			complete: true,
+		},
+		"closure_var": wantPair{
+			vals: map[string]constant.Value{
+				`"hello"`:   constant.MakeString("hello"),
+				`"goodbye"`: constant.MakeString("goodbye"),
+			},
+			complete: true,
+		},
+		"cmp_compare": wantPair{
+			vals: map[string]constant.Value{
+				`-1`: constant.MakeInt64(-1),
+				`0`:  constant.MakeInt64(0),
+			},
+			complete: true,
+		},
+		"cmp_or": wantPair{
+			vals:     map[string]constant.Value{`"default"`: constant.MakeString("default")},
+			complete: false,
+		},
+		"cmp_or_known": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"cmp_or_spread": wantPair{
+			vals:     map[string]constant.Value{},
+			complete: false,
+		},
+		"comma_ok_assert": wantPair{
+			vals:     map[string]constant.Value{`true`: constant.MakeBool(true)},
+			complete: true,
+		},
+		"comma_ok_map": wantPair{
+			vals:     map[string]constant.Value{`false`: constant.MakeBool(false)},
+			complete: true,
+		},
+		"comma_ok_map_any_key": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"comma_ok_map_deleted": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"comma_ok_map_escape": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"comma_ok_map_present": wantPair{
+			vals: map[string]constant.Value{
+				`true`: constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"comma_ok_map_value": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`2`: constant.MakeInt64(2),
+			},
+			complete: true,
+		},
+		"comma_ok_map_value_present": wantPair{
+			vals: map[string]constant.Value{
+				`1`: constant.MakeInt64(1),
+				`3`: constant.MakeInt64(3),
+			},
+			complete: true,
+		},
+		"comma_ok_nil": wantPair{
+			vals:     map[string]constant.Value{`0`: constant.MakeInt64(0)},
+			complete: true,
+		},
+		"comma_ok_recv_closed": wantPair{
+			vals: map[string]constant.Value{
+				`""`:  constant.MakeString(""),
+				`"a"`: constant.MakeString("a"),
+			},
+			complete: true,
+		},
+		"comma_ok_recv_closed_ok": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"comma_ok_recv_open": wantPair{
+			vals: map[string]constant.Value{
+				`true`: constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"comma_ok_recv_param": wantPair{
+			vals: map[string]constant.Value{
+				`""`: constant.MakeString(""),
+			},
+			complete: false,
+		},
+		"comma_ok_recv_passed": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"comma_ok_recv_value": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+			},
+			complete: true,
+		},
+		"const_shadow": wantPair{
+			vals: map[string]constant.Value{
+				`"dev"`:  constant.MakeString("dev"),
+				`"test"`: constant.MakeString("test"),
+			},
+			complete: true,
+		},
+		"const_shadow_block": wantPair{
+			vals: map[string]constant.Value{
+				`3`: constant.MakeInt64(3),
+			},
+			complete: true,
+		},
+		"const_shadow_param": wantPair{
+			vals: map[string]constant.Value{
+				`"dev"`: constant.MakeString("dev"),
+			},
+			complete: true,
+		},
+		"conversion": wantPair{
+			vals: map[string]constant.Value{
+				`44`: constant.MakeInt64(44),
+				`5`:  constant.MakeInt64(5),
+			},
+			complete: true,
+		},
+		"cycle_add": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+			},
+			complete: false,
+		},
+		"cycle_elem": wantPair{
+			vals: map[string]constant.Value{
+				`1`: constant.MakeInt64(1),
+			},
+			complete: false,
+		},
+		"cycle_field": wantPair{
+			vals: map[string]constant.Value{
+				`1`: constant.MakeInt64(1),
+			},
+			complete: false,
+		},
+		"cycle_loop": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+			},
+			complete: false,
+		},
+		"cycle_parse": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+			},
+			complete: false,
+		},
+		"cycle_recursive_call": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`1`: constant.MakeInt64(1),
+				`2`: constant.MakeInt64(2),
+			},
+			complete: false,
+		},
+		"cycle_recursive_param": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`:  constant.MakeString("a"),
+				`"ax"`: constant.MakeString("ax"),
+			},
+			complete: false,
+		},
+		"cycle_swap_loop": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+			},
+			complete: false,
+		},
+		"defer_loop": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`:    constant.MakeString("a"),
+				`"b"`:    constant.MakeString("b"),
+				`"none"`: constant.MakeString("none"),
+			},
+			complete: true,
+		},
+		"defer_override": wantPair{
+			vals: map[string]constant.Value{
+				`"done"`:           constant.MakeString("done"),
+				`"done (verbose)"`: constant.MakeString("done (verbose)"),
+			},
+			complete: true,
+		},
+		"defer_wrap": wantPair{
+			vals: map[string]constant.Value{
+				`"[a]"`: constant.MakeString("[a]"),
+				`"[b]"`: constant.MakeString("[b]"),
+			},
+			complete: true,
+		},
+		"dot_import": wantPair{
+			vals:     map[string]constant.Value{`"fast"`: constant.MakeString("fast")},
+			complete: false,
+		},
+		"field_escape": wantPair{
+			vals:     map[string]constant.Value{`"fast"`: constant.MakeString("fast")},
+			complete: false,
+		},
+		"field_store": wantPair{
+			vals: map[string]constant.Value{
+				`""`:  constant.MakeString(""),
+				`"x"`: constant.MakeString("x"),
+				`"y"`: constant.MakeString("y"),
+			},
+			complete: true,
+		},
+		"field_store_alias": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+				`"b"`: constant.MakeString("b"),
+				`"c"`: constant.MakeString("c"),
+			},
+			complete: true,
+		},
+		"field_store_nested": wantPair{
+			vals: map[string]constant.Value{
+				`""`:     constant.MakeString(""),
+				`"deep"`: constant.MakeString("deep"),
+			},
+			complete: true,
+		},
+		"field_store_whole": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+				`"b"`: constant.MakeString("b"),
+			},
+			complete: true,
+		},
+		"flag_set": wantPair{
+			vals:     map[string]constant.Value{`"fast"`: constant.MakeString("fast")},
+			complete: false,
+		},
+		"flag_string": wantPair{
+			vals:     map[string]constant.Value{`"fast"`: constant.MakeString("fast")},
+			complete: false,
+		},
+		"flag_string_var": wantPair{
+			vals: map[string]constant.Value{
+				`""`:     constant.MakeString(""),
+				`"fast"`: constant.MakeString("fast"),
+			},
+			complete: false,
+		},
+		"filepath_join": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"generic_conversion": wantPair{
+			vals: map[string]constant.Value{
+				`44`: constant.MakeInt64(44),
+				`5`:  constant.MakeInt64(5),
+			},
+			complete: true,
+		},
+		"generic_conversion_chain": wantPair{
+			vals: map[string]constant.Value{
+				`"A"`: constant.MakeString("A"),
+			},
+			complete: true,
+		},
+		"generic_conversion_instance": wantPair{
+			vals: map[string]constant.Value{
+				`255`: constant.MakeInt64(255),
+			},
+			complete: true,
+		},
+		"getenv_fallback": wantPair{
+			vals:     map[string]constant.Value{`"default"`: constant.MakeString("default")},
+			complete: false,
+		},
+		"getenv_helper": wantPair{
+			vals:     map[string]constant.Value{`"default"`: constant.MakeString("default")},
+			complete: false,
+		},
+		"int_to_string": wantPair{
+			vals: map[string]constant.Value{
+				`"A"`:        constant.MakeString("A"),
+				`"a"`:        constant.MakeString("a"),
+				"\"\uFFFD\"": constant.MakeString("\uFFFD"),
+			},
+			complete: true,
+		},
+		"lazy_global": wantPair{
+			vals: map[string]constant.Value{
+				`""`:     constant.MakeString(""),
+				`"dev"`:  constant.MakeString("dev"),
+				`"prod"`: constant.MakeString("prod"),
+			},
+			complete: true,
+		},
+		"lazy_global_reset": wantPair{
+			vals:     map[string]constant.Value{},
+			complete: false,
+		},
+		"loop_fixpoint_flags": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`1`: constant.MakeInt64(1),
+				`2`: constant.MakeInt64(2),
+				`3`: constant.MakeInt64(3),
+			},
+			complete: true,
+		},
+		"loop_fixpoint_mod": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`1`: constant.MakeInt64(1),
+				`2`: constant.MakeInt64(2),
+			},
+			complete: true,
+		},
+		"loop_fixpoint_toggle": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"loop_labeled_break": wantPair{
+			vals: map[string]constant.Value{
+				`"done"`:  constant.MakeString("done"),
+				`"serve"`: constant.MakeString("serve"),
+			},
+			complete: true,
+		},
+		"loop_switch_break": wantPair{
+			vals: map[string]constant.Value{
+				`"serve"`: constant.MakeString("serve"),
+			},
+			complete: true,
+		},
+		"make_store": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`7`: constant.MakeInt64(7),
+			},
+			complete: true,
+		},
+		"map_any_key_int64": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`5`: constant.MakeInt64(5),
+			},
+			complete: true,
+		},
+		"map_any_key_named": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`5`: constant.MakeInt64(5),
+			},
+			complete: true,
+		},
+		"map_delete": wantPair{
+			vals: map[string]constant.Value{
+				`"xy"`: constant.MakeString("xy"),
+				`"y"`:  constant.MakeString("y"),
+			},
+			complete: true,
+		},
+		"map_escape": wantPair{
+			vals: map[string]constant.Value{
+				`"x"`: constant.MakeString("x"),
+			},
+			complete: false,
+		},
+		"map_return_closure": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+			},
+			complete: false,
+		},
+		"map_store": wantPair{
+			vals: map[string]constant.Value{
+				`"x"`: constant.MakeString("x"),
+				`"z"`: constant.MakeString("z"),
+			},
+			complete: true,
+		},
+		"map_store_other_key": wantPair{
+			vals: map[string]constant.Value{
+				`"x"`: constant.MakeString("x"),
+			},
+			complete: true,
+		},
+		"map_store_range": wantPair{
+			vals: map[string]constant.Value{
+				`""`:  constant.MakeString(""),
+				`"y"`: constant.MakeString("y"),
+			},
+			complete: true,
+		},
+		"map_store_unknown_key": wantPair{
+			vals: map[string]constant.Value{
+				`"x"`: constant.MakeString("x"),
+				`"y"`: constant.MakeString("y"),
+			},
+			complete: true,
+		},
+		"math_min": wantPair{
+			vals: map[string]constant.Value{
+				`3/2`: constant.MakeFloat64(1.5),
+				`5/2`: constant.MakeFloat64(2.5),
+			},
+			complete: true,
+		},
+		"method_call": wantPair{
+			vals: map[string]constant.Value{
+				`""`:        constant.MakeString(""),
+				`"default"`: constant.MakeString("default"),
+			},
+			complete: true,
+		},
+		"min_max": wantPair{
+			vals: map[string]constant.Value{
+				`1`:   constant.MakeInt64(1),
+				`3`:   constant.MakeInt64(3),
+				`100`: constant.MakeInt64(100),
+			},
+			complete: true,
+		},
+		"never_returns": wantPair{
+			vals:     map[string]constant.Value{`"fast"`: constant.MakeString("fast")},
+			complete: true,
+		},
+		"new_struct": wantPair{
+			vals: map[string]constant.Value{
+				`""`:      constant.MakeString(""),
+				`"debug"`: constant.MakeString("debug"),
+			},
+			complete: true,
+		},
+		"os_args": wantPair{
+			vals:     map[string]constant.Value{},
+			complete: false,
+		},
+		"per_index": wantPair{
+			vals:     map[string]constant.Value{`"fast"`: constant.MakeString("fast")},
+			complete: true,
+		},
+		"per_index_append": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: false,
+		},
+		"per_index_keys": wantPair{
+			vals: map[string]constant.Value{
+				`""`:     constant.MakeString(""),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"param": wantPair{
+			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
+			complete: false,
+		},
+		"param_binding": wantPair{
+			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
+			complete: true,
+		},
+		"pointer_store": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+				`"b"`: constant.MakeString("b"),
+			},
+			complete: true,
+		},
+		"pointer_store_alias": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+			},
+			complete: false,
+		},
+		"pointer_store_arg": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+				`"b"`: constant.MakeString("b"),
+			},
+			complete: true,
+		},
+		"pointer_store_field": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`:  constant.MakeString("fast"),
+				`"reset"`: constant.MakeString("reset"),
+				`"slow"`:  constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"pointer_store_returned": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+			},
+			complete: false,
+		},
+		"ptr_alias": wantPair{
+			vals: map[string]constant.Value{
+				`""`:      constant.MakeString(""),
+				`"debug"`: constant.MakeString("debug"),
+			},
+			complete: true,
+		},
+		"ptr_composite": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"range_index": wantPair{
+			vals: map[string]constant.Value{
+				`0`:    constant.MakeInt64(0),
+				`8080`: constant.MakeInt64(8080),
+				`8081`: constant.MakeInt64(8081),
+				`8082`: constant.MakeInt64(8082),
+			},
+			complete: true,
+		},
+		"range_int": wantPair{
+			vals: map[string]constant.Value{
+				`-1`: constant.MakeInt64(-1),
+				`0`:  constant.MakeInt64(0),
+				`1`:  constant.MakeInt64(1),
+				`2`:  constant.MakeInt64(2),
+				`3`:  constant.MakeInt64(3),
+				`4`:  constant.MakeInt64(4),
+			},
+			complete: true,
+		},
+		"range_int_unknown": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+			},
+			complete: false,
+		},
+		"range_int_var": wantPair{
+			vals: map[string]constant.Value{
+				`0`:  constant.MakeInt64(0),
+				`10`: constant.MakeInt64(10),
+				`20`: constant.MakeInt64(20),
+			},
+			complete: true,
+		},
+		"range_iter": wantPair{
+			vals: map[string]constant.Value{
+				`""`:          constant.MakeString(""),
+				`"log.debug"`: constant.MakeString("log.debug"),
+				`"log.info"`:  constant.MakeString("log.info"),
+			},
+			complete: true,
+		},
+		"range_iter_escape": wantPair{
+			vals: map[string]constant.Value{
+				`""`:  constant.MakeString(""),
+				`"a"`: constant.MakeString("a"),
+			},
+			complete: false,
+		},
+		"range_iter_seq2": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`1`: constant.MakeInt64(1),
+				`2`: constant.MakeInt64(2),
+			},
+			complete: true,
+		},
+		"range_iter_std": wantPair{
+			vals: map[string]constant.Value{
+				`""`:          constant.MakeString(""),
+				`"eu-west-1"`: constant.MakeString("eu-west-1"),
+				`"us-east-1"`: constant.MakeString("us-east-1"),
+			},
+			complete: true,
+		},
+		"range_literal": wantPair{
+			vals: map[string]constant.Value{
+				`"a!"`:   constant.MakeString("a!"),
+				`"b!"`:   constant.MakeString("b!"),
+				`"none"`: constant.MakeString("none"),
+			},
+			complete: true,
+		},
+		"range_map": wantPair{
+			vals: map[string]constant.Value{
+				`""`:            constant.MakeString(""),
+				`"primary@db1"`: constant.MakeString("primary@db1"),
+				`"primary@db2"`: constant.MakeString("primary@db2"),
+				`"replica@db1"`: constant.MakeString("replica@db1"),
+				`"replica@db2"`: constant.MakeString("replica@db2"),
+			},
+			complete: true,
+		},
+		"range_map_unknown": wantPair{
+			vals: map[string]constant.Value{
+				`""`:    constant.MakeString(""),
+				`"db1"`: constant.MakeString("db1"),
+			},
+			complete: false,
+		},
+		"recover_callee": wantPair{
+			vals: map[string]constant.Value{
+				`""`:         constant.MakeString(""),
+				`"other"`:    constant.MakeString("other"),
+				`"positive"`: constant.MakeString("positive"),
+			},
+			complete: true,
+		},
+		"recover_fallback": wantPair{
+			vals: map[string]constant.Value{
+				`""`:         constant.MakeString(""),
+				`"fallback"`: constant.MakeString("fallback"),
+				`"loaded"`:   constant.MakeString("loaded"),
+			},
+			complete: true,
+		},
+		"recursion": wantPair{
+			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
+			complete: true,
+		},
+		"recover_panic": wantPair{
+			vals: map[string]constant.Value{
+				`""`:       constant.MakeString(""),
+				`"loaded"`: constant.MakeString("loaded"),
+			},
+			complete: true,
+		},
+		"recv_basic_pointer": wantPair{
+			vals: map[string]constant.Value{
+				`"high"`: constant.MakeString("high"),
+				`"mid"`:  constant.MakeString("mid"),
+			},
+			complete: true,
+		},
+		"recv_basic_value": wantPair{
+			vals: map[string]constant.Value{
+				`"mid"`:  constant.MakeString("mid"),
+				`"none"`: constant.MakeString("none"),
+			},
+			complete: true,
+		},
+		"recv_pointer_write": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`:  constant.MakeString("fast"),
+				`"reset"`: constant.MakeString("reset"),
+			},
+			complete: true,
+		},
+		"recv_value_write": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+			},
+			complete: true,
+		},
+		"renamed_import": wantPair{
+			vals:     map[string]constant.Value{`"fast"`: constant.MakeString("fast")},
+			complete: true,
+		},
+		"select_recv": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`:    constant.MakeString("a"),
+				`"a?"`:   constant.MakeString("a?"),
+				`"b"`:    constant.MakeString("b"),
+				`"b?"`:   constant.MakeString("b?"),
+				`"idle"`: constant.MakeString("idle"),
+				`"none"`: constant.MakeString("none"),
+			},
+			complete: true,
+		},
+		"select_timeout": wantPair{
+			vals: map[string]constant.Value{
+				`"busy"`:   constant.MakeString("busy"),
+				`"idle"`:   constant.MakeString("idle"),
+				`"ticked"`: constant.MakeString("ticked"),
+				`"waited"`: constant.MakeString("waited"),
+			},
+			complete: true,
+		},
+		"self_assignment": wantPair{
+			vals:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
+			complete: true,
+		},
+		"shadowed_import": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"slice_alias_store": wantPair{
+			vals: map[string]constant.Value{
+				`1`: constant.MakeInt64(1),
+			},
+			complete: false,
+		},
+		"slice_append": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`:   constant.MakeString("fast"),
+				`"medium"`: constant.MakeString("medium"),
+				`"slow"`:   constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"slice_escape": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: false,
+		},
+		"slice_literal": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"slice_param": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"slice_store": wantPair{
+			vals: map[string]constant.Value{
+				`"fast"`:   constant.MakeString("fast"),
+				`"medium"`: constant.MakeString("medium"),
+				`"slow"`:   constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		"struct_equal": wantPair{
+			vals: map[string]constant.Value{
+				`false`: constant.MakeBool(false),
+				`true`:  constant.MakeBool(true),
+			},
+			complete: true,
+		},
+		"struct_unequal": wantPair{
+			vals:     map[string]constant.Value{`false`: constant.MakeBool(false)},
+			complete: true,
+		},
+		"swap": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+				`"b"`: constant.MakeString("b"),
+			},
+			complete: true,
+		},
+		"type_assert": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`: constant.MakeString("a"),
+				`"b"`: constant.MakeString("b"),
+			},
+			complete: true,
+		},
+		"type_assert_comma_ok": wantPair{
+			vals: map[string]constant.Value{
+				`0`: constant.MakeInt64(0),
+				`2`: constant.MakeInt64(2),
+			},
+			complete: true,
+		},
+		"type_assert_interface": wantPair{
+			vals: map[string]constant.Value{
+				`1`: constant.MakeInt64(1),
+			},
+			complete: true,
+		},
+		"type_assert_unknown": wantPair{
+			vals: map[string]constant.Value{
+				`""`: constant.MakeString(""),
+			},
+			complete: false,
+		},
+		"type_switch": wantPair{
+			vals: map[string]constant.Value{
+				`"a"`:     constant.MakeString("a"),
+				`"b!"`:    constant.MakeString("b!"),
+				`"none"`:  constant.MakeString("none"),
+				`"other"`: constant.MakeString("other"),
+			},
+			complete: true,
+		},
+		"unique_handle": wantPair{
+			vals: map[string]constant.Value{
+				`"eu-west-1"`: constant.MakeString("eu-west-1"),
+				`"us-east-1"`: constant.MakeString("us-east-1"),
+			},
+			complete: true,
+		},
+		"url_builder": wantPair{
+			vals: map[string]constant.Value{
+				`""`:     constant.MakeString(""),
+				`"9000"`: constant.MakeString("9000"),
+			},
+			complete: true,
+		},
+		"url_host": wantPair{
+			vals: map[string]constant.Value{
+				`"api.internal"`:         constant.MakeString("api.internal"),
+				`"api.staging.internal"`: constant.MakeString("api.staging.internal"),
+			},
+			complete: true,
+		},
+		"wire_gen": wantPair{
+			vals: map[string]constant.Value{
+				`"file::memory:"`:          constant.MakeString("file::memory:"),
+				`"postgres://db/app"`:      constant.MakeString("postgres://db/app"),
+				`"postgres://replica/app"`: constant.MakeString("postgres://replica/app"),
+			},
+			complete: true,
+		},
+		"worker_chan": wantPair{
+			vals: map[string]constant.Value{
+				`"missing"`: constant.MakeString("missing"),
+				`"none"`:    constant.MakeString("none"),
+				`"ok"`:      constant.MakeString("ok"),
+			},
+			complete: true,
+		},
+		"worker_group": wantPair{
+			vals: map[string]constant.Value{
+				`"down"`: constant.MakeString("down"),
+				`"up"`:   constant.MakeString("up"),
+			},
+			complete: true,
+		},
+		"worker_slice": wantPair{
+			vals: map[string]constant.Value{
+				`"missing"`: constant.MakeString("missing"),
+				`"ok"`:      constant.MakeString("ok"),
+			},
+			complete: true,
+		},
+	}
+
+	const testdata = "testdata/scan"
+
+	entries, err := testdataFS.ReadDir(testdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		name := entry.Name()
+		name = strings.TrimSuffix(name, ".go")
+		t.Run(name, func(t *testing.T) {
+			file, info := loadTestFile(t, filepath.Join(testdata, entry.Name()))
+
+			expr := findResult(t, file)
+
+			gotVals, gotComplete := Scan(expr, []*ast.File{file}, info)
+			want := wants[name]
+			if !reflect.DeepEqual(gotVals, want.vals) {
+				t.Errorf("got %v, want %v", gotVals, want.vals)
+			}
+			if gotComplete != want.complete {
+				t.Errorf("got complete = %v, want %v", gotComplete, want.complete)
+			}
+			if !gotComplete {
+				s := &Scanner{Files: []*ast.File{file}, Info: info}
+				if res := s.Scan(expr); res.Why == nil || len(res.Why.Leaves()) == 0 {
+					t.Error("no reason for incomplete result")
+				}
+			}
+
+			checkDifferential(t, filepath.Join(testdata, entry.Name()), file, gotVals, gotComplete)
+		})
+	}
+}
+
+func TestScannerWhy(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/scan/getenv_helper.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	res := s.Scan(findResult(t, file))
+	if res.Complete {
+		t.Fatal("got complete result, want incomplete")
+	}
+	if res.Why == nil {
+		t.Fatal("no reason for incomplete result")
+	}
+
+	const want = `scanning getenv("MODE", "default")
+  call to getenv
+    variable v
+      call to os.LookupEnv
+        body of os.LookupEnv is not available
+`
+	if got := res.Why.Format(nil); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	leaves := res.Why.Leaves()
+	if len(leaves) != 1 {
+		t.Fatalf("got %d leaves, want 1", len(leaves))
+	}
+	if !leaves[0].Pos.IsValid() {
+		t.Error("leaf reason has no position")
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/consistency/assume.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	call, ok := findResult(t, file).(*ast.CallExpr)
+	if !ok {
+		t.Fatal("result of f is not a call")
+	}
+
+	if inc := (&Scanner{Files: s.Files, Info: info}).CheckConsistency(call, 0); inc != nil {
+		t.Fatalf("got inconsistency %q, want none", inc.Msg)
+	}
+
+	var m *types.Var
+	for ident, obj := range info.Defs {
+		if ident.Name == "m" {
+			m = obj.(*types.Var)
+		}
+	}
+
+	// Changing Assume without calling Invalidate leaves a stale cached call result.
+	s.Assume = map[*types.Var][]constant.Value{m: {constant.MakeString("slow")}}
+	s.ScanCallResult(call, 0)
+	s.Assume = nil
+
+	inc := s.CheckConsistency(call, 0)
+	if inc == nil {
+		t.Fatal("got no inconsistency")
+	}
+	if len(inc.Extra) != 1 || inc.Extra[0].ExactString() != `"slow"` {
+		t.Errorf("got extra values %v, want [\"slow\"]", inc.Extra)
+	}
+	if inc.Func.Name() != "mode" {
+		t.Errorf("got function %s, want mode", inc.Func.Name())
+	}
+
+	s.Invalidate()
+	if inc := s.CheckConsistency(call, 0); inc != nil {
+		t.Errorf("after Invalidate, got inconsistency %q, want none", inc.Msg)
+	}
+}
+
+func TestTimerReceive(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/scan/select_timeout.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var recv *ast.UnaryExpr
+	ast.Inspect(file, func(n ast.Node) bool {
+		if u, ok := n.(*ast.UnaryExpr); ok && u.Op == token.ARROW && types.ExprString(u.X) == "ticker.C" {
+			recv = u
+		}
+		return true
+	})
+	if recv == nil {
+		t.Fatal("receive from ticker.C not found")
+	}
+
+	res := s.Scan(recv)
+	if res.Complete {
+		t.Fatal("got complete result, want incomplete")
+	}
+	const want = `scanning <-ticker.C
+  receive from ticker.C yields the time a timer fires, an external input
+`
+	if got := res.Why.Format(nil); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestScannerAssume(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/scan/param.go")
+
+	var x *types.Var
+	for ident, obj := range info.Defs {
+		if ident.Name == "x" {
+			x = obj.(*types.Var)
+		}
+	}
+	if x == nil {
+		t.Fatal("parameter x not found")
+	}
+
+	s := &Scanner{
+		Files:  []*ast.File{file},
+		Info:   info,
+		Assume: map[*types.Var][]constant.Value{x: {constant.MakeString(""), constant.MakeString("goodbye")}},
+	}
+	res := s.Scan(findResult(t, file))
+
+	want := map[string]constant.Value{
+		`""`:        constant.MakeString(""),
+		`"goodbye"`: constant.MakeString("goodbye"),
+		`"hello"`:   constant.MakeString("hello"),
+	}
+	if !reflect.DeepEqual(res.Values, want) {
+		t.Errorf("got %v, want %v", res.Values, want)
+	}
+	if !res.Complete {
+		t.Errorf("got incomplete result, want complete; reason:\n%s", res.Why.Format(nil))
+	}
+}
+
+func TestMaxValues(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/scan/binary_op.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info, MaxValues: 1}
+
+	res := s.Scan(findResult(t, file))
+
+	want := map[string]constant.Value{
+		`21`: constant.MakeInt64(21),
+	}
+	if !reflect.DeepEqual(res.Values, want) {
+		t.Errorf("got %v, want %v", res.Values, want)
+	}
+	if res.Complete {
+		t.Error("got complete result, want incomplete")
+	}
+	if !res.Truncated {
+		t.Error("result not marked truncated")
+	}
+	if got := res.Why.Format(nil); !strings.Contains(got, "values exceed the limit of 1") {
+		t.Errorf("reason does not mention the limit:\n%s", got)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/scan/binary_op.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info, Timeout: time.Nanosecond}
+
+	res := s.Scan(findResult(t, file))
+	if res.Complete {
+		t.Error("got complete result, want incomplete")
+	}
+	if !res.TimedOut {
+		t.Error("result not marked timed out")
+	}
+	if got := res.Why.Format(nil); !strings.Contains(got, "timed out before variable n") {
+		t.Errorf("reason does not mention the timeout:\n%s", got)
+	}
+
+	// Timed-out results are not cached.
+	s.Timeout = 0
+	res = s.Scan(findResult(t, file))
+	if !res.Complete {
+		t.Errorf("got incomplete result, want complete; reason:\n%s", res.Why.Format(nil))
+	}
+}
+
+func TestUnknownCalls(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/unknowncalls/unknowncalls.go")
+
+	cases := []struct {
+		mode UnknownCalls
+		want map[string]constant.Value
+	}{{
+		mode: AssumeReturns,
+		want: map[string]constant.Value{`"ok"`: constant.MakeString("ok")},
+	}, {
+		mode: AssumeMayPanic,
+		want: map[string]constant.Value{
+			`""`:   constant.MakeString(""),
+			`"ok"`: constant.MakeString("ok"),
+		},
+	}}
+
+	for _, tc := range cases {
+		s := &Scanner{Files: []*ast.File{file}, Info: info, UnknownCalls: tc.mode}
+		res := s.Scan(findResult(t, file))
+		if !res.Complete {
+			t.Errorf("mode %d: got incomplete result; reason:\n%s", tc.mode, res.Why.Format(nil))
+		}
+		if !reflect.DeepEqual(res.Values, tc.want) {
+			t.Errorf("mode %d: got %v, want %v", tc.mode, res.Values, tc.want)
+		}
+	}
+}
+
+func TestIntraprocedural(t *testing.T) {
+	cases := []struct {
+		file     string
+		want     map[string]constant.Value
+		complete bool
+	}{{
+		// A call of another function is opaque.
+		file:     "param_binding.go",
+		complete: false,
+	}, {
+		// A function literal called in place is followed.
+		file:     "closure_immediate.go",
+		want:     map[string]constant.Value{`"hello"`: constant.MakeString("hello")},
+		complete: true,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			file, info := loadTestFile(t, filepath.Join("testdata/scan", tc.file))
+			s := &Scanner{Files: []*ast.File{file}, Info: info, Intraprocedural: true}
+			res := s.Scan(findResult(t, file))
+			if !reflect.DeepEqual(res.Values, tc.want) {
+				t.Errorf("got %v, want %v", res.Values, tc.want)
+			}
+			if res.Complete != tc.complete {
+				t.Errorf("got complete = %v, want %v", res.Complete, tc.complete)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/audit/audit.go")
+	expr := findResult(t, file)
+
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+	if res := s.Scan(expr); !res.Complete {
+		t.Fatalf("without auditing, got incomplete result; reason:\n%s", res.Why.Format(nil))
+	}
+
+	s = &Scanner{Files: []*ast.File{file}, Info: info, Audit: true}
+	res := s.Scan(expr)
+	if res.Complete {
+		t.Fatal("with auditing, got complete result, want incomplete")
+	}
+	const want = `scanning mode
+  audit: mode is assigned in a goroutine
+`
+	if got := res.Why.Format(nil); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A result that passes the audit is unaffected.
+	file, info = loadTestFile(t, "testdata/scan/self_assignment.go")
+	s = &Scanner{Files: []*ast.File{file}, Info: info, Audit: true}
+	if res := s.Scan(findResult(t, file)); !res.Complete {
+		t.Errorf("got incomplete result; reason:\n%s", res.Why.Format(nil))
+	}
+}
+
+func TestExplain(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/explain/explain.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	res := s.Scan(findResult(t, file))
+
+	d := s.Explain(res, constant.MakeString("verbose"))
+	if d == nil {
+		t.Fatal("no derivation")
+	}
+
+	const want = `variable m
+  call to pick
+    variable s
+      "verbose"
+`
+	if got := d.Format(nil); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if d := s.Explain(res, constant.MakeString("slow")); d != nil {
+		t.Errorf("got derivation for a value that is not present:\n%s", d.Format(nil))
+	}
+}
+
+func TestWriters(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/writers/writers.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	res := s.Writers(findResult(t, file))
+	if !res.Complete {
+		t.Fatalf("got incomplete result; reason:\n%s", res.Why.Format(nil))
+	}
+
+	var got []string
+	for _, w := range res.Writers {
+		got = append(got, fmt.Sprintf("%T %s %v", w.Stmt, types.ExprString(w.Expr), w.Zero))
+	}
+	want := []string{
+		"*ast.DeclStmt mode true",
+		`*ast.ReturnStmt "fast" false`,
+		`*ast.ReturnStmt strings.ToUpper("slow") false`,
+		`*ast.AssignStmt "default" false`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGenerateTest(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/gentest/gentest.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "defaultMode" {
+			fn = obj.(*types.Func)
+		}
+	}
+
+	got, err := s.GenerateTest(nil, fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = `package test
+
+import "testing"
+
+// TestDefaultModeValues checks that defaultMode returns only the values found for it by exprvals.
+func TestDefaultModeValues(t *testing.T) {
+	got := defaultMode()
+	switch got {
+	case "fast":
+	case "slow":
+	case "verbose": // via call to pick, variable s
+	default:
+		t.Errorf("defaultMode() = %v, want one of the values found by exprvals", got)
+	}
+}
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	for ident, obj := range info.Defs {
+		if ident.Name == "pick" {
+			if _, err := s.GenerateTest(nil, obj.(*types.Func), 0); err == nil {
+				t.Error("got no error for a function with parameters")
+			}
+		}
+	}
+}
+
+func TestGenerateTestDuplicates(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/gentest/dup.go")
+
+	var (
+		fn    *types.Func
+		third *types.Var
+	)
+	for ident, obj := range info.Defs {
+		switch ident.Name {
+		case "defaultThird":
+			fn = obj.(*types.Func)
+		case "third":
+			third = obj.(*types.Var)
+		}
+	}
+
+	// Exactly one third and its nearest float64 are different constants
+	// but the same float64.
+	s := &Scanner{
+		Files: []*ast.File{file},
+		Info:  info,
+		Assume: map[*types.Var][]constant.Value{third: {
+			constant.BinaryOp(constant.MakeInt64(1), token.QUO, constant.MakeInt64(3)),
+			constant.MakeFloat64(1.0 / 3),
+		}},
+	}
+	got, err := s.GenerateTest(nil, fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(got), "case "); n != 1 {
+		t.Errorf("got %d cases, want 1:\n%s", n, got)
+	}
+	if !strings.Contains(string(got), "case 0.3333333333333333:") {
+		t.Errorf("got:\n%s\nwant a case for 0.3333333333333333", got)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/reachable/reachable.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var (
+		n     int
+		fatal ast.Stmt
+		env   *types.Var
+	)
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Field:
+			if len(node.Names) > 0 && node.Names[0].Name == "env" {
+				env = info.Defs[node.Names[0]].(*types.Var)
+			}
+		case *ast.ExprStmt:
+			call, ok := node.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			switch types.ExprString(call.Fun) {
+			case "reached", "unreached":
+				n++
+				want := types.ExprString(call.Fun) == "reached"
+				if got := s.Reachable(node); got != want {
+					t.Errorf("marker %d (%s): got %v, want %v", n, types.ExprString(call.Fun), got, want)
+				}
+			case "log.Fatal":
+				fatal = node
+			}
+		}
+		return true
+	})
+	if n == 0 {
+		t.Fatal("no statements to check")
+	}
+
+	// The last log.Fatal is in g, which can only reach it in production.
+	if !s.Reachable(fatal) {
+		t.Error("log.Fatal in g is unreachable without assumptions")
+	}
+	s.Assume = map[*types.Var][]constant.Value{env: {constant.MakeString("staging")}}
+	if s.Reachable(fatal) {
+		t.Error("log.Fatal in g is reachable when env is staging")
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/enum/enum.go")
+	files := []*ast.File{file}
+
+	lookup := func(name string) *types.Named {
+		for ident, obj := range info.Defs {
+			if tn, ok := obj.(*types.TypeName); ok && ident.Name == name {
+				return tn.Type().(*types.Named)
+			}
+		}
+		t.Fatalf("type %s not found", name)
+		return nil
+	}
+
+	cases := []struct {
+		typ     string
+		members []string
+		vals    []int64
+	}{{
+		typ:     "flag",
+		members: []string{"flagA=1", "flagB=2", "_=4", "flagD=8"},
+		vals:    []int64{1, 2, 8},
+	}, {
+		typ:     "level",
+		members: []string{"levelLow=0", "levelMid=100", "levelHigh=200", "levelDefault=100"},
+		vals:    []int64{0, 100, 200},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.typ, func(t *testing.T) {
+			enum := EnumValues(lookup(c.typ), files, info)
+			if enum == nil {
+				t.Fatal("no enum found")
+			}
+
+			var members []string
+			for _, m := range enum.Members {
+				members = append(members, m.Obj.Name()+"="+m.Value().ExactString())
+			}
+			if !reflect.DeepEqual(members, c.members) {
+				t.Errorf("got members %v, want %v", members, c.members)
+			}
+
+			want := make(map[string]constant.Value)
+			for _, v := range c.vals {
+				cv := constant.MakeInt64(v)
+				want[cv.ExactString()] = cv
+			}
+			if got := enum.Values(); !reflect.DeepEqual(got, want) {
+				t.Errorf("got values %v, want %v", got, want)
+			}
+		})
+	}
+
+	enum := EnumValues(lookup("level"), files, info)
+	if got := enum.Lookup(constant.MakeInt64(100)); len(got) != 2 {
+		t.Errorf("got %d members with value 100, want 2", len(got))
+	}
+
+	// A type from a dependency, known only from export data.
+	file, info = loadTestFile(t, "testdata/enum/external.go")
+	var tok *types.Named
+	for _, obj := range info.Uses {
+		if tn, ok := obj.(*types.TypeName); ok && tn.Name() == "Token" {
+			tok = tn.Type().(*types.Named)
+		}
+	}
+	if tok == nil {
+		t.Fatal("type token.Token not found")
+	}
+	enum = EnumValues(tok, []*ast.File{file}, info)
+	if enum == nil {
+		t.Fatal("no enum found for token.Token")
+	}
+	var first []string
+	for _, m := range enum.Members[:4] {
+		first = append(first, m.Obj.Name())
+	}
+	if want := []string{"ILLEGAL", "EOF", "COMMENT", "IDENT"}; !reflect.DeepEqual(first, want) {
+		t.Errorf("got first members %v, want %v", first, want)
+	}
+	for _, m := range enum.Members {
+		if !m.Obj.Exported() {
+			t.Errorf("got unexported member %s", m.Obj.Name())
+		}
+	}
+	if got := enum.Lookup(constant.MakeInt64(int64(token.ADD))); len(got) != 1 || got[0].Obj.Name() != "ADD" {
+		t.Errorf("got members %v for the value of token.ADD", got)
+	}
+}
+
+func TestScanBits(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/bits/perm.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	got := s.ScanBits(findResult(t, file))
+	if !got.Complete {
+		t.Fatalf("got incomplete result; reason:\n%s", got.Why.Format(nil))
+	}
+	if want := constant.MakeInt64(0b1111); !valuesEqual(got.May, want) {
+		t.Errorf("got may-bits %s, want %s", got.May, want)
+	}
+	if want := constant.MakeInt64(0b0001); !valuesEqual(got.Must, want) {
+		t.Errorf("got must-bits %s, want %s", got.Must, want)
+	}
+}
+
+func TestMapKeys(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/mapkeys/config.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	got := s.MapKeys(findResult(t, file))
+	if !got.Complete {
+		t.Fatalf("got incomplete result; reason:\n%s", got.Why.Format(nil))
+	}
+	want := map[string]constant.Value{
+		`"host"`:    constant.MakeString("host"),
+		`"mode"`:    constant.MakeString("mode"),
+		`"port"`:    constant.MakeString("port"),
+		`"retries"`: constant.MakeString("retries"),
+		`"timeout"`: constant.MakeString("timeout"),
+	}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("got %v, want %v", got.Values, want)
+	}
+}
+
+func TestParts(t *testing.T) {
+	cases := map[string][]string{
+		"struct": {
+			`.Limit.Max [0 10] true`,
+			`.Mode [""] false`,
+			`.Port [80] true`,
+		},
+		"slice": {
+			`[...] [] true`,
+			`[0] ["a"] true`,
+			`[1] ["b"] false`,
+		},
+		"map": {
+			`["a"] ["x"] true`,
+			`["b"] [""] false`,
+			`[...] [""] true`,
+		},
+	}
+	for name, want := range cases {
+		t.Run(name, func(t *testing.T) {
+			file, info := loadTestFile(t, "testdata/parts/"+name+".go")
+			s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+			// The result of f is a part of the value to scan.
+			var expr ast.Expr
+			switch result := findResult(t, file).(type) {
+			case *ast.IndexExpr:
+				expr = result.X
+			case *ast.SelectorExpr:
+				expr = result.X
+			default:
+				t.Fatalf("unexpected result %s", types.ExprString(result))
+			}
+
+			var got []string
+			for key, part := range s.Scan(expr).Parts {
+				got = append(got, fmt.Sprintf("%s %v %v", key, exactStrings(part.Values), part.Complete))
+			}
+			slices.Sort(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckNilComparisons(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/typednil/typednil.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var got []string
+	for _, c := range s.CheckNilComparisons() {
+		for _, tn := range c.TypedNils {
+			got = append(got, types.ExprString(c.Cmp)+": "+types.ExprString(tn.Expr)+" ("+tn.Type.String()+") from "+types.ExprString(tn.Origin))
+		}
+	}
+	want := []string{
+		`check("x") != nil: err (*test.validationError) from nil`,
+		`err == nil: e (*test.validationError) from e`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCheckNilChannels(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/nilchan/nilchan.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var got []string
+	for _, op := range s.CheckNilChannels() {
+		var desc string
+		switch n := op.Op.(type) {
+		case ast.Stmt:
+			desc = fmt.Sprintf("%T", n)
+		case ast.Expr:
+			desc = types.ExprString(n)
+		}
+		if op.Chan != nil {
+			desc += " on " + types.ExprString(op.Chan)
+		}
+		got = append(got, desc)
+	}
+	want := []string{
+		`*ast.SendStmt on done`,
+		`<-done on done`,
+		`*ast.SelectStmt`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Nothing after a receive on a nil channel is reachable.
+	var println ast.Stmt
+	ast.Inspect(file, func(n ast.Node) bool {
+		if stmt, ok := n.(*ast.ExprStmt); ok && println == nil {
+			if call, ok := stmt.X.(*ast.CallExpr); ok && types.ExprString(call) == `println("unreachable")` {
+				println = stmt
+			}
+		}
+		return true
+	})
+	if s.Reachable(println) {
+		t.Error("statement after receive on nil channel is reachable")
+	}
+}
+
+func TestCheckStringConversions(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/stringconv/stringconv.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var got []string
+	for _, c := range s.CheckStringConversions() {
+		got = append(got, fmt.Sprintf("%s: %v", types.ExprString(c.Conv), c.Values))
+	}
+	want := []string{"string(code): [7]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTemplateTexts(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/template/template.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	res := s.TemplateTexts(findResult(t, file))
+	if !res.Complete {
+		t.Fatalf("got incomplete result; reason:\n%s", res.Why.Format(nil))
+	}
+	want := []string{
+		`"{{define \"header\"}}<h1>{{.Title}}</h1>{{end}}{{.Body}} ({{.Author}})"`,
+		`"{{define \"header\"}}<h1>{{.Title}}</h1>{{end}}{{.Body}}"`,
+	}
+	if got := exactStrings(res.Values); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	file, info = loadTestFile(t, "testdata/template/inplace.go")
+	s = &Scanner{Files: []*ast.File{file}, Info: info}
+	if res := s.TemplateTexts(findResult(t, file)); res.Complete {
+		t.Errorf("got complete result %v for a template parsed in place", exactStrings(res.Values))
+	}
+}
+
+func TestSquirrelToSql(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":             "module example.com/app\n\ngo 1.23\n\nrequire github.com/Masterminds/squirrel v1.5.4\n",
+		"vendor/modules.txt": "# github.com/Masterminds/squirrel v1.5.4\n## explicit\ngithub.com/Masterminds/squirrel\n",
+		"vendor/github.com/Masterminds/squirrel/select.go": `package squirrel
+
+type SelectBuilder struct{ sql string }
+
+func Select(columns ...string) SelectBuilder { return SelectBuilder{} }
+
+func (b SelectBuilder) From(from string) SelectBuilder                         { return b }
+func (b SelectBuilder) Join(join string, rest ...interface{}) SelectBuilder     { return b }
+func (b SelectBuilder) LeftJoin(join string, rest ...interface{}) SelectBuilder { return b }
+func (b SelectBuilder) Where(pred interface{}, args ...interface{}) SelectBuilder {
+	return b
+}
+func (b SelectBuilder) OrderBy(orderBys ...string) SelectBuilder { return b }
+func (b SelectBuilder) Limit(limit uint64) SelectBuilder          { return b }
+func (b SelectBuilder) ToSql() (string, []interface{}, error)     { return b.sql, nil, nil }
+
+type Eq map[string]interface{}
+`,
+		"main.go": `package main
+
+import (
+	"os"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+func users(admin bool) sq.SelectBuilder {
+	table := "users"
+	if admin {
+		table = "admins"
+	}
+	return sq.Select("id", "name").From(table)
+}
+
+func main() {
+	admin := len(os.Args) > 1
+	order := "name"
+	if len(os.Args) > 2 {
+		order = "id"
+	}
+	q := users(admin).LeftJoin("teams USING (team_id)").Where("active = ?", true).OrderBy(order).Limit(10)
+	text, _, _ := q.ToSql()
+	println(text)
+
+	eq, _, _ := users(admin).Where(sq.Eq{"id": 1}).ToSql()
+	println(eq)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports := ReportPackages(pkgs, ReportOptions{})
+	got := make(map[string]string)
+	for _, e := range reports[0] {
+		if e.Kind == "var" {
+			got[e.Name] = fmt.Sprintf("%v %v", e.Values, e.Complete)
+		}
+	}
+	want := map[string]string{
+		"text": `["SELECT id, name FROM admins LEFT JOIN teams USING (team_id) WHERE active = ? ORDER BY id LIMIT 10" ` +
+			`"SELECT id, name FROM admins LEFT JOIN teams USING (team_id) WHERE active = ? ORDER BY name LIMIT 10" ` +
+			`"SELECT id, name FROM users LEFT JOIN teams USING (team_id) WHERE active = ? ORDER BY id LIMIT 10" ` +
+			`"SELECT id, name FROM users LEFT JOIN teams USING (team_id) WHERE active = ? ORDER BY name LIMIT 10"] true`,
+		"eq": "[] false",
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("got %s = %s, want %s", name, got[name], w)
+		}
+	}
+}
+
+func TestCheckComparisons(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dyntypes/compare.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var got []string
+	for _, c := range s.CheckComparisons() {
+		for _, w := range c.Witnesses {
+			got = append(got, types.ExprString(c.Cmp)+": "+w.Type.String()+" from "+types.ExprString(w.Expr))
+		}
+	}
+	want := []string{
+		`a == b: []string from []string{…}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterSummary(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/scan/getenv_fallback.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+	expr := findResult(t, file)
+
+	check := func(want map[string]constant.Value, wantComplete bool) {
+		t.Helper()
+		res := s.Scan(expr)
+		if !reflect.DeepEqual(res.Values, want) {
+			t.Errorf("got %v, want %v", res.Values, want)
+		}
+		if res.Complete != wantComplete {
+			t.Errorf("got complete = %v, want %v", res.Complete, wantComplete)
+		}
+	}
+
+	unregistered := map[string]constant.Value{`"default"`: constant.MakeString("default")}
+	check(unregistered, false)
+
+	t.Cleanup(func() { RegisterSummary("os.Getenv", nil) })
+	RegisterSummary("os.Getenv", func(call *ast.CallExpr, idx int, scan func(ast.Expr) (map[string]constant.Value, bool)) (map[string]constant.Value, bool) {
+		return map[string]constant.Value{
+			`""`:     constant.MakeString(""),
+			`"prod"`: constant.MakeString("prod"),
+		}, true
+	})
+
+	// The cached result must not be reused.
+	check(map[string]constant.Value{
+		`"default"`: constant.MakeString("default"),
+		`""`:        constant.MakeString(""),
+		`"prod"`:    constant.MakeString("prod"),
+	}, true)
+
+	RegisterSummary("os.Getenv", nil)
+	check(unregistered, false)
+}
+
+func TestMissingInfo(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/scan/never_returns.go")
+	expr := findResult(t, file)
+
+	// A Scanner with partial type information,
+	// such as from go/packages without NeedTypesInfo.
+	s := &Scanner{Files: []*ast.File{file}, Info: &types.Info{Types: info.Types}}
+	res := s.Scan(expr)
+	if res.Complete {
+		t.Fatal("got complete result, want incomplete")
+	}
+	if got, want := res.Why.Format(nil), "scanning mode\n  type information lacks Defs, Uses\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTypeInfo(t *testing.T) {
+	src, err := testdataFS.ReadFile("testdata/scan/never_returns.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "never_returns.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []*ast.File{file}
+
+	// Type-check without recording any information,
+	// as go/packages does for NeedTypes without NeedTypesInfo.
+	conf := types.Config{Importer: testImporter}
+	pkg, err := conf.Check("test", fset, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := TypeInfo(fset, files, pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, complete := Scan(findResult(t, file), files, info)
+	want := map[string]constant.Value{`"fast"`: constant.MakeString("fast")}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("got %v, want %v", vals, want)
+	}
+	if !complete {
+		t.Error("got incomplete result")
+	}
+}
+
+func TestRunCorpus(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/corpus\n\ngo 1.23\n",
+		"a/a.go": `package a
+
+const Mode = "fast"
+`,
+		"main.go": `package main
+
+import "example.com/corpus/a"
+
+func main() {
+	x := a.Mode
+	if len(x) > 3 {
+		x = "slow"
+	}
+	println(x)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Select the uses of x.
+	sel := func(expr ast.Expr, info *types.Info) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Name == "x" && info.Uses[ident] != nil
+	}
+	results, err := RunCorpus(dir, sel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []wantPair
+	for _, r := range results {
+		if r.Pkg.Path != "example.com/corpus" {
+			t.Errorf("got package %s, want example.com/corpus", r.Pkg.Path)
+		}
+		got = append(got, wantPair{vals: r.Values, complete: r.Complete})
+	}
+	want := []wantPair{
+		// The x in len(x).
+		{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		// The x in x = "slow".
+		{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+		// The x in println(x).
+		{
+			vals: map[string]constant.Value{
+				`"fast"`: constant.MakeString("fast"),
+				`"slow"`: constant.MakeString("slow"),
+			},
+			complete: true,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzeFunc(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/dataflow.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	var names []string
+	for _, v := range fv.Vars {
+		names = append(names, v.Name())
+	}
+	if want := []string{"verbose", "level", "mode", "last", "i"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got vars %v, want %v", names, want)
+	}
+
+	// describe renders the values of the variables at a statement.
+	describe := func(stmt string) string {
+		for _, p := range fv.Points {
+			if !strings.HasPrefix(stmtString(p.Stmt), stmt) {
+				continue
+			}
+			var parts []string
+			for _, v := range fv.Vars {
+				res, ok := p.Values[v]
+				if !ok {
+					continue
+				}
+				part := v.Name() + "=" + strings.Join(exactStrings(res.Values), ",")
+				if !res.Complete {
+					part += "..."
+				}
+				parts = append(parts, part)
+			}
+			return strings.Join(parts, " ")
+		}
+		t.Fatalf("no point for %s", stmt)
+		return ""
+	}
+
+	cases := []struct{ stmt, want string }{
+		{`level := 1`, `verbose=...`},
+		{`mode = "slow"`, `verbose=false,true level=1,2 mode="fast"`},
+		{`last = i`, `verbose=false,true level=1,2 mode="slow" last=-1,0,1,2 i=0,1,2`},
+		{`return mode`, `verbose=false,true level=1,2 mode="slow" last=-1,0,1,2`},
+	}
+	for _, c := range cases {
+		if got := describe(c.stmt); got != c.want {
+			t.Errorf("at %s: got %s, want %s", c.stmt, got, c.want)
+		}
+	}
+}
+
+func stmtString(stmt ast.Stmt) string {
+	buf := new(strings.Builder)
+	format.Node(buf, token.NewFileSet(), stmt)
+	return buf.String()
+}
+
+func TestAnalyzeFuncClamp(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/clamp.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		for v, res := range p.Values {
+			if v.Name() != "retries" {
+				continue
+			}
+			// The value from the command line is unknown,
+			// but 50 is ruled out by the clamp.
+			if got, want := exactStrings(res.Values), []string{"10", "3"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if res.Complete {
+				t.Error("got complete result, want incomplete")
+			}
+			return
+		}
+	}
+	t.Fatal("no values for retries at the return statement")
+}
+
+func TestAnalyzeFuncStructs(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/structs.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	// Assigning the whole struct replaces the values of all its fields.
+	wants := map[string][]string{
+		"cfg.mode":    {`"d"`},
+		"cfg.limit.n": {"7"},
+	}
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		got := make(map[string][]string)
+		for v, res := range p.Values {
+			if _, ok := wants[v.Name()]; !ok {
+				continue
+			}
+			if !res.Complete {
+				t.Errorf("%s: got incomplete result", v.Name())
+			}
+			got[v.Name()] = exactStrings(res.Values)
+		}
+		if !reflect.DeepEqual(got, wants) {
+			t.Errorf("got %v, want %v", got, wants)
+		}
+		return
+	}
+	t.Fatal("no return statement")
+}
+
+func TestAnalyzeFuncSelect(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/select.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	wants := map[string][]string{
+		"state": {`"a"`, `"quit"`},
+		"mode":  {`"a!"`, `"none"`},
+	}
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		got := make(map[string][]string)
+		for v, res := range p.Values {
+			if _, ok := wants[v.Name()]; !ok {
+				continue
+			}
+			if !res.Complete {
+				t.Errorf("%s: got incomplete result", v.Name())
+			}
+			got[v.Name()] = exactStrings(res.Values)
+		}
+		if !reflect.DeepEqual(got, wants) {
+			t.Errorf("got %v, want %v", got, wants)
+		}
+		return
+	}
+	t.Fatal("no return statement")
+}
+
+func TestAnalyzeFuncSend(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/send.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	wants := map[string][]string{
+		// The value sent is that of x at the send,
+		// not at the receive.
+		"got": {`"a"`},
+
+		// Sending the result of a call that never returns
+		// does not complete.
+		"state": {`"ok"`},
+	}
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		got := make(map[string][]string)
+		for v, res := range p.Values {
+			if _, ok := wants[v.Name()]; !ok {
+				continue
+			}
+			if !res.Complete {
+				t.Errorf("%s: got incomplete result", v.Name())
+			}
+			got[v.Name()] = exactStrings(res.Values)
+		}
+		if !reflect.DeepEqual(got, wants) {
+			t.Errorf("got %v, want %v", got, wants)
+		}
+		return
+	}
+	t.Fatal("no return statement")
+}
+
+func TestAnalyzeFuncBranches(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/branches.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	wants := map[string][]string{
+		"state": {`"seen"`, `"start"`},
+		"mode":  {`"inner"`, `"none"`, `"outer"`},
+	}
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		got := make(map[string][]string)
+		for v, res := range p.Values {
+			if _, ok := wants[v.Name()]; !ok {
+				continue
+			}
+			if !res.Complete {
+				t.Errorf("%s: got incomplete result", v.Name())
+			}
+			got[v.Name()] = exactStrings(res.Values)
+		}
+		if !reflect.DeepEqual(got, wants) {
+			t.Errorf("got %v, want %v", got, wants)
+		}
+		return
+	}
+	t.Fatal("no return statement")
+}
+
+func TestAnalyzeFuncSwap(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/swap.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	wants := map[string][]string{
+		"x":        {`"b"`},
+		"y":        {`"a"`},
+		"i":        {`2`},
+		"j":        {`3`},
+		"p.first":  {`"right"`},
+		"p.second": {`"left"`},
+	}
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		got := make(map[string][]string)
+		for v, res := range p.Values {
+			if _, ok := wants[v.Name()]; !ok {
+				continue
+			}
+			if !res.Complete {
+				t.Errorf("%s: got incomplete result", v.Name())
+			}
+			got[v.Name()] = exactStrings(res.Values)
+		}
+		if !reflect.DeepEqual(got, wants) {
+			t.Errorf("got %v, want %v", got, wants)
+		}
+		return
+	}
+	t.Fatal("no return statement")
+}
+
+func TestAnalyzeFuncTypeSwitch(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/typeswitch.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		for v, res := range p.Values {
+			if v.Name() != "s" {
+				continue
+			}
+			if !res.Complete {
+				t.Error("got incomplete result")
+			}
+			// Without following x in the clause,
+			// "a" would be among its values.
+			want := []string{`"a!"`, `"int"`, `"none"`}
+			if got := exactStrings(res.Values); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			return
+		}
+	}
+	t.Fatal("no value for s at return statement")
+}
+
+func TestAnalyzeFuncBlank(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/blank.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		t.Fatal("no result")
+	}
+
+	wants := map[string][]string{
+		"x": {`"b"`},
+		"y": {`"a"`},
+	}
+	for _, p := range fv.Points {
+		if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+			continue
+		}
+		for v, res := range p.Values {
+			want, ok := wants[v.Name()]
+			if !ok {
+				continue
+			}
+			delete(wants, v.Name())
+			if !res.Complete {
+				t.Errorf("%s: got incomplete result", v.Name())
+			}
+			if got := exactStrings(res.Values); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: got %v, want %v", v.Name(), got, want)
+			}
+		}
+	}
+	for name := range wants {
+		t.Errorf("no value for %s at return statement", name)
+	}
+}
+
+func TestAnalyzeFuncJoin(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/dataflow/join.go")
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "f" {
+			fn = obj.(*types.Func)
+		}
+	}
+
+	// A domain-specific join that keeps the values when they agree
+	// and otherwise gives up on them.
+	collapse := func(v *types.Var, x, y map[string]constant.Value) (map[string]constant.Value, bool) {
+		if reflect.DeepEqual(exactStrings(x), exactStrings(y)) {
+			return cloneVals(x), true
+		}
+		return map[string]constant.Value{}, false
+	}
+
+	cases := []struct {
+		name         string
+		join         JoinFunc
+		want         []string
+		wantComplete bool
+	}{
+		{"default", nil, []string{`"append"`, `"none"`, `"read"`, `"write"`}, true},
+		{"union", UnionJoin, []string{`"append"`, `"none"`, `"read"`, `"write"`}, true},
+		{"k4", KLimitJoin(4), []string{`"append"`, `"none"`, `"read"`, `"write"`}, true},
+		{"k2", KLimitJoin(2), []string{`"append"`, `"none"`}, false},
+		{"collapse", collapse, nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Scanner{Files: []*ast.File{file}, Info: info, Join: tc.join}
+			fv := s.AnalyzeFunc(fn)
+			if fv == nil {
+				t.Fatal("no result")
+			}
+			for _, p := range fv.Points {
+				if _, ok := p.Stmt.(*ast.ReturnStmt); !ok {
+					continue
+				}
+				for v, res := range p.Values {
+					if v.Name() != "mode" {
+						continue
+					}
+					if got := exactStrings(res.Values); !reflect.DeepEqual(got, tc.want) && (len(got) > 0 || len(tc.want) > 0) {
+						t.Errorf("got %v, want %v", got, tc.want)
+					}
+					if res.Complete != tc.wantComplete {
+						t.Errorf("got complete %v, want %v", res.Complete, tc.wantComplete)
+					}
+					return
+				}
+			}
+			t.Fatal("no values of mode at the return statement")
+		})
+	}
+}
+
+func TestScanPanicValues(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/panics/panics.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	var fn *types.Func
+	for ident, obj := range info.Defs {
+		if ident.Name == "lookup" {
+			fn = obj.(*types.Func)
+		}
+	}
+
+	res := s.ScanPanicValues(fn)
+	if !res.Complete {
+		t.Fatalf("got incomplete result; reason:\n%s", res.Why.Format(nil))
+	}
+	if len(res.Calls) != 3 {
+		t.Errorf("got %d calls to panic, want 3", len(res.Calls))
+	}
+	if got, want := exactStrings(res.Values), []string{`"closed"`, "1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got values %v, want %v", got, want)
+	}
+	var typs []string
+	for _, dt := range res.Types {
+		typs = append(typs, dt.Type.String())
+	}
+	if want := []string{"string", "test.errNotFound", "int"}; !reflect.DeepEqual(typs, want) {
+		t.Errorf("got types %v, want %v", typs, want)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	file, info := loadTestFile(t, "testdata/history/history.go")
+	s := &Scanner{Files: []*ast.File{file}, Info: info}
+
+	// The query point is the argument of the last call to println.
+	var ident *ast.Ident
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && types.ExprString(call.Fun) == "println" {
+			ident = call.Args[0].(*ast.Ident)
+		}
+		return true
+	})
+
+	hist := s.History(ident)
+	if !hist.Complete {
+		t.Fatalf("got incomplete result; reason:\n%s", hist.Why.Format(nil))
+	}
+	got := make(map[string]string)
+	for k, w := range hist.When {
+		got[k] = w.String()
+	}
+	want := map[string]string{
+		`"boot"`:  "before",
+		`"run"`:   "at",
+		`"debug"`: "at",
+		`"done"`:  "after",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRank(t *testing.T) {
+	cases := []struct {
+		file string
+		want []string
+	}{
+		{"rank.go", []string{`"info"`, `"warn"`, `"debug"`, `"verbose-x"`, `"trace"`}},
+		{"zero.go", []string{`"json"`, `"text"`, `""`}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			file, info := loadTestFile(t, filepath.Join("testdata/rank", tc.file))
+			s := &Scanner{Files: []*ast.File{file}, Info: info}
+			r := s.Rank(findResult(t, file))
+			if !r.Complete {
+				t.Error("got incomplete result")
+			}
+			if !reflect.DeepEqual(r.Order, tc.want) {
+				t.Errorf("got %v, want %v", r.Order, tc.want)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/report\n\ngo 1.23\n",
+		"main.go": `package main
+
+import "os"
+
+func mode(fast bool) (m string) {
+	if fast {
+		m = "fast"
+		return
+	}
+	return "slow"
+}
+
+func main() {
+	level := 1
+	if len(os.Args) > 1 {
+		level = 2
+	}
+	println(mode(level > 1), level)
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("got %d packages, want 1", len(pkgs))
+	}
+
+	var got []string
+	for _, e := range Report(pkgs[0], 0) {
+		got = append(got, fmt.Sprintf("%s %s %s %v %v", e.Kind, e.Func, e.Name, e.Values, e.Complete))
+	}
+	want := []string{
+		`result example.com/report.mode m ["fast" "slow"] true`,
+		`param example.com/report.mode fast [] false`,
+		`var example.com/report.main level [1 2] true`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReportDeferRecover(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/report\n\ngo 1.23\n",
+		"main.go": `package main
+
+func mode() (m string) {
+	defer func() { m = "deferred" }()
+	return "fast"
+}
+
+func try() (s string) {
+	defer func() { recover() }()
+	s = "x"
+	panic("boom")
+}
+
+func main() {
+	println(mode(), try())
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("got %d packages, want 1", len(pkgs))
+	}
+
+	var got []string
+	for _, e := range Report(pkgs[0], 0) {
+		got = append(got, fmt.Sprintf("%s %s %s %v %v", e.Kind, e.Func, e.Name, e.Values, e.Complete))
+	}
+	want := []string{
+		`result example.com/report.mode m ["deferred"] true`,
+		`result example.com/report.try s ["" "x"] true`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReportPackages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/report\n\ngo 1.23\n",
+		"config/config.go": `package config
+
+func Mode(fast bool) string {
+	if fast {
+		return "fast"
+	}
+	return "slow"
+}
+`,
+		"main.go": `package main
+
+import (
+	"os"
+
+	"example.com/report/config"
+)
+
+func main() {
+	mode := config.Mode(len(os.Args) > 1)
+	println(mode)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages, want 2", len(pkgs))
+	}
+
+	var order []string
+	reports := ReportPackages(pkgs, ReportOptions{
+		Workers: 2,
+		Progress: func(pkg *Package, done int) {
+			order = append(order, fmt.Sprintf("%d %s", done, pkg.Path))
+		},
+	})
+
+	// The config package is imported by the main package, so it is finished first.
+	if want := []string{"1 example.com/report/config", "2 example.com/report"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("got progress %q, want %q", order, want)
+	}
+
+	var got []string
+	for i, pkg := range pkgs {
+		for _, e := range reports[i] {
+			if e.Kind == "var" {
+				got = append(got, fmt.Sprintf("%s %s %v %v", pkg.Path, e.Name, e.Values, e.Complete))
+			}
+		}
+	}
+	want := []string{
+		// The result of config.Mode is known from the analysis of its package.
+		`example.com/report mode ["fast" "slow"] true`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReportPackagesDeferred(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/report\n\ngo 1.23\n",
+		"config/config.go": `package config
+
+func Mode() (m string) {
+	defer func() { m = "deferred" }()
+	return "fast"
+}
+`,
+		"main.go": `package main
+
+import "example.com/report/config"
+
+func main() {
+	mode := config.Mode()
+	println(mode)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages, want 2", len(pkgs))
+	}
+
+	reports := ReportPackages(pkgs, ReportOptions{Workers: 2})
+
+	var got []string
+	for i, pkg := range pkgs {
+		for _, e := range reports[i] {
+			got = append(got, fmt.Sprintf("%s %s %s %v %v", pkg.Path, e.Kind, e.Name, e.Values, e.Complete))
+		}
+	}
+	want := []string{
+		// The deferred assignment, not the return statement, decides what callers in other packages see.
+		`example.com/report var mode ["deferred"] true`,
+		`example.com/report/config result m ["deferred"] true`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLoadPackagesVendored(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":             "module example.com/app\n\ngo 1.23\n\nrequire example.org/dep v1.0.0\n",
+		"vendor/modules.txt": "# example.org/dep v1.0.0\n## explicit\nexample.org/dep\n",
+		"vendor/example.org/dep/dep.go": `package dep
+
+func Level(debug bool) string {
+	if debug {
+		return "debug"
+	}
+	return "info"
+}
+`,
+		"vendor/example.org/unused/unused.go": "package unused\n",
+		"main.go": `package main
+
+import (
+	"os"
+
+	"example.org/dep"
+)
+
+func main() {
+	level := dep.Level(len(os.Args) > 1)
+	println(level)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the vendored packages that are imported are loaded.
+	var paths []string
+	for _, pkg := range pkgs {
+		paths = append(paths, fmt.Sprintf("%s %v", pkg.Path, pkg.Vendored))
+	}
+	if want := []string{"example.com/app false", "example.org/dep true"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("got packages %q, want %q", paths, want)
+	}
+
+	reports := ReportPackages(pkgs, ReportOptions{Workers: 2})
+	var got []string
+	for _, e := range reports[0] {
+		if e.Kind == "var" {
+			got = append(got, fmt.Sprintf("%s %v %v", e.Name, e.Values, e.Complete))
+		}
+	}
+	if want := []string{`level ["debug" "info"] true`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReportPackagesDetail(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/detail\n\ngo 1.23\n",
+		"config/config.go": `package config
+
+func Mode(fast bool) string {
+	if fast {
+		return "fast"
+	}
+	return "slow"
+}
+`,
+		"main.go": `package main
+
+import (
+	"os"
+
+	"example.com/detail/config"
+)
+
+func main() {
+	mode := config.Mode(len(os.Args) > 1)
+	println(mode)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		detail    SummaryDetail
+		wantVar   string
+		wantBytes int
+	}{
+		// "fast" and "slow", 6 bytes each plus 8.
+		{detail: SummaryFull, wantVar: `mode ["fast" "slow"] true`, wantBytes: 28},
+		{detail: SummaryHash, wantVar: "mode [] false", wantBytes: 16},
+		{detail: SummaryTop, wantVar: "mode [] false", wantBytes: 0},
+	}
+	for _, c := range cases {
+		t.Run(c.detail.String(), func(t *testing.T) {
+			var stats SummaryStats
+			reports := ReportPackages(pkgs, ReportOptions{
+				Detail: []DetailRule{{Pattern: "example.com/detail/config/...", Detail: c.detail}},
+				Stats:  &stats,
+			})
+
+			var got []string
+			for i, pkg := range pkgs {
+				for _, e := range reports[i] {
+					if e.Kind == "var" {
+						got = append(got, fmt.Sprintf("%s %v %v", e.Name, e.Values, e.Complete))
+					}
+				}
+				if pkg.Path == "example.com/detail/config" {
+					if n := stats.Bytes[pkg.Path]; n != c.wantBytes {
+						t.Errorf("got %d bytes retained, want %d", n, c.wantBytes)
+					}
+				}
+			}
+			if want := []string{c.wantVar}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// mapCache is a [SummaryCache] backed by a map.
+type mapCache struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (c *mapCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.m[key]
+	return data, ok
+}
+
+func (c *mapCache) Put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[key] = data
+}
+
+func TestReportPackagesSummaries(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/cached\n\ngo 1.23\n",
+		"config/config.go": `package config
+
+func Mode(fast bool) string {
+	if fast {
+		return "fast"
+	}
+	return "slow"
+}
+`,
+		"main.go": `package main
+
+import (
+	"os"
+
+	"example.com/cached/config"
+)
+
+func main() {
+	mode := config.Mode(len(os.Args) > 1)
+	println(mode)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := &mapCache{m: make(map[string][]byte)}
+	ReportPackages(pkgs, ReportOptions{Summaries: cache})
+	if _, ok := cache.m["example.com/cached/config.Mode#0"]; !ok {
+		t.Fatalf("result of config.Mode not cached; have keys %v", slices.Sorted(maps.Keys(cache.m)))
+	}
+
+	// Analyzing the main package alone,
+	// the results of config.Mode come from the cache.
+	var main []*Package
+	for _, pkg := range pkgs {
+		if pkg.Path == "example.com/cached" {
+			main = append(main, pkg)
+		}
+	}
+	for _, c := range []struct {
+		name  string
+		cache SummaryCache
+		want  string
+	}{
+		{"uncached", nil, `mode [] false`},
+		{"cached", cache, `mode ["fast" "slow"] true`},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			reports := ReportPackages(main, ReportOptions{Summaries: c.cache})
+			var got []string
+			for _, e := range reports[0] {
+				if e.Kind == "var" {
+					got = append(got, fmt.Sprintf("%s %v %v", e.Name, e.Values, e.Complete))
+				}
+			}
+			if want := []string{c.want}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestReportPackagesGenerated(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/report\n\ngo 1.23\n",
+		"parser/parser.go": `// Code generated by goyacc -o parser.go parser.y. DO NOT EDIT.
+
+package parser
+
+//line parser.y:12
+func Token(num bool) string {
+	tok := "IDENT"
+	if num {
+		tok = "NUMBER"
+	}
+	return tok
+}
+`,
+		"main.go": `package main
+
+import (
+	"os"
+
+	"example.com/report/parser"
+)
+
+func main() {
+	tok := parser.Token(len(os.Args) > 1)
+	println(tok)
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := func(opts ReportOptions) []string {
+		var result []string
+		for i, pkg := range ReportPackages(pkgs, opts) {
+			for _, e := range pkg {
+				result = append(result, fmt.Sprintf("%s %s %s:%d %v %v %v", pkgs[i].Path, e.Name, filepath.Base(e.Pos.Filename), e.Pos.Line, e.Generated, e.Values, e.Complete))
+			}
+		}
+		return result
+	}
+
+	got := entries(ReportOptions{})
+	want := []string{
+		`example.com/report tok main.go:10 false ["IDENT" "NUMBER"] true`,
+		`example.com/report/parser 0 parser.y:12 true ["IDENT" "NUMBER"] true`,
+		`example.com/report/parser num parser.y:12 true [] false`,
+		`example.com/report/parser tok parser.y:13 true ["IDENT" "NUMBER"] true`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The results of functions in generated files are still known to their callers.
+	got = entries(ReportOptions{SkipGenerated: true})
+	want = want[:1]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("with SkipGenerated, got %q, want %q", got, want)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	_, info := loadTestFile(t, "testdata/format/format.go")
+	typeOf := func(name string) types.Type {
+		for ident, obj := range info.Defs {
+			if ident.Name == name {
+				return obj.Type()
+			}
+		}
+		t.Fatalf("no declaration of %s", name)
+		return nil
+	}
+
+	cases := []struct {
+		typ  string
+		val  constant.Value
+		want string
+	}{
+		{typ: "b", val: constant.MakeInt64('A'), want: `'A'`},
+		{typ: "b", val: constant.MakeInt64('\n'), want: `'\n'`},
+		{typ: "b", val: constant.MakeInt64(0xff), want: `'\xff'`},
+		{typ: "r", val: constant.MakeInt64('é'), want: `'é'`},
+		{typ: "r", val: constant.MakeInt64(0xd800), want: `55296`},
+		{typ: "r", val: constant.MakeInt64(-1), want: `-1`},
+		{typ: "d", val: constant.MakeInt64(','), want: `','`},
+		{typ: "u", val: constant.MakeInt64('A'), want: `65`},
+		{typ: "i", val: constant.MakeInt64('A'), want: `65`},
+		{typ: "s", val: constant.MakeString("A"), want: `"A"`},
+	}
+	for _, c := range cases {
+		typ := typeOf(c.typ)
+		if got := FormatValue(c.val, typ, FormatOptions{Chars: true}); got != c.want {
+			t.Errorf("%s of type %s: got %s, want %s", c.val.ExactString(), typ, got, c.want)
+		}
+		if got := FormatValue(c.val, typ, FormatOptions{}); got != c.val.ExactString() {
+			t.Errorf("%s of type %s without options: got %s", c.val.ExactString(), typ, got)
+		}
+	}
+}
+
+func TestArgValues(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/audit\n\ngo 1.23\n",
+		"db/db.go": `package db
+
+type DB struct{}
+
+func (*DB) Query(q string, args ...any) {}
+
+func (*DB) QueryRow(q string, args ...any) {}
+
+func Open(dsn string) *DB { return &DB{} }
+`,
+		"users/users.go": `package users
+
+import "example.com/audit/db"
+
+const table = "users"
+
+func Count(d *db.DB, active bool) {
+	const base = "SELECT count(*) FROM " + table
+	q := base
+	if active {
+		q = base + " WHERE active"
+	}
+	d.Query(q)
+}
+`,
+		"main.go": `package main
+
+import (
+	"os"
+
+	"example.com/audit/db"
+	"example.com/audit/users"
+)
+
+func main() {
+	d := db.Open(os.Getenv("DSN"))
+	users.Count(d, len(os.Args) > 1)
+	d.QueryRow("SELECT 1")
+}
+`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := ArgValues("(*example.com/audit/db.DB).Query...", 0, pkgs)
+	var calls []string
+	for _, c := range res.Calls {
+		calls = append(calls, fmt.Sprintf("%s:%d %s", filepath.Base(c.Pos.Filename), c.Pos.Line, types.ExprString(c.Call.Fun)))
+	}
+	if want := []string{"main.go:13 d.QueryRow", "users.go:13 d.Query"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+	want := []string{`"SELECT 1"`, `"SELECT count(*) FROM users WHERE active"`, `"SELECT count(*) FROM users"`}
+	if got := exactStrings(res.Values); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !res.Complete {
+		t.Error("got incomplete result")
+	}
+
+	// The DSN comes from the environment.
+	res = ArgValues("example.com/audit/db.Open", 0, pkgs)
+	if len(res.Calls) != 1 || res.Complete {
+		t.Errorf("got %d calls, complete = %v; want 1 call, incomplete", len(res.Calls), res.Complete)
+	}
+}
+
+func TestSurvey(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/survey\n\ngo 1.23\n",
+		"main.go": `package main
+
+import "os"
+
+func main() {
+	mode := "fast"
+	if len(os.Args) > 1 {
+		mode = "slow"
+	}
+	n := 0
+	n++
+	println(mode, n)
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, e := range Survey(pkgs) {
+		got[e.Kind] = fmt.Sprintf("%d %d %d %s", e.Count, e.Examined, e.Punted, e.Support)
+	}
+	want := map[string]string{
+		"AssignStmt": "3 3 0 full",
+		"IncDecStmt": "1 1 1 none",
+		"IfStmt":     "1 0 0 unexamined",
+	}
+	for kind, w := range want {
+		if got[kind] != w {
+			t.Errorf("%s: got %q, want %q", kind, got[kind], w)
+		}
+	}
+}
+
+func TestEvalInPackage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/eval\n\ngo 1.23\n",
+		"main.go": `package main
+
+import "time"
+
+const prefix = "mode-"
+
+func mode(fast bool) string {
+	if fast {
+		return prefix + "fast"
+	}
+	return "slow"
+}
+
+func main() {
+	time.Sleep(time.Second)
+	println(mode(len(prefix) > 1))
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs[0]
+	ntypes := len(pkg.Info.Types)
+
+	cases := []struct {
+		expr string
+		want []string
+	}{{
+		expr: `prefix + "suffix"`,
+		want: []string{`"mode-suffix"`},
+	}, {
+		expr: `mode(true)`,
+		want: []string{`"mode-fast"`, `"slow"`},
+	}, {
+		expr: `int64(2 * time.Second)`,
+		want: []string{`2000000000`},
+	}}
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			res, err := EvalInPackage(pkg, c.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := exactStrings(res.Values); !reflect.DeepEqual(got, c.want) || !res.Complete {
+				t.Errorf("got %v (complete = %v), want %v (complete)", got, res.Complete, c.want)
+			}
+		})
+	}
+
+	if _, err := EvalInPackage(pkg, "undefined + 1"); err == nil {
+		t.Error("got no error for an undefined name")
+	}
+	if _, err := EvalInPackage(pkg, "prefix +"); err == nil {
+		t.Error("got no error for a syntax error")
+	}
+
+	if len(pkg.Info.Types) != ntypes {
+		t.Errorf("type information of the package changed")
+	}
+}
+
+func TestScanDeps(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/deps\n\ngo 1.23\n",
+		"consts.go": `package main
+
+const prefix = "mode-"
+`,
+		"helper.go": `package main
+
+import "cmp"
+
+func pick(fast bool) string {
+	if fast {
+		return prefix + "fast"
+	}
+	return cmp.Or("", "slow")
+}
+`,
+		"other.go": `package main
+
+func unrelated() string { return "x" }
+`,
+		"main.go": `package main
+
+import "os"
+
+func main() {
+	mode := pick(len(os.Args) > 1)
+	println(mode, unrelated())
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgs, err := LoadPackages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs[0]
+
+	var mode *ast.Ident
+	for _, file := range pkg.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && ident.Name == "mode" && pkg.Info.Uses[ident] != nil {
+				mode = ident
+			}
+			return true
+		})
+	}
+	if mode == nil {
+		t.Fatal("no use of mode found")
+	}
+
+	s := &Scanner{Files: pkg.Files, Info: pkg.Info}
+	d := s.ScanDeps(mode)
+	if want := []string{`"mode-fast"`, `"slow"`}; !reflect.DeepEqual(exactStrings(d.Values), want) || !d.Complete {
+		t.Errorf("got %v (complete = %v), want %v (complete)", exactStrings(d.Values), d.Complete, want)
+	}
+
+	var gotFiles []string
+	for _, file := range d.Files {
+		gotFiles = append(gotFiles, filepath.Base(pkg.Fset.Position(file.Pos()).Filename))
+	}
+	slices.Sort(gotFiles)
+	if want := []string{"consts.go", "helper.go", "main.go"}; !reflect.DeepEqual(gotFiles, want) {
+		t.Errorf("got files %v, want %v", gotFiles, want)
+	}
+
+	var gotFuncs []string
+	for _, fn := range d.Funcs {
+		gotFuncs = append(gotFuncs, fn.FullName())
+	}
+	if want := []string{"cmp.Or", "example.com/deps.pick"}; !reflect.DeepEqual(gotFuncs, want) {
+		t.Errorf("got funcs %v, want %v", gotFuncs, want)
+	}
+
+	var gotPkgs []string
+	for _, p := range d.Packages {
+		gotPkgs = append(gotPkgs, p.Path())
+	}
+	if want := []string{"cmp", "example.com/deps"}; !reflect.DeepEqual(gotPkgs, want) {
+		t.Errorf("got packages %v, want %v", gotPkgs, want)
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	vals := []constant.Value{
+		constant.MakeBool(true),
+		constant.MakeString("a \"quoted\"\nline"),
+		constant.MakeInt64(-42),
+		constant.MakeFromLiteral("123456789012345678901234567890", token.INT, 0),
+		constant.MakeFloat64(-1.5),
+		constant.BinaryOp(constant.MakeInt64(1), token.QUO, constant.MakeInt64(3)),
+		constant.BinaryOp(constant.MakeFloat64(2), token.ADD, constant.MakeImag(constant.MakeFloat64(0.25))),
+	}
+	for _, v := range vals {
+		enc := encodeValue(v)
+		got, err := decodeValue(enc)
+		if err != nil {
+			t.Errorf("decoding %q: %s", enc, err)
+			continue
+		}
+		if got.Kind() != v.Kind() || !constant.Compare(got, token.EQL, v) {
+			t.Errorf("%s encoded as %q decodes to %s", v.ExactString(), enc, got.ExactString())
+		}
+	}
+}
+
+func TestDetailRuleMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"github.com/...", "github.com/samber/lo", true},
+		{"github.com/...", "golang.org/x/tools", false},
+		{"example.com/mod/...", "example.com/mod", true},
+		{"example.com/mod/...", "example.com/modx", false},
+		{"example.com/.../internal", "example.com/a/b/internal", true},
+		{"example.com/mod", "example.com/mod/sub", false},
+	}
+	for _, c := range cases {
+		if got := (DetailRule{Pattern: c.pattern}).match(c.path); got != c.want {
+			t.Errorf("%q matching %q: got %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	cases := map[string]string{
+		"github.com/samber/lo":                        "github.com/samber/lo",
+		"example.com/mod/vendor/github.com/samber/lo": "github.com/samber/lo",
+		"vendor/golang.org/x/net/http2/hpack":         "golang.org/x/net/http2/hpack",
+		"example.com/vendored":                        "example.com/vendored",
+	}
+	for path, want := range cases {
+		if got := canonicalPath(path); got != want {
+			t.Errorf("canonicalPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func BenchmarkRunCorpus(b *testing.B) {
+	sel := func(expr ast.Expr, info *types.Info) bool {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		_, isVar := info.Uses[ident].(*types.Var)
+		return isVar
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := RunCorpus(".", sel); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// testImporter is shared among tests so that imported packages are loaded only once.
+var testImporter = importer.Default()
+
+// findResult finds the last single-value return statement in function f
+// and returns its result expression.
+func findResult(t *testing.T, file *ast.File) ast.Expr {
+	t.Helper()
+
+	var expr ast.Expr
+	for _, decl := range file.Decls {
+		fdecl, ok := decl.(*ast.FuncDecl)
+		if !ok || fdecl.Name.Name != "f" {
+			continue
+		}
+		ast.Inspect(fdecl.Body, func(n ast.Node) bool {
+			if ret, ok := n.(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+				expr = ret.Results[0]
+			}
+			return true
+		})
+	}
+	if expr == nil {
+		t.Fatal("no single-value return statement found in f")
+	}
+	return expr
+}
+
+// loadTestFile parses and type-checks the given file from testdataFS.
+func loadTestFile(t *testing.T, filename string) (*ast.File, *types.Info) {
+	t.Helper()
+
+	src, err := testdataFS.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Base(filename), src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Defs:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Instances:  make(map[*ast.Ident]types.Instance),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Uses:       make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: testImporter}
+	if _, err := conf.Check("test", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+	return file, info
+}
+
 //go:embed testdata/*
 var testdataFS embed.FS
@@ -0,0 +1,919 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// A field path is a sequence of field indexes,
+// as in [types.Selection.Index],
+// selecting a (possibly nested, possibly embedded) field of a struct.
+// Pointers along the way are implicitly dereferenced.
+
+// fieldsKey identifies the field of a variable in query.active.
+type fieldsKey struct {
+	v    *types.Var
+	path string
+}
+
+// callFieldsKey identifies the field of a result of a call in query.active.
+type callFieldsKey struct {
+	call *ast.CallExpr
+	idx  int
+	path string
+}
+
+// fieldFuncsKey identifies the function values of the field of a variable in query.active.
+type fieldFuncsKey struct {
+	v    *types.Var
+	path string
+}
+
+// storesKey identifies, in query.active,
+// a method call whose body is being searched for stores
+// to a field of its receiver.
+type storesKey struct {
+	call *ast.CallExpr
+	path string
+}
+
+// argStoresKey identifies, in query.active,
+// a call whose body is being searched for stores
+// to a field of one of its pointer arguments.
+type argStoresKey struct {
+	call *ast.CallExpr
+	arg  int
+	path string
+}
+
+// aliasStoresKey identifies, in query.active,
+// a pointer variable that is searched for stores
+// to a field of the value it points to,
+// because it is a copy of another pointer.
+type aliasStoresKey struct {
+	v    *types.Var
+	path string
+}
+
+func pathKey(path []int) string {
+	return fmt.Sprint(path)
+}
+
+// scanField determines the possible values of the field at path in the struct x,
+// or in the struct x points to.
+// The values may come from composite literals,
+// from stores to the field,
+// from function and method results,
+// (following chains of method calls, as in builder-style APIs)
+// from stores to the field of a method's receiver,
+// and (following chains of constructors, as in dependency-injection code)
+// from stores to the field of a pointer passed to a function.
+func (q *query) scanField(x ast.Expr, path []int) (map[string]constant.Value, bool) {
+	if len(path) == 0 {
+		return q.scan(x)
+	}
+
+	x = ast.Unparen(x)
+
+	switch x := x.(type) {
+	case *ast.StarExpr:
+		return q.scanField(x.X, path)
+
+	case *ast.UnaryExpr:
+		if x.Op == token.AND {
+			return q.scanField(x.X, path)
+		}
+
+	case *ast.CompositeLit:
+		return q.scanCompositeField(x, path)
+
+	case *ast.SelectorExpr:
+		if sel, ok := q.info.Selections[x]; ok && sel.Kind() == types.FieldVal {
+			return q.scanField(x.X, slices.Concat(sel.Index(), path))
+		}
+
+	case *ast.Ident:
+		if tv, ok := q.info.Types[x]; ok && tv.IsNil() {
+			// Selecting a field through a nil pointer panics,
+			// so there is no value.
+			return map[string]constant.Value{}, true
+		}
+		if v, ok := q.info.ObjectOf(x).(*types.Var); ok {
+			if leaf := q.fieldVars[v.Origin()][pathKey(path)]; leaf != nil {
+				if n, ok := q.narrowed[leaf]; ok {
+					return cloneVals(n.vals), n.complete
+				}
+			}
+			return q.nested(x, "variable "+x.Name, func() (map[string]constant.Value, bool) {
+				return q.scanVarField(v, path)
+			})
+		}
+
+	case *ast.CallExpr:
+		if tv, ok := q.info.Types[x.Fun]; ok && tv.IsType() && len(x.Args) == 1 {
+			// A conversion between struct types with the same fields.
+			return q.scanField(x.Args[0], path)
+		}
+		if isBuiltin(x, "new", q.info) && len(x.Args) == 1 {
+			if tv, ok := q.info.Types[x.Args[0]]; ok && tv.IsType() {
+				// A pointer to a new zero value.
+				return q.zeroField(x, tv.Type, path)
+			}
+			// A pointer to a new variable with the given value (since Go 1.26).
+			return q.scanField(x.Args[0], path)
+		}
+		return q.nested(x, "call to "+types.ExprString(x.Fun), func() (map[string]constant.Value, bool) {
+			return q.scanCallField(x, 0, path)
+		})
+	}
+
+	q.incomplete(x, "fields of %s are not tracked", types.ExprString(x))
+	return nil, false
+}
+
+// scanCompositeField determines the possible values of the field at path in a struct composite literal.
+// A field that the literal omits has the zero value.
+func (q *query) scanCompositeField(lit *ast.CompositeLit, path []int) (map[string]constant.Value, bool) {
+	st, ok := structOf(q.info.TypeOf(lit))
+	if !ok || path[0] >= st.NumFields() {
+		q.incomplete(lit, "unsupported composite literal of type %s", q.info.TypeOf(lit))
+		return nil, false
+	}
+	field := st.Field(path[0])
+
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == field.Name() {
+				return q.scanField(kv.Value, path[1:])
+			}
+			continue
+		}
+		if i == path[0] {
+			return q.scanField(elt, path[1:])
+		}
+	}
+
+	return q.zeroField(lit, field.Type(), path[1:])
+}
+
+// zeroField determines the value of the field at path in the zero value of typ.
+// If the path goes through a nil pointer,
+// there is no value (selecting the field would panic).
+func (q *query) zeroField(node ast.Node, typ types.Type, path []int) (map[string]constant.Value, bool) {
+	if len(path) == 0 {
+		zero, ok := zeroValue(typ)
+		if !ok {
+			q.incomplete(node, "zero value of %s is not a constant", typ)
+			return nil, false
+		}
+		return map[string]constant.Value{zero.ExactString(): zero}, true
+	}
+
+	if _, ok := typ.Underlying().(*types.Pointer); ok {
+		return map[string]constant.Value{}, true
+	}
+	st, ok := structOf(typ)
+	if !ok || path[0] >= st.NumFields() {
+		q.incomplete(node, "unsupported field of %s", typ)
+		return nil, false
+	}
+	return q.zeroField(node, st.Field(path[0]).Type(), path[1:])
+}
+
+// scanCallField determines the possible values of the field at path
+// in the idx'th result of call.
+func (q *query) scanCallField(call *ast.CallExpr, idx int, path []int) (map[string]constant.Value, bool) {
+	if s, ok := fieldSummaryFor(call, q.info); ok {
+		return s(q, call, idx, path)
+	}
+
+	key := callFieldsKey{call: call, idx: idx, path: pathKey(path)}
+	if !q.enter(key) {
+		return nil, true
+	}
+	defer q.leave(key)
+
+	callees, complete := q.callees(call.Fun)
+	if len(callees) == 0 && !complete {
+		return nil, false
+	}
+
+	result := make(map[string]constant.Value)
+	for _, c := range callees {
+		results := c.sig.Results()
+		if idx >= results.Len() {
+			q.incomplete(call, "%s has no result %d", types.ExprString(call.Fun), idx)
+			complete = false
+			continue
+		}
+
+		q.frames = append(q.frames, newFrame(call, call.Fun, c.sig, len(q.frames), q.info))
+		q.forEachReturn(c.body, results.Len(), idx, func(_ *ast.ReturnStmt, expr ast.Expr, inner *ast.CallExpr) {
+			var (
+				vals map[string]constant.Value
+				ok   bool
+			)
+			switch {
+			case expr != nil:
+				vals, ok = q.scanField(expr, path)
+			case inner != nil:
+				vals, ok = q.scanCallField(inner, idx, path)
+			default:
+				// A bare return of a named result.
+				vals, ok = q.scanVarField(results.At(idx), path)
+			}
+			union(result, vals)
+			complete = complete && ok
+		})
+		q.frames = q.frames[:len(q.frames)-1]
+	}
+
+	return result, complete
+}
+
+// scanVarField determines the possible values of the field at path in v,
+// which is a struct or a pointer to one.
+func (q *query) scanVarField(v *types.Var, path []int) (map[string]constant.Value, bool) {
+	v = v.Origin()
+
+	key := fieldsKey{v: v, path: pathKey(path)}
+	if !q.enter(key) {
+		return nil, true
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		return nil, false
+	}
+	q.auditVar(v)
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+	add := func(vals map[string]constant.Value, ok bool) {
+		union(result, vals)
+		complete = complete && ok
+	}
+
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.fieldArg(v); ok {
+			q.inFrames(depth, func() { add(q.scanField(arg, path)) })
+		} else {
+			q.incomplete(node, "%s is a parameter and its argument is unknown", v.Name())
+			complete = false
+		}
+	}
+
+	q.fieldStores(node, v, path, false, add)
+
+	return result, complete
+}
+
+// fieldCallees determines the function bodies that the field at path
+// in the struct x,
+// or in the struct x points to,
+// may hold,
+// as for the handlers in a table or router:
+//
+//	r := router{handle: serveIndex}
+//	r.handle(req)
+//
+// The functions may come from composite literals
+// and from stores to the field.
+func (q *query) fieldCallees(x ast.Expr, path []int) ([]callee, bool) {
+	x = ast.Unparen(x)
+
+	switch x := x.(type) {
+	case *ast.SelectorExpr:
+		if sel, ok := q.info.Selections[x]; ok && sel.Kind() == types.FieldVal {
+			return q.fieldCallees(x.X, slices.Concat(sel.Index(), path))
+		}
+	}
+	if len(path) == 0 {
+		return q.callees(x)
+	}
+
+	switch x := x.(type) {
+	case *ast.StarExpr:
+		return q.fieldCallees(x.X, path)
+
+	case *ast.UnaryExpr:
+		if x.Op == token.AND {
+			return q.fieldCallees(x.X, path)
+		}
+
+	case *ast.CompositeLit:
+		st, ok := structOf(q.info.TypeOf(x))
+		if !ok || path[0] >= st.NumFields() {
+			break
+		}
+		field := st.Field(path[0])
+		for i, elt := range x.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if key, ok := kv.Key.(*ast.Ident); ok && key.Name == field.Name() {
+					return q.fieldCallees(kv.Value, path[1:])
+				}
+				continue
+			}
+			if i == path[0] {
+				return q.fieldCallees(elt, path[1:])
+			}
+		}
+		// An omitted field is nil,
+		// and so is any function within it,
+		// which can't be called successfully.
+		return nil, true
+
+	case *ast.Ident:
+		if tv, ok := q.info.Types[x]; ok && tv.IsNil() {
+			return nil, true
+		}
+		if v, ok := q.info.ObjectOf(x).(*types.Var); ok {
+			return q.varFieldCallees(v, path)
+		}
+	}
+
+	q.incomplete(x, "function values in the fields of %s are not tracked", types.ExprString(x))
+	return nil, false
+}
+
+// varFieldCallees determines the function bodies that the field at path in v,
+// which is a struct or a pointer to one,
+// may hold.
+// Uses of v that might let the field change unseen,
+// as in [query.scanVarField],
+// make the result incomplete.
+func (q *query) varFieldCallees(v *types.Var, path []int) ([]callee, bool) {
+	v = v.Origin()
+
+	key := fieldFuncsKey{v: v, path: pathKey(path)}
+	if !q.enter(key) {
+		return nil, true
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		return nil, false
+	}
+
+	var (
+		result   []callee
+		complete = true
+	)
+	add := func(callees []callee, ok bool) {
+		result = append(result, callees...)
+		complete = complete && ok
+	}
+
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.fieldArg(v); ok {
+			q.inFrames(depth, func() { add(q.fieldCallees(arg, path)) })
+		} else {
+			q.incomplete(node, "%s is a parameter and its argument is unknown", v.Name())
+			complete = false
+		}
+	}
+
+	_, isPtr := v.Type().Underlying().(*types.Pointer)
+
+	inspectWithStack(node, func(n ast.Node, stack []ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				storePath, ok := q.fieldPathOf(lhs, v)
+				if !ok || !hasPrefix(path, storePath) {
+					continue
+				}
+				if (n.Tok != token.ASSIGN && n.Tok != token.DEFINE) || len(n.Lhs) != len(n.Rhs) {
+					q.incomplete(n, "unsupported assignment to %s", types.ExprString(lhs))
+					complete = false
+					continue
+				}
+				add(q.fieldCallees(n.Rhs[i], path[len(storePath):]))
+			}
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					// The zero value holds no functions.
+				case len(n.Names):
+					add(q.fieldCallees(n.Values[i], path))
+				default:
+					q.incomplete(n, "unsupported declaration of %s", v.Name())
+					complete = false
+				}
+			}
+
+		case *ast.Ident:
+			if !identIsVar(n, v, q.info) || q.info.Defs[n] != nil {
+				return true
+			}
+			// Stores found by following methods and functions
+			// are of values that can't be scanned as constants,
+			// and so make the result incomplete,
+			// as do uses that might let the field change unseen.
+			q.checkFieldUse(n, stack, v, isPtr, path, func(_ map[string]constant.Value, ok bool) {
+				complete = complete && ok
+			})
+		}
+		return true
+	})
+
+	return result, complete
+}
+
+// fieldArg is like [query.paramArg],
+// but also resolves a method's receiver,
+// including a pointer receiver,
+// to the expression it is selected from.
+func (q *query) fieldArg(v *types.Var) (ast.Expr, int, bool) {
+	if arg, depth, ok := q.paramArg(v); ok {
+		return arg, depth, true
+	}
+	for i := len(q.frames) - 1; i >= 0; i-- {
+		if fr := q.frames[i]; fr.recv == v {
+			return fr.recvX, fr.depth, true
+		}
+	}
+	return nil, 0, false
+}
+
+// fieldStores finds the values stored in node to the field at path in v
+// and passes them to add.
+// Unless storesOnly is true,
+// whole values assigned to v (and their fields at path) are included too.
+//
+// Stores made by methods called on v are followed.
+// Uses of v that might let the field change unseen,
+// such as taking its address,
+// make the result incomplete.
+func (q *query) fieldStores(node ast.Node, v *types.Var, path []int, storesOnly bool, add func(map[string]constant.Value, bool)) {
+	_, isPtr := v.Type().Underlying().(*types.Pointer)
+
+	inspectWithStack(node, func(n ast.Node, stack []ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				storePath, ok := q.fieldPathOf(lhs, v)
+				if !ok {
+					continue
+				}
+				if _, isIdent := ast.Unparen(lhs).(*ast.Ident); isIdent && storesOnly {
+					// Assigning the receiver itself does not affect the caller.
+					continue
+				}
+				if !hasPrefix(path, storePath) {
+					continue
+				}
+				if n.Tok != token.ASSIGN && n.Tok != token.DEFINE {
+					q.incomplete(n, "unsupported assignment to %s", types.ExprString(lhs))
+					add(nil, false)
+					continue
+				}
+				if len(n.Lhs) == len(n.Rhs) {
+					add(q.scanField(n.Rhs[i], path[len(storePath):]))
+					continue
+				}
+				// A multi-valued call, as in u, err := url.Parse(s).
+				call, ok := ast.Unparen(n.Rhs[0]).(*ast.CallExpr)
+				if !ok || len(n.Rhs) != 1 {
+					q.incomplete(n, "unsupported assignment to %s", types.ExprString(lhs))
+					add(nil, false)
+					continue
+				}
+				add(q.nested(call, "call to "+types.ExprString(call.Fun), func() (map[string]constant.Value, bool) {
+					return q.scanCallField(call, i, path[len(storePath):])
+				}))
+			}
+
+		case *ast.IncDecStmt:
+			if storePath, ok := q.fieldPathOf(n.X, v); ok && hasPrefix(path, storePath) {
+				q.incomplete(n, "unsupported %s of %s", n.Tok, types.ExprString(n.X))
+				add(nil, false)
+			}
+
+		case *ast.ValueSpec:
+			if storesOnly {
+				return true
+			}
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					add(q.zeroField(n, v.Type(), path))
+				case len(n.Names):
+					add(q.scanField(n.Values[i], path))
+				case 1:
+					call, ok := ast.Unparen(n.Values[0]).(*ast.CallExpr)
+					if !ok {
+						q.incomplete(n, "unsupported declaration of %s", v.Name())
+						add(nil, false)
+						continue
+					}
+					add(q.nested(call, "call to "+types.ExprString(call.Fun), func() (map[string]constant.Value, bool) {
+						return q.scanCallField(call, i, path)
+					}))
+				default:
+					q.incomplete(n, "unsupported declaration of %s", v.Name())
+					add(nil, false)
+				}
+			}
+
+		case *ast.Ident:
+			if !identIsVar(n, v, q.info) || q.info.Defs[n] != nil {
+				return true
+			}
+			q.checkFieldUse(n, stack, v, isPtr, path, add)
+		}
+		return true
+	})
+}
+
+// checkFieldUse examines a use of v,
+// with ancestors in stack,
+// for its effect on the field at path.
+// Calls of methods with pointer receivers are searched for stores to the field.
+// Uses that might let the field change unseen make the result incomplete.
+func (q *query) checkFieldUse(ident *ast.Ident, stack []ast.Node, v *types.Var, isPtr bool, path []int, add func(map[string]constant.Value, bool)) {
+	unsafe := func(node ast.Node, format string, args ...any) {
+		q.incomplete(node, format, args...)
+		add(nil, false)
+	}
+
+	parent, child, ancestors := parentOf(ident, stack)
+
+	// Find the outermost field selection based on v.
+	for {
+		sel, ok := parent.(*ast.SelectorExpr)
+		if !ok || sel.X != child {
+			break
+		}
+		selection, ok := q.info.Selections[sel]
+		if !ok || selection.Kind() != types.FieldVal {
+			break
+		}
+		parent, child, ancestors = parentOf(sel, ancestors)
+	}
+
+	switch parent := parent.(type) {
+	case *ast.SelectorExpr:
+		// A method call (or method value).
+		selection, ok := q.info.Selections[parent]
+		if !ok || selection.Kind() != types.MethodVal {
+			unsafe(parent, "unsupported use of %s", types.ExprString(parent))
+			return
+		}
+		method, ok := selection.Obj().(*types.Func)
+		if !ok || method.Signature().Recv() == nil {
+			return
+		}
+		if _, ptrRecv := method.Signature().Recv().Type().Underlying().(*types.Pointer); !ptrRecv {
+			// The method gets a copy.
+			return
+		}
+		if child != ident {
+			unsafe(parent, "method %s may change %s", types.ExprString(parent), types.ExprString(child.(ast.Expr)))
+			return
+		}
+		call, callParent, ok := ancestorCall(parent, ancestors)
+		if !ok {
+			unsafe(parent, "method value %s may change %s", types.ExprString(parent), v.Name())
+			return
+		}
+		if _, ok := summaryFor(call, q.info); ok {
+			// Summarized methods are taken not to change their receivers.
+			return
+		}
+		_, discarded := callParent.(*ast.ExprStmt)
+		q.methodStores(call, path, !discarded, add)
+
+	case *ast.StarExpr:
+		// Stores through *v are handled by the caller.
+		grand, _, _ := parentOf(parent, ancestors)
+		if u, ok := grand.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			unsafe(u, "address of *%s is taken", v.Name())
+		}
+
+	case *ast.UnaryExpr:
+		if parent.Op != token.AND {
+			return
+		}
+		if storePath, ok := q.fieldPathOf(child.(ast.Expr), v); ok && hasPrefix(path, storePath) && q.addrStores(parent, ancestors, path[len(storePath):], add) {
+			return
+		}
+		unsafe(parent, "address of %s is taken", types.ExprString(child.(ast.Expr)))
+
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == child {
+				// A store, handled by the caller.
+				return
+			}
+		}
+		if !isPtr || child != ident {
+			return
+		}
+		if i := slices.Index(parent.Rhs, child.(ast.Expr)); len(parent.Lhs) == len(parent.Rhs) && q.aliasStores(parent.Lhs[i], path, add) {
+			return
+		}
+		unsafe(parent, "%s is copied, making an alias", v.Name())
+
+	case *ast.ValueSpec:
+		if !isPtr || child != ident {
+			return
+		}
+		if i := slices.Index(parent.Values, child.(ast.Expr)); len(parent.Names) == len(parent.Values) && q.aliasStores(parent.Names[i], path, add) {
+			return
+		}
+		unsafe(parent, "%s is copied, making an alias", v.Name())
+
+	case *ast.ReturnStmt, *ast.BinaryExpr, *ast.IncDecStmt:
+		// Returning a pointer hands it to the caller,
+		// whose uses of it are tracked separately.
+
+	case *ast.CallExpr:
+		if !isPtr || child != ident {
+			return
+		}
+		i := slices.Index(parent.Args, child.(ast.Expr))
+		if i < 0 {
+			unsafe(ident, "%s is used in a way that may change it", v.Name())
+			return
+		}
+		// Passing a pointer to a function,
+		// as to a provider in dependency-injection code.
+		q.argStores(parent, i, path, add)
+
+	case *ast.KeyValueExpr, *ast.CompositeLit:
+		if isPtr && child == ident && !returnsLit(parent, ancestors) {
+			// Storing the pointer in a struct makes an alias,
+			// unless the struct is returned (as by a constructor),
+			// which hands the pointer to the caller like returning it.
+			unsafe(ident, "%s is used in a way that may change it", v.Name())
+		}
+
+	default:
+		if isPtr && child == ident {
+			unsafe(ident, "%s is used in a way that may change it", v.Name())
+		}
+	}
+}
+
+// aliasStores finds the stores to the field at path
+// through the pointer variable alias,
+// which is assigned a copy of another pointer,
+// and passes their values to add.
+// It reports false if alias is not a local variable,
+// so that the stores through it cannot be found.
+func (q *query) aliasStores(alias ast.Expr, path []int, add func(map[string]constant.Value, bool)) bool {
+	ident, ok := ast.Unparen(alias).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	if ident.Name == "_" {
+		return true
+	}
+	v, ok := q.info.ObjectOf(ident).(*types.Var)
+	if !ok || v.IsField() {
+		return false
+	}
+	v = v.Origin()
+	if v.Pkg() != nil && v.Parent() == v.Pkg().Scope() {
+		return false
+	}
+
+	key := aliasStoresKey{v: v, path: pathKey(path)}
+	if !q.enter(key) {
+		return true
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		return false
+	}
+	q.fieldStores(node, v, path, true, add)
+	return true
+}
+
+// addrStores finds the stores to the field at path
+// through addr,
+// an expression &x with ancestors in stack,
+// and passes their values to add.
+// The pointer may be assigned to a local variable
+// or passed to a function,
+// whose stores through it are followed.
+// It reports false if the pointer is used otherwise,
+// so that the stores through it cannot be found.
+func (q *query) addrStores(addr *ast.UnaryExpr, stack []ast.Node, path []int, add func(map[string]constant.Value, bool)) bool {
+	parent, child, ancestors := parentOf(addr, stack)
+	switch parent := parent.(type) {
+	case *ast.AssignStmt:
+		i := slices.Index(parent.Rhs, child.(ast.Expr))
+		return i >= 0 && len(parent.Lhs) == len(parent.Rhs) && q.aliasStores(parent.Lhs[i], path, add)
+
+	case *ast.ValueSpec:
+		i := slices.Index(parent.Values, child.(ast.Expr))
+		return i >= 0 && len(parent.Names) == len(parent.Values) && q.aliasStores(parent.Names[i], path, add)
+
+	case *ast.CallExpr:
+		i := slices.Index(parent.Args, child.(ast.Expr))
+		if i < 0 {
+			return false
+		}
+		if callParent, _, _ := parentOf(parent, ancestors); !isExprStmt(callParent) && returnsPointer(q.info.TypeOf(parent)) {
+			// The result may alias the pointer.
+			return false
+		}
+		q.argStores(parent, i, path, add)
+		return true
+	}
+	return false
+}
+
+// isExprStmt tells whether n is an expression statement.
+func isExprStmt(n ast.Node) bool {
+	_, ok := n.(*ast.ExprStmt)
+	return ok
+}
+
+// returnsPointer tells whether typ,
+// the type of a call,
+// is or includes a pointer.
+func returnsPointer(typ types.Type) bool {
+	if tuple, ok := typ.(*types.Tuple); ok {
+		for i := range tuple.Len() {
+			if returnsPointer(tuple.At(i).Type()) {
+				return true
+			}
+		}
+		return false
+	}
+	if typ == nil {
+		return false
+	}
+	_, ok := typ.Underlying().(*types.Pointer)
+	return ok
+}
+
+// methodStores finds the stores to the field at path in the receiver
+// of the method called by call
+// and passes their values to add.
+// If resultUsed is true and the method returns a pointer,
+// which may alias the receiver,
+// the result is incomplete.
+func (q *query) methodStores(call *ast.CallExpr, path []int, resultUsed bool, add func(map[string]constant.Value, bool)) {
+	key := storesKey{call: call, path: pathKey(path)}
+	if !q.enter(key) {
+		return
+	}
+	defer q.leave(key)
+
+	callees, ok := q.callees(call.Fun)
+	if !ok {
+		add(nil, false)
+	}
+
+	for _, c := range callees {
+		recv := c.sig.Recv()
+		if recv == nil {
+			continue
+		}
+		if results := c.sig.Results(); resultUsed && results.Len() > 0 {
+			if _, ok := results.At(0).Type().Underlying().(*types.Pointer); ok {
+				q.incomplete(call, "result of %s may alias its receiver", types.ExprString(call))
+				add(nil, false)
+			}
+		}
+
+		q.frames = append(q.frames, newFrame(call, call.Fun, c.sig, len(q.frames), q.info))
+		q.fieldStores(c.body, recv.Origin(), path, true, add)
+		q.frames = q.frames[:len(q.frames)-1]
+	}
+}
+
+// argStores finds the stores to the field at path
+// in the pointer that is argument i of call,
+// made by the function called,
+// and passes their values to add.
+// That follows pointers through constructor chains,
+// as in dependency-injection code
+// (such as the wire_gen.go files generated by wire),
+// where each provider receives the values made by the ones before it.
+func (q *query) argStores(call *ast.CallExpr, i int, path []int, add func(map[string]constant.Value, bool)) {
+	key := argStoresKey{call: call, arg: i, path: pathKey(path)}
+	if !q.enter(key) {
+		return
+	}
+	defer q.leave(key)
+
+	callees, ok := q.callees(call.Fun)
+	if !ok {
+		add(nil, false)
+	}
+
+	for _, c := range callees {
+		params := c.sig.Params()
+		if call.Ellipsis.IsValid() || (c.sig.Variadic() && i >= params.Len()-1) {
+			q.incomplete(call, "unsupported variadic call of %s", types.ExprString(call.Fun))
+			add(nil, false)
+			continue
+		}
+		if i >= params.Len() {
+			continue
+		}
+
+		q.frames = append(q.frames, newFrame(call, call.Fun, c.sig, len(q.frames), q.info))
+		q.fieldStores(c.body, params.At(i).Origin(), path, true, add)
+		q.frames = q.frames[:len(q.frames)-1]
+	}
+}
+
+// returnsLit tells whether node,
+// a composite literal or one of its key-value pairs with ancestors in stack,
+// is (or is in) a composite literal that is returned,
+// or whose address is returned.
+func returnsLit(node ast.Node, stack []ast.Node) bool {
+	if _, ok := node.(*ast.KeyValueExpr); ok {
+		node, _, stack = parentOf(node, stack)
+	}
+	if _, ok := node.(*ast.CompositeLit); !ok {
+		return false
+	}
+	parent, _, ancestors := parentOf(node, stack)
+	if u, ok := parent.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		parent, _, _ = parentOf(u, ancestors)
+	}
+	_, ok := parent.(*ast.ReturnStmt)
+	return ok
+}
+
+// ancestorCall returns the call of which fun is the function, if there is one,
+// and the parent of the call.
+func ancestorCall(fun ast.Expr, ancestors []ast.Node) (*ast.CallExpr, ast.Node, bool) {
+	parent, child, ancestors := parentOf(fun, ancestors)
+	call, ok := parent.(*ast.CallExpr)
+	if !ok || call.Fun != child {
+		return nil, nil, false
+	}
+	callParent, _, _ := parentOf(call, ancestors)
+	return call, callParent, true
+}
+
+// fieldPathOf tells whether expr selects a field of v
+// (or is v itself, or *v),
+// and if so returns the field path.
+func (q *query) fieldPathOf(expr ast.Expr, v *types.Var) ([]int, bool) {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		if identIsVar(expr, v, q.info) {
+			return nil, true
+		}
+
+	case *ast.StarExpr:
+		return q.fieldPathOf(expr.X, v)
+
+	case *ast.SelectorExpr:
+		sel, ok := q.info.Selections[expr]
+		if !ok || sel.Kind() != types.FieldVal {
+			return nil, false
+		}
+		base, ok := q.fieldPathOf(expr.X, v)
+		if !ok {
+			return nil, false
+		}
+		return slices.Concat(base, sel.Index()), true
+	}
+	return nil, false
+}
+
+// hasPrefix tells whether path begins with prefix.
+func hasPrefix(path, prefix []int) bool {
+	return len(prefix) <= len(path) && slices.Equal(path[:len(prefix)], prefix)
+}
+
+// structOf returns the struct type underlying typ,
+// or the type typ points to.
+func structOf(typ types.Type) (*types.Struct, bool) {
+	if typ == nil {
+		return nil, false
+	}
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	return st, ok
+}
@@ -0,0 +1,92 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/constant"
+	"go/types"
+	"strconv"
+	"unicode/utf8"
+)
+
+// FormatOptions configures [FormatValue].
+type FormatOptions struct {
+	// Chars renders the values of byte and rune types,
+	// and of types based on them,
+	// as quoted characters,
+	// as in 'A' and '\n',
+	// instead of as integers.
+	// This is easier to read when auditing code that deals in characters,
+	// such as parsers.
+	// A value that is not a valid character stays an integer.
+	Chars bool
+}
+
+// FormatValue renders v,
+// a value of type typ,
+// according to opts.
+// Without options,
+// or when none of them applies to typ,
+// the result is the ExactString representation of v.
+func FormatValue(v constant.Value, typ types.Type, opts FormatOptions) string {
+	if opts.Chars && v.Kind() == constant.Int && typ != nil {
+		if s, ok := formatChar(v, typ); ok {
+			return s
+		}
+	}
+	return v.ExactString()
+}
+
+var (
+	byteType = types.Universe.Lookup("byte").Type()
+	runeType = types.Universe.Lookup("rune").Type()
+)
+
+// formatChar renders v as a quoted character
+// if typ is a byte or rune type
+// and v is a valid value of it.
+func formatChar(v constant.Value, typ types.Type) (string, bool) {
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return "", false
+	}
+	n, exact := constant.Int64Val(v)
+	if !exact {
+		return "", false
+	}
+
+	switch {
+	case basic == byteType:
+		switch {
+		case n < 0 || n > 0xff:
+			return "", false
+		case n < utf8.RuneSelf:
+			return strconv.QuoteRune(rune(n)), true
+		default:
+			// A byte beyond ASCII is not a character by itself.
+			return fmt.Sprintf(`'\x%02x'`, n), true
+		}
+
+	case basic == runeType || basic.Kind() == types.UntypedRune:
+		if n < 0 || n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+			return "", false
+		}
+		return strconv.QuoteRune(rune(n)), true
+	}
+	return "", false
+}
+
+// formatValues renders vals,
+// values of type typ,
+// according to opts,
+// in the order of their ExactString representations.
+func formatValues(vals map[string]constant.Value, typ types.Type, opts FormatOptions) []string {
+	keys := exactStrings(vals)
+	if opts == (FormatOptions{}) {
+		return keys
+	}
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, FormatValue(vals[k], typ, opts))
+	}
+	return result
+}
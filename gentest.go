@@ -0,0 +1,178 @@
+package exprvals
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GenerateTest produces the source of a Go test
+// that locks in the possible values of result idx of fn:
+// it calls fn and fails if the result is not one of them.
+// Each value is a case of a switch statement,
+// with a comment telling where the value comes from
+// (see [Scanner.Explain]),
+// including its position in fset if fset is non-nil.
+// Values that are the same once converted to the result's type,
+// such as floating-point constants that round to the same float64,
+// share a case.
+//
+// The test belongs in fn's package.
+// Only functions without parameters or type parameters,
+// declared in the Scanner's files,
+// are supported,
+// and the values of the result must be complete.
+func (s *Scanner) GenerateTest(fset *token.FileSet, fn *types.Func, idx int) ([]byte, error) {
+	if missing := missingInfo(s.Info); missing != "" {
+		return nil, errors.New(missing)
+	}
+
+	sig := fn.Signature()
+	switch {
+	case sig.Recv() != nil:
+		return nil, fmt.Errorf("%s is a method", fn.Name())
+	case sig.TypeParams().Len() > 0:
+		return nil, fmt.Errorf("%s is generic", fn.Name())
+	case sig.Params().Len() > 0:
+		return nil, fmt.Errorf("%s has parameters", fn.Name())
+	case idx < 0 || idx >= sig.Results().Len():
+		return nil, fmt.Errorf("%s has no result %d", fn.Name(), idx)
+	}
+
+	decl, ok := findSmallestEnclosingNode(s.Files, fn.Scope()).(*ast.FuncDecl)
+	if !ok || decl.Body == nil {
+		return nil, fmt.Errorf("declaration of %s not found", fn.Name())
+	}
+
+	// Find the values, and the return statement each one comes from first.
+	var (
+		vals    = make(map[string]constant.Value)
+		sources = make(map[string]Result)
+	)
+	for _, res := range s.returnResults(decl, idx) {
+		if !res.Complete {
+			msg := fmt.Sprintf("values of %s are incomplete", fn.Name())
+			if res.Why != nil {
+				msg += ":\n" + res.Why.Format(fset)
+			}
+			return nil, errors.New(msg)
+		}
+		for k, v := range res.Values {
+			if _, ok := vals[k]; !ok {
+				vals[k] = v
+				sources[k] = res
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "package %s\n\n", fn.Pkg().Name())
+	fmt.Fprintf(buf, "import \"testing\"\n\n")
+	fmt.Fprintf(buf, "// %s checks that %s returns only the values found for it by exprvals.\n", testName(fn, idx), fn.Name())
+	fmt.Fprintf(buf, "func %s(t *testing.T) {\n", testName(fn, idx))
+
+	lhs := make([]string, sig.Results().Len())
+	for i := range lhs {
+		lhs[i] = "_"
+	}
+	lhs[idx] = "got"
+	fmt.Fprintf(buf, "%s := %s()\n", strings.Join(lhs, ", "), fn.Name())
+
+	fmt.Fprintf(buf, "switch got {\n")
+	written := make(map[string]bool)
+	for _, k := range exactStrings(vals) {
+		lit, ok := valueLiteral(vals[k], sig.Results().At(idx).Type())
+		if !ok {
+			return nil, fmt.Errorf("cannot write %s as a literal", k)
+		}
+		if written[lit] {
+			// A duplicate case would not compile.
+			continue
+		}
+		written[lit] = true
+		fmt.Fprintf(buf, "case %s:", lit)
+		if d := s.Explain(sources[k], vals[k]); d != nil {
+			if p := provenance(fset, d); p != "" {
+				fmt.Fprintf(buf, " // %s", p)
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(buf, "default:\n")
+	fmt.Fprintf(buf, "t.Errorf(\"%s() = %%v, want one of the values found by exprvals\", got)\n", fn.Name())
+	fmt.Fprintf(buf, "}\n}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// testName is the name of the test that [Scanner.GenerateTest] produces
+// for result idx of fn.
+func testName(fn *types.Func, idx int) string {
+	name := fn.Name()
+	r, size := utf8.DecodeRuneInString(name)
+	name = string(unicode.ToUpper(r)) + name[size:]
+	if fn.Signature().Results().Len() > 1 {
+		name += strconv.Itoa(idx)
+	}
+	return "Test" + name + "Values"
+}
+
+// provenance describes where the value in d comes from:
+// the position of the last step (if fset is non-nil),
+// and the steps in between,
+// as in "config.go:12:10, via call to pick, variable s".
+func provenance(fset *token.FileSet, d *Derivation) string {
+	var parts []string
+
+	if last := d.Steps[len(d.Steps)-1]; fset != nil && last.Node.Pos().IsValid() {
+		pos := fset.Position(last.Node.Pos())
+		pos.Filename = filepath.Base(pos.Filename)
+		parts = append(parts, pos.String())
+	}
+	if len(d.Steps) > 2 {
+		var via []string
+		for _, step := range d.Steps[1 : len(d.Steps)-1] {
+			via = append(via, step.Msg)
+		}
+		parts = append(parts, "via "+strings.Join(via, ", "))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// valueLiteral renders v as a Go literal
+// for a value of type typ.
+// A floating-point value is rounded to the precision of typ, as the compiler would,
+// so that values the same in typ have the same literal.
+func valueLiteral(v constant.Value, typ types.Type) (string, bool) {
+	if basic, ok := typ.Underlying().(*types.Basic); ok && basic.Info()&types.IsFloat != 0 {
+		f, _ := constant.Float64Val(constant.ToFloat(v))
+		bits := 64
+		if basic.Kind() == types.Float32 {
+			bits = 32
+		}
+		return strconv.FormatFloat(f, 'g', -1, bits), true
+	}
+
+	switch v.Kind() {
+	case constant.String, constant.Bool, constant.Int:
+		return v.ExactString(), true
+	case constant.Float:
+		f, exact := constant.Float64Val(v)
+		if !exact {
+			return "", false
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	}
+	return "", false
+}
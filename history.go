@@ -0,0 +1,149 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+)
+
+// When tells when a variable may have a value,
+// relative to a point in its function.
+// See [Scanner.History].
+type When int
+
+const (
+	// WhenAt means the variable may have the value at the point.
+	WhenAt When = iota
+
+	// WhenBefore means the variable may have the value
+	// only at statements before the point
+	// (in source order),
+	// having been reassigned by the time it is reached.
+	WhenBefore
+
+	// WhenAfter means the variable may have the value
+	// only once the point is passed.
+	WhenAfter
+)
+
+func (w When) String() string {
+	switch w {
+	case WhenAt:
+		return "at"
+	case WhenBefore:
+		return "before"
+	case WhenAfter:
+		return "after"
+	}
+	return fmt.Sprintf("When(%d)", int(w))
+}
+
+// History is the outcome of [Scanner.History].
+type History struct {
+	// Result holds the values the variable may have at any time,
+	// as found by [Scanner.Scan].
+	Result
+
+	// When tells, for each of the Values (by the same keys),
+	// when the variable may have it
+	// relative to the point of the query.
+	When map[string]When
+}
+
+// History finds the values that the local variable used at ident
+// may have over its whole lifetime,
+// as [Scanner.Scan] does,
+// and tells for each whether the variable may have it at ident,
+// only before,
+// or only after,
+// according to [Scanner.AnalyzeFunc].
+// Some uses want the whole history of a variable,
+// such as inferring the members of an enum from the values it takes,
+// while others want only the values at one point;
+// History serves both.
+//
+// A value that [Scanner.AnalyzeFunc] finds at ident
+// but Scan does not
+// (as for a parameter with values from s.Assume)
+// is included.
+// When the values at ident cannot be followed statement by statement
+// (for instance, because ident is in a function literal),
+// all the values are [WhenAt].
+func (s *Scanner) History(ident *ast.Ident) History {
+	res := s.Scan(ident)
+	hist := History{Result: res, When: make(map[string]When)}
+	for k := range res.Values {
+		hist.When[k] = WhenAt
+	}
+
+	v, ok := s.Info.Uses[ident].(*types.Var)
+	if !ok {
+		return hist
+	}
+
+	// Find the innermost statement containing ident,
+	// and the function declaration containing that.
+	var (
+		stmt ast.Stmt
+		fn   *types.Func
+	)
+	path := pathTo(s.Files, ident)
+	for i := len(path) - 1; i >= 0 && fn == nil; i-- {
+		switch n := path[i].(type) {
+		case *ast.FuncLit:
+			return hist
+		case *ast.FuncDecl:
+			fn, _ = s.Info.Defs[n.Name].(*types.Func)
+		case ast.Stmt:
+			if stmt == nil {
+				stmt = n
+			}
+		}
+	}
+	if stmt == nil || fn == nil {
+		return hist
+	}
+	fv := s.AnalyzeFunc(fn)
+	if fv == nil {
+		return hist
+	}
+
+	var at *Result
+	for _, point := range fv.Points {
+		if point.Stmt != stmt {
+			continue
+		}
+		if r, ok := point.Values[v]; ok {
+			at = &r
+		}
+	}
+	if at == nil {
+		return hist
+	}
+
+	hist.Values = cloneVals(hist.Values)
+	for k, val := range at.Values {
+		if _, ok := hist.Values[k]; !ok {
+			hist.Values[k] = val
+		}
+	}
+
+	for k := range hist.Values {
+		if _, ok := at.Values[k]; ok {
+			hist.When[k] = WhenAt
+			continue
+		}
+		hist.When[k] = WhenAfter
+		for _, point := range fv.Points {
+			if point.Stmt.End() > stmt.Pos() {
+				continue
+			}
+			if _, ok := point.Values[v].Values[k]; ok {
+				hist.When[k] = WhenBefore
+				break
+			}
+		}
+	}
+
+	return hist
+}
@@ -0,0 +1,92 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// TypeInfo type-checks files,
+// which make up the package pkg,
+// and returns the type information needed for scanning them.
+// The packages that pkg imports are used as they are,
+// so only pkg's own files are type-checked.
+// This is for callers that have the syntax and types of a package
+// but not its [types.Info]
+// (e.g. from go/packages with NeedSyntax|NeedTypes).
+//
+// The objects in the result belong to a new package,
+// not to pkg itself.
+func TypeInfo(fset *token.FileSet, files []*ast.File, pkg *types.Package) (*types.Info, error) {
+	imports := make(map[string]*types.Package)
+	for _, imp := range pkg.Imports() {
+		imports[imp.Path()] = imp
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if imp, ok := imports[path]; ok {
+				return imp, nil
+			}
+			return nil, fmt.Errorf("%s is not imported by %s", path, pkg.Path())
+		}),
+	}
+
+	info := newInfo()
+	if _, err := conf.Check(pkg.Path(), fset, files, info); err != nil {
+		return nil, fmt.Errorf("type-checking %s: %w", pkg.Path(), err)
+	}
+	return info, nil
+}
+
+// newInfo returns a [types.Info] with all the maps that scanning uses.
+func newInfo() *types.Info {
+	return &types.Info{
+		Defs:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Instances:  make(map[*ast.Ident]types.Instance),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Uses:       make(map[*ast.Ident]types.Object),
+	}
+}
+
+// missingInfo describes the type information that scanning requires but info lacks.
+// It returns "" if nothing is missing.
+func missingInfo(info *types.Info) string {
+	if info == nil {
+		return "no type information"
+	}
+	var missing []string
+	if info.Types == nil {
+		missing = append(missing, "Types")
+	}
+	if info.Defs == nil {
+		missing = append(missing, "Defs")
+	}
+	if info.Uses == nil {
+		missing = append(missing, "Uses")
+	}
+	if len(missing) == 0 {
+		return ""
+	}
+	return "type information lacks " + strings.Join(missing, ", ")
+}
+
+// underlying is like [types.Type.Underlying],
+// but returns nil for a nil type,
+// such as the type of an expression missing from the type information.
+func underlying(typ types.Type) types.Type {
+	if typ == nil {
+		return nil
+	}
+	return typ.Underlying()
+}
+
+// isInterface is like [types.IsInterface],
+// but returns false for a nil type.
+func isInterface(typ types.Type) bool {
+	return typ != nil && types.IsInterface(typ)
+}
@@ -0,0 +1,54 @@
+package exprvals
+
+import (
+	"go/constant"
+	"go/types"
+)
+
+// A JoinFunc combines the possible values x and y of the variable v
+// where two control-flow paths meet in [Scanner.AnalyzeFunc]:
+// after an if statement,
+// a switch or select statement,
+// or at the top of a loop.
+// It tells whether the result keeps all of the values of x and y;
+// if not,
+// the values of v are incomplete from there on.
+// It must not modify x or y.
+//
+// A JoinFunc that loses precision,
+// by giving up values or adding ones that x and y do not have,
+// trades the precision of the analysis for its speed
+// (and can stand for a domain-specific abstraction,
+// such as replacing many integers with a few representatives).
+type JoinFunc func(v *types.Var, x, y map[string]constant.Value) (map[string]constant.Value, bool)
+
+// UnionJoin is the default [JoinFunc]:
+// the union of the values,
+// which keeps all of them.
+func UnionJoin(v *types.Var, x, y map[string]constant.Value) (map[string]constant.Value, bool) {
+	result := cloneVals(x)
+	union(result, y)
+	return result, true
+}
+
+// KLimitJoin returns a [JoinFunc] that takes the union of the values
+// as long as there are at most k of them,
+// and otherwise widens:
+// it keeps the k values whose ExactString representations sort first,
+// and the values are incomplete.
+// That bounds the work done for variables with many values,
+// such as loop counters,
+// which are unlikely to be complete in any case.
+func KLimitJoin(k int) JoinFunc {
+	return func(v *types.Var, x, y map[string]constant.Value) (map[string]constant.Value, bool) {
+		result, _ := UnionJoin(v, x, y)
+		if len(result) <= k {
+			return result, true
+		}
+		limited := make(map[string]constant.Value, k)
+		for _, key := range exactStrings(result)[:max(k, 0)] {
+			limited[key] = result[key]
+		}
+		return limited, false
+	}
+}
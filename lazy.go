@@ -0,0 +1,142 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// scanLazyGlobal determines the possible values of v,
+// a package-level variable,
+// if it is set lazily,
+// as in:
+//
+//	var cached string
+//
+//	func get() string {
+//		if cached == "" {
+//			cached = compute()
+//		}
+//		return cached
+//	}
+//
+// That is,
+// v is unexported,
+// it is declared without a value,
+// and it is assigned only in the bodies of if statements
+// whose conditions compare it to its zero value.
+// Its values are then the zero value
+// and those of the assignments.
+// The last result tells whether v is set that way.
+// Since v is unexported,
+// the Scanner's files are taken to include all the assignments to it.
+func (q *query) scanLazyGlobal(v *types.Var) (map[string]constant.Value, bool, bool) {
+	if v.Exported() {
+		return nil, false, false
+	}
+	zero, ok := zeroValue(v.Type())
+	if !ok {
+		return nil, false, false
+	}
+
+	var (
+		assigns []*ast.AssignStmt
+		lazy    = true
+	)
+	for _, file := range q.files {
+		q.consult(file)
+		inspectWithStack(file, func(n ast.Node, stack []ast.Node) bool {
+			if !lazy {
+				return false
+			}
+			ident, ok := n.(*ast.Ident)
+			if !ok || !identIsVar(ident, v, q.info) {
+				return true
+			}
+			parent, child, ancestors := parentOf(ident, stack)
+			switch parent := parent.(type) {
+			case *ast.ValueSpec:
+				lazy = len(parent.Values) == 0
+
+			case *ast.AssignStmt:
+				if !isLHS(parent, child) {
+					break
+				}
+				if parent.Tok != token.ASSIGN || !q.guardedByZeroCheck(ancestors, v, zero) {
+					lazy = false
+					break
+				}
+				assigns = append(assigns, parent)
+
+			case *ast.UnaryExpr:
+				lazy = parent.Op != token.AND
+
+			case *ast.IncDecStmt:
+				lazy = false
+
+			case *ast.RangeStmt:
+				lazy = child != parent.Key && child != parent.Value
+
+			case *ast.SelectorExpr:
+				// A method with a pointer receiver may change v.
+				if sel, ok := q.info.Selections[parent]; ok && sel.Kind() == types.MethodVal {
+					_, isPtr := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer)
+					lazy = !isPtr
+				}
+			}
+			return true
+		})
+	}
+	if !lazy || len(assigns) == 0 {
+		return nil, false, false
+	}
+
+	var (
+		result   = map[string]constant.Value{zero.ExactString(): zero}
+		complete = true
+	)
+	for _, assign := range assigns {
+		vals, ok := q.scanAssignment(assign, v)
+		union(result, vals)
+		complete = complete && ok
+	}
+	return result, complete, true
+}
+
+// isLHS tells whether expr is on the left-hand side of stmt.
+func isLHS(stmt *ast.AssignStmt, expr ast.Node) bool {
+	for _, lhs := range stmt.Lhs {
+		if lhs == expr {
+			return true
+		}
+	}
+	return false
+}
+
+// guardedByZeroCheck tells whether ancestors,
+// the ancestors of a statement,
+// include the body of an if statement
+// whose condition is v == zero
+// (or zero == v).
+func (q *query) guardedByZeroCheck(ancestors []ast.Node, v *types.Var, zero constant.Value) bool {
+	for i, n := range ancestors {
+		ifStmt, ok := n.(*ast.IfStmt)
+		if !ok || i+1 >= len(ancestors) || ancestors[i+1] != ifStmt.Body {
+			continue
+		}
+		cond, ok := ast.Unparen(ifStmt.Cond).(*ast.BinaryExpr)
+		if !ok || cond.Op != token.EQL {
+			continue
+		}
+		for _, pair := range [][2]ast.Expr{{cond.X, cond.Y}, {cond.Y, cond.X}} {
+			if !exprIsVar(pair[0], v, q.info) {
+				continue
+			}
+			if tv, ok := q.info.Types[pair[1]]; ok && tv.Value != nil && constant.Compare(tv.Value, token.EQL, zero) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// mapKeysKey identifies the keys of a map variable in query.active.
+type mapKeysKey struct {
+	v *types.Var
+}
+
+// callMapKeysKey identifies the keys of the result of a call in query.active.
+type callMapKeysKey struct {
+	call *ast.CallExpr
+}
+
+// MapKeys determines the keys that the map-valued expression node may contain,
+// independent of any particular lookup.
+// This is useful for checks like "every config key is documented."
+//
+// Keys come from composite literals,
+// from stores (m[k] = v, m[k]++, and so on),
+// from the results of functions in the Scanner's files,
+// and from maps copied in with maps.Copy or maps.Clone.
+// Since the order of statements is not considered,
+// a key removed with delete or clear
+// may have been added again afterwards,
+// so removals do not affect the result.
+// The result is incomplete if the map may be changed unseen,
+// e.g. by passing it to a function.
+func (s *Scanner) MapKeys(node ast.Expr) Result {
+	return s.cached(cacheKey{node: node, idx: -2}, func() Result {
+		q := s.newQuery()
+		return q.result(node, "scanning keys of "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+			return q.scanMapKeys(node)
+		})
+	})
+}
+
+// scanMapKeys determines the keys that expr,
+// which has map type,
+// may contain.
+func (q *query) scanMapKeys(expr ast.Expr) (map[string]constant.Value, bool) {
+	expr = ast.Unparen(expr)
+
+	switch expr := expr.(type) {
+	case *ast.CompositeLit:
+		var (
+			result   = make(map[string]constant.Value)
+			complete = true
+		)
+		for _, elt := range expr.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			vals, ok := q.scan(kv.Key)
+			union(result, vals)
+			complete = complete && ok
+		}
+		return result, complete
+
+	case *ast.Ident:
+		if v, ok := q.info.ObjectOf(expr).(*types.Var); ok {
+			return q.nested(expr, "keys of "+expr.Name, func() (map[string]constant.Value, bool) {
+				return q.scanVarMapKeys(v)
+			})
+		}
+		if tv, ok := q.info.Types[expr]; ok && tv.IsNil() {
+			return map[string]constant.Value{}, true
+		}
+
+	case *ast.CallExpr:
+		if isBuiltin(expr, "make", q.info) {
+			return map[string]constant.Value{}, true
+		}
+		if fun := calleeFunc(expr, q.info); fun != nil && fun.FullName() == "maps.Clone" && len(expr.Args) == 1 {
+			return q.scanMapKeys(expr.Args[0])
+		}
+		return q.nested(expr, "call to "+types.ExprString(expr.Fun), func() (map[string]constant.Value, bool) {
+			return q.scanCallMapKeys(expr)
+		})
+	}
+
+	q.incomplete(expr, "keys of %s are not tracked", types.ExprString(expr))
+	return nil, false
+}
+
+// scanCallMapKeys determines the keys that the result of call may contain.
+func (q *query) scanCallMapKeys(call *ast.CallExpr) (map[string]constant.Value, bool) {
+	key := callMapKeysKey{call: call}
+	if !q.enter(key) {
+		return nil, true
+	}
+	defer q.leave(key)
+
+	callees, complete := q.callees(call.Fun)
+	if len(callees) == 0 && !complete {
+		return nil, false
+	}
+
+	result := make(map[string]constant.Value)
+	for _, c := range callees {
+		q.frames = append(q.frames, newFrame(call, call.Fun, c.sig, len(q.frames), q.info))
+		q.forEachReturn(c.body, c.sig.Results().Len(), 0, func(ret *ast.ReturnStmt, expr ast.Expr, inner *ast.CallExpr) {
+			var (
+				vals map[string]constant.Value
+				ok   bool
+			)
+			switch {
+			case expr != nil:
+				vals, ok = q.scanMapKeys(expr)
+			case inner != nil:
+				q.incomplete(ret, "unsupported return statement")
+			default:
+				// A bare return of a named result.
+				vals, ok = q.scanVarMapKeys(c.sig.Results().At(0))
+			}
+			union(result, vals)
+			complete = complete && ok
+		})
+		q.frames = q.frames[:len(q.frames)-1]
+	}
+
+	return result, complete
+}
+
+// scanVarMapKeys determines the keys that v,
+// which has map type,
+// may contain.
+func (q *query) scanVarMapKeys(v *types.Var) (map[string]constant.Value, bool) {
+	v = v.Origin()
+
+	key := mapKeysKey{v: v}
+	if !q.enter(key) {
+		return nil, true
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		return nil, false
+	}
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+	add := func(vals map[string]constant.Value, ok bool) {
+		union(result, vals)
+		complete = complete && ok
+	}
+
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.paramArg(v); ok {
+			q.inFrames(depth, func() { add(q.scanMapKeys(arg)) })
+		} else {
+			q.incomplete(node, "%s is a parameter and its argument is unknown", v.Name())
+			complete = false
+		}
+	}
+
+	inspectWithStack(node, func(n ast.Node, stack []ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if !exprIsVar(lhs, v, q.info) {
+					continue
+				}
+				if (n.Tok != token.ASSIGN && n.Tok != token.DEFINE) || len(n.Lhs) != len(n.Rhs) {
+					q.incomplete(n, "unsupported assignment to %s", v.Name())
+					add(nil, false)
+					continue
+				}
+				add(q.scanMapKeys(n.Rhs[i]))
+			}
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					// A nil map has no keys.
+				case len(n.Names):
+					add(q.scanMapKeys(n.Values[i]))
+				default:
+					q.incomplete(n, "unsupported declaration of %s", v.Name())
+					add(nil, false)
+				}
+			}
+
+		case *ast.Ident:
+			if !identIsVar(n, v, q.info) || q.info.Defs[n] != nil {
+				return true
+			}
+			q.checkMapKeysUse(n, stack, v, add)
+		}
+		return true
+	})
+
+	return result, complete
+}
+
+// checkMapKeysUse examines a use of the map variable v,
+// with ancestors in stack,
+// for keys it may add.
+// Uses that might add keys unseen make the result incomplete.
+func (q *query) checkMapKeysUse(ident *ast.Ident, stack []ast.Node, v *types.Var, add func(map[string]constant.Value, bool)) {
+	parent, child, ancestors := parentOf(ident, stack)
+
+	switch parent := parent.(type) {
+	case *ast.IndexExpr:
+		if parent.X != child {
+			// Used as a key.
+			return
+		}
+		if mapIndexStored(parent, ancestors) {
+			add(q.scan(parent.Index))
+		}
+		return
+
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == child {
+				// Assigning v itself is handled by the caller.
+				return
+			}
+		}
+
+	case *ast.CallExpr:
+		if isBuiltin(parent, "len", q.info) || isBuiltin(parent, "delete", q.info) || isBuiltin(parent, "clear", q.info) {
+			return
+		}
+		if fun := calleeFunc(parent, q.info); fun != nil {
+			switch fun.FullName() {
+			case "maps.Copy":
+				if len(parent.Args) == 2 && parent.Args[0] == child {
+					add(q.scanMapKeys(parent.Args[1]))
+				}
+				// Copying v into another map does not change v.
+				return
+			case "maps.All", "maps.Clone", "maps.Keys", "maps.Values":
+				return
+			}
+		}
+
+	case *ast.RangeStmt:
+		if parent.X == child {
+			return
+		}
+
+	case *ast.BinaryExpr:
+		// Comparison with nil.
+		return
+
+	case *ast.ReturnStmt:
+		// Returning the map hands it to the caller,
+		// whose uses of it are tracked separately.
+		return
+	}
+
+	q.incomplete(ident, "%s is used in a way that may add keys", v.Name())
+	add(nil, false)
+}
+
+// mapIndexStored tells whether the index expression idx
+// is the target of an assignment or an increment or decrement,
+// which adds its key to the map.
+// The ancestors of idx are in stack, innermost last.
+func mapIndexStored(idx *ast.IndexExpr, stack []ast.Node) bool {
+	parent, child, _ := parentOf(idx, stack)
+
+	switch parent := parent.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == child {
+				return true
+			}
+		}
+	case *ast.IncDecStmt:
+		return true
+	case *ast.RangeStmt:
+		return parent.Key == child || parent.Value == child
+	}
+	return false
+}
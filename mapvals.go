@@ -0,0 +1,426 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// mapValsKey identifies the entries of a map variable in query.active.
+type mapValsKey struct {
+	v *types.Var
+}
+
+// mapVals holds the possible values of the entries of a map.
+// Entries with known keys are tracked separately,
+// so that a lookup of one key is not spoiled by the others.
+type mapVals struct {
+	// byKey holds the possible values of the entries with known keys,
+	// keyed by the ExactString representations of the keys.
+	byKey map[string]valSet
+
+	// present holds the keys that are in the map from the start,
+	// as in a composite literal,
+	// and are never deleted,
+	// so that looking them up never produces the zero value.
+	present map[string]bool
+
+	// unkeyed holds the possible values of entries that may have any key.
+	unkeyed valSet
+}
+
+func newMapVals() *mapVals {
+	return &mapVals{
+		byKey:   make(map[string]valSet),
+		present: make(map[string]bool),
+		unkeyed: valSet{complete: true},
+	}
+}
+
+// unknownMapVals returns a mapVals about which nothing is known,
+// recording the reason described by format and args.
+func (q *query) unknownMapVals(node ast.Node, format string, args ...any) *mapVals {
+	q.incomplete(node, format, args...)
+	mv := newMapVals()
+	mv.unkeyed.complete = false
+	return mv
+}
+
+// store adds vals as the possible values of the entry with the given key.
+func (mv *mapVals) store(key string, vals map[string]constant.Value, complete bool) {
+	set, ok := mv.byKey[key]
+	if !ok {
+		set.complete = true
+	}
+	set.add(vals, complete)
+	mv.byKey[key] = set
+}
+
+// at returns the possible values of the entry with the given key,
+// which include zero
+// (the zero value of the map's element type, if it is a constant)
+// unless the key is always present.
+func (mv *mapVals) at(key string, zero constant.Value) (map[string]constant.Value, bool) {
+	set, ok := mv.byKey[key]
+	if !ok {
+		set.complete = true
+	}
+	result := cloneVals(set.vals)
+	union(result, mv.unkeyed.vals)
+	complete := set.complete && mv.unkeyed.complete
+	if !mv.present[key] {
+		if zero == nil {
+			complete = false
+		} else {
+			result[zero.ExactString()] = zero
+		}
+	}
+	return result, complete
+}
+
+// all returns the possible values of any entry.
+func (mv *mapVals) all() (map[string]constant.Value, bool) {
+	var (
+		result   = cloneVals(mv.unkeyed.vals)
+		complete = mv.unkeyed.complete
+	)
+	for _, set := range mv.byKey {
+		union(result, set.vals)
+		complete = complete && set.complete
+	}
+	return result, complete
+}
+
+// merge adds the possibilities in other to mv.
+// A key is present in the result only if it is present in both.
+func (mv *mapVals) merge(other *mapVals) {
+	for key, set := range other.byKey {
+		mv.store(key, set.vals, set.complete)
+	}
+	for key := range mv.present {
+		if !other.present[key] {
+			delete(mv.present, key)
+		}
+	}
+	mv.unkeyed.add(other.unkeyed.vals, other.unkeyed.complete)
+}
+
+// scanMapIndex determines the possible values of expr[index],
+// where expr has map type.
+// If the possible values of index are known,
+// only the entries with those keys are considered.
+// A key that may be absent produces the zero value.
+func (q *query) scanMapIndex(expr, index ast.Expr) (map[string]constant.Value, bool) {
+	return q.nested(expr, "entries of "+types.ExprString(expr), func() (map[string]constant.Value, bool) {
+		var zero constant.Value
+		if m, ok := underlying(q.info.TypeOf(expr)).(*types.Map); ok {
+			zero, _ = zeroValue(m.Elem())
+		}
+
+		mv := q.scanMapVals(expr)
+		keys, ok := q.scan(index)
+		if !ok || interfaceKeyed(q.info.TypeOf(expr)) {
+			// Any entry may be the one looked up.
+			// (With an interface key type,
+			// keys with the same value but different dynamic types are different,
+			// but the entries are not told apart by type.)
+			vals, complete := mv.all()
+			if zero == nil {
+				return vals, false
+			}
+			vals[zero.ExactString()] = zero
+			return vals, complete
+		}
+
+		var (
+			result   = make(map[string]constant.Value)
+			complete = true
+		)
+		for k := range keys {
+			vals, ok := mv.at(k, zero)
+			union(result, vals)
+			complete = complete && ok
+		}
+		return result, complete
+	})
+}
+
+// scanMapVals determines the possible values of the entries of expr,
+// which has map type.
+func (q *query) scanMapVals(expr ast.Expr) *mapVals {
+	expr = ast.Unparen(expr)
+
+	switch expr := expr.(type) {
+	case *ast.CompositeLit:
+		return q.scanCompositeMapVals(expr)
+
+	case *ast.Ident:
+		if v, ok := q.info.ObjectOf(expr).(*types.Var); ok {
+			return q.scanVarMapVals(v)
+		}
+		if tv, ok := q.info.Types[expr]; ok && tv.IsNil() {
+			return newMapVals()
+		}
+
+	case *ast.CallExpr:
+		if isBuiltin(expr, "make", q.info) {
+			return newMapVals()
+		}
+		if fun := calleeFunc(expr, q.info); fun != nil && fun.FullName() == "maps.Clone" && len(expr.Args) == 1 {
+			return q.scanMapVals(expr.Args[0])
+		}
+	}
+
+	return q.unknownMapVals(expr, "entries of %s are not tracked", types.ExprString(expr))
+}
+
+// scanCompositeMapVals determines the possible values of the entries
+// of a map composite literal.
+func (q *query) scanCompositeMapVals(lit *ast.CompositeLit) *mapVals {
+	mv := newMapVals()
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		vals, complete := q.scan(kv.Value)
+		keys, ok := q.scan(kv.Key)
+		if !ok {
+			mv.unkeyed.add(vals, complete)
+			continue
+		}
+		for k := range keys {
+			mv.store(k, vals, complete)
+			if len(keys) == 1 {
+				mv.present[k] = true
+			}
+		}
+	}
+	return mv
+}
+
+// scanVarMapVals determines the possible values of the entries of v,
+// which has map type.
+// Values stored into its entries,
+// as by v["k"] = x,
+// are included.
+// The result is incomplete if v is used in any way
+// that might let its entries change unseen,
+// such as passing it to a function.
+func (q *query) scanVarMapVals(v *types.Var) *mapVals {
+	v = v.Origin()
+
+	key := mapValsKey{v: v}
+	if !q.enter(key) {
+		return newMapVals()
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		mv := newMapVals()
+		mv.unkeyed.complete = false
+		return mv
+	}
+
+	var (
+		// mv starts out with no possibilities;
+		// each assignment to v adds some.
+		mv *mapVals
+
+		// uses are the other uses of v,
+		// examined once all the assignments to v are known.
+		uses []mapUse
+	)
+
+	add := func(other *mapVals) {
+		if mv == nil {
+			mv = other
+		} else {
+			mv.merge(other)
+		}
+	}
+
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.paramArg(v); ok {
+			q.inFrames(depth, func() { add(q.scanMapVals(arg)) })
+		} else {
+			add(q.unknownMapVals(node, "%s is a parameter and its argument is unknown", v.Name()))
+		}
+	}
+
+	inspectWithStack(node, func(n ast.Node, stack []ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if !exprIsVar(lhs, v, q.info) {
+					continue
+				}
+				if (n.Tok != token.ASSIGN && n.Tok != token.DEFINE) || len(n.Lhs) != len(n.Rhs) {
+					add(q.unknownMapVals(n, "unsupported assignment to %s", v.Name()))
+					continue
+				}
+				add(q.scanMapVals(n.Rhs[i]))
+			}
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					// A nil map has no entries.
+					add(newMapVals())
+				case len(n.Names):
+					add(q.scanMapVals(n.Values[i]))
+				default:
+					add(q.unknownMapVals(n, "unsupported declaration of %s", v.Name()))
+				}
+			}
+
+		case *ast.Ident:
+			if !identIsVar(n, v, q.info) || q.info.Defs[n] != nil {
+				return true
+			}
+			uses = append(uses, mapUse{ident: n, stack: slices.Clone(stack)})
+		}
+		return true
+	})
+
+	if mv == nil {
+		mv = newMapVals()
+	}
+	for _, use := range uses {
+		q.checkMapValsUse(mv, use.ident, use.stack, v)
+	}
+	return mv
+}
+
+// interfaceKeyed tells whether typ is a map type whose keys are interfaces
+// (or of a type parameter's type),
+// so that keys with equal values may differ in dynamic type.
+func interfaceKeyed(typ types.Type) bool {
+	m, ok := underlying(typ).(*types.Map)
+	return ok && types.IsInterface(m.Key())
+}
+
+// mapUse is a use of a map variable,
+// with its ancestors.
+type mapUse struct {
+	ident *ast.Ident
+	stack []ast.Node
+}
+
+// checkMapValsUse examines a use of the map variable v,
+// with ancestors in stack,
+// and adds to mv the entries it may store
+// or removes the keys it may delete from those that are present.
+// Uses that might change the entries unseen make mv incomplete.
+func (q *query) checkMapValsUse(mv *mapVals, ident *ast.Ident, stack []ast.Node, v *types.Var) {
+	parent, child, ancestors := parentOf(ident, stack)
+
+	switch parent := parent.(type) {
+	case *ast.IndexExpr:
+		if parent.X != child {
+			// Used as a key.
+			return
+		}
+		if !mapIndexStored(parent, ancestors) {
+			return
+		}
+		stmt, idxChild, _ := parentOf(parent, ancestors)
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != len(assign.Rhs) {
+			q.incomplete(parent, "unsupported store to %s", types.ExprString(parent))
+			mv.unkeyed.complete = false
+			return
+		}
+		i := 0
+		for i < len(assign.Lhs) && assign.Lhs[i] != idxChild {
+			i++
+		}
+		vals, complete := q.scan(assign.Rhs[i])
+		keys, ok := q.scan(parent.Index)
+		if !ok {
+			mv.unkeyed.add(vals, complete)
+			return
+		}
+		for k := range keys {
+			mv.store(k, vals, complete)
+		}
+		return
+
+	case *ast.AssignStmt:
+		for _, lhs := range parent.Lhs {
+			if lhs == child {
+				// Assigning v itself is handled by the caller.
+				return
+			}
+		}
+
+	case *ast.CallExpr:
+		switch {
+		case isBuiltin(parent, "len", q.info):
+			return
+		case isBuiltin(parent, "clear", q.info):
+			clear(mv.present)
+			return
+		case isBuiltin(parent, "delete", q.info):
+			if len(parent.Args) != 2 {
+				return
+			}
+			keys, ok := q.scan(parent.Args[1])
+			if !ok {
+				clear(mv.present)
+				return
+			}
+			for k := range keys {
+				delete(mv.present, k)
+			}
+			return
+		}
+		if fun := calleeFunc(parent, q.info); fun != nil {
+			switch fun.FullName() {
+			case "maps.Copy":
+				if len(parent.Args) == 2 && parent.Args[0] == child {
+					other := q.scanMapVals(parent.Args[1])
+					for key, set := range other.byKey {
+						mv.store(key, set.vals, set.complete)
+					}
+					mv.unkeyed.add(other.unkeyed.vals, other.unkeyed.complete)
+				}
+				// Copying v into another map does not change v.
+				return
+			case "maps.All", "maps.Clone", "maps.Keys", "maps.Values":
+				return
+			}
+		}
+
+	case *ast.RangeStmt:
+		if parent.X == child {
+			return
+		}
+
+	case *ast.BinaryExpr:
+		// Comparison with nil.
+		return
+
+	case *ast.ReturnStmt:
+		// Returning the map from its own function hands it to the caller,
+		// whose uses of it are tracked separately.
+		// Returning it from a function literal lets the literal's callers change it unseen.
+		scope := q.varScopeNode(v)
+		if !slices.ContainsFunc(stack, func(n ast.Node) bool {
+			_, isLit := n.(*ast.FuncLit)
+			return isLit && n != scope
+		}) {
+			return
+		}
+	}
+
+	q.incomplete(ident, "%s is used in a way that may change its entries", v.Name())
+	mv.unkeyed.complete = false
+}
@@ -0,0 +1,301 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// nonLocalExitFuncs are the functions and methods,
+// by full name (see [types.Func.FullName]),
+// known never to return normally.
+// Calls to other functions are checked with [query.inferNeverReturns].
+var nonLocalExitFuncs = map[string]bool{
+	"os.Exit":        true,
+	"runtime.Goexit": true,
+
+	"log.Fatal":   true,
+	"log.Fatalf":  true,
+	"log.Fatalln": true,
+	"log.Panic":   true,
+	"log.Panicf":  true,
+	"log.Panicln": true,
+
+	"(*log.Logger).Fatal":   true,
+	"(*log.Logger).Fatalf":  true,
+	"(*log.Logger).Fatalln": true,
+	"(*log.Logger).Panic":   true,
+	"(*log.Logger).Panicf":  true,
+	"(*log.Logger).Panicln": true,
+
+	"(*testing.common).FailNow": true,
+	"(*testing.common).Fatal":   true,
+	"(*testing.common).Fatalf":  true,
+	"(*testing.common).Skip":    true,
+	"(*testing.common).SkipNow": true,
+	"(*testing.common).Skipf":   true,
+}
+
+// neverReturns tells whether call never returns normally:
+// it is a call to panic,
+// to one of the nonLocalExitFuncs,
+// or to a function whose body is available
+// and in which every path ends in such a call.
+func (q *query) neverReturns(call *ast.CallExpr) bool {
+	if isBuiltin(call, "panic", q.info) {
+		return true
+	}
+	fn := calleeFunc(call, q.info)
+	if fn == nil {
+		return false
+	}
+	if nonLocalExitFuncs[funcName(fn)] {
+		return true
+	}
+	return q.inferNeverReturns(fn)
+}
+
+// exprNeverCompletes tells whether evaluating expr
+// always reaches a call that never returns,
+// as in ch <- mustGet().
+// Calls in function literals,
+// and in the right operands of && and ||,
+// which may not be evaluated,
+// do not count.
+func (q *query) exprNeverCompletes(expr ast.Expr) bool {
+	var result bool
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if result {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				result = q.exprNeverCompletes(n.X)
+				return false
+			}
+		case *ast.CallExpr:
+			result = q.neverReturns(n)
+		}
+		return true
+	})
+	return result
+}
+
+// inferNeverReturns tells whether every path through the body of fn
+// ends in a call that never returns
+// (or in a loop that never ends).
+// The answer is cached in q.noReturn.
+// Functions whose bodies are unavailable,
+// or that contain return statements or deferred calls
+// (which might recover from a panic),
+// are assumed to return.
+func (q *query) inferNeverReturns(fn *types.Func) bool {
+	fn = fn.Origin()
+	if result, ok := q.noReturn[fn]; ok {
+		return result
+	}
+
+	// Assume fn returns while inferring,
+	// in case it is recursive.
+	q.noReturn[fn] = false
+
+	body := q.calleeBody(fn)
+	if body == nil {
+		return false
+	}
+
+	var mayReturn bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt, *ast.DeferStmt:
+			mayReturn = true
+		}
+		return !mayReturn
+	})
+	if mayReturn {
+		return false
+	}
+
+	result := q.listNeverCompletes(body.List)
+	q.noReturn[fn] = result
+	return result
+}
+
+// stmtNeverCompletes tells whether control never passes from stmt
+// to the statement after it.
+// This is the case for return and branch statements,
+// calls that never return,
+// and compound statements built from those.
+func (q *query) stmtNeverCompletes(stmt ast.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+
+	case *ast.ExprStmt:
+		call, ok := ast.Unparen(stmt.X).(*ast.CallExpr)
+		if ok && q.neverReturns(call) {
+			return true
+		}
+		return q.opBlocks(stmt)
+
+	case *ast.SendStmt:
+		return q.opBlocks(stmt) || q.exprNeverCompletes(stmt.Chan) || q.exprNeverCompletes(stmt.Value)
+
+	case *ast.AssignStmt:
+		return q.opBlocks(stmt)
+
+	case *ast.BlockStmt:
+		return q.listNeverCompletes(stmt.List)
+
+	case *ast.LabeledStmt:
+		if loop, ok := stmt.Stmt.(*ast.ForStmt); ok {
+			return loop.Cond == nil && !leavesLoop(loop.Body, stmt.Label.Name)
+		}
+		return q.stmtNeverCompletes(stmt.Stmt)
+
+	case *ast.IfStmt:
+		return stmt.Else != nil && q.stmtNeverCompletes(stmt.Body) && q.stmtNeverCompletes(stmt.Else)
+
+	case *ast.ForStmt:
+		// An endless loop never completes,
+		// unless something in it leaves the loop.
+		return stmt.Cond == nil && !leavesLoop(stmt.Body, "")
+
+	case *ast.SelectStmt:
+		// A select statement with no cases,
+		// or with only cases on nil channels and no default,
+		// blocks forever.
+		return q.selectBlocks(stmt)
+	}
+
+	return false
+}
+
+// listNeverCompletes tells whether control never passes
+// beyond the end of the statement list.
+func (q *query) listNeverCompletes(list []ast.Stmt) bool {
+	var dead bool
+	for _, stmt := range list {
+		if _, ok := stmt.(*ast.LabeledStmt); ok {
+			// Reachable by goto.
+			dead = false
+		}
+		if !dead && q.stmtNeverCompletes(stmt) {
+			dead = true
+		}
+	}
+	return dead
+}
+
+// reachable returns the statements in list that can be reached from the beginning of the list
+// or (via goto) from elsewhere,
+// omitting those that follow a statement that never completes.
+func (q *query) reachable(list []ast.Stmt) []ast.Stmt {
+	var (
+		result []ast.Stmt
+		dead   bool
+	)
+	for _, stmt := range list {
+		if _, ok := stmt.(*ast.LabeledStmt); ok {
+			dead = false
+		}
+		if dead {
+			continue
+		}
+		result = append(result, stmt)
+		if q.stmtNeverCompletes(stmt) {
+			dead = true
+		}
+	}
+	return result
+}
+
+// inspectReachable is like [ast.Inspect],
+// but skips statements that cannot be reached
+// because they follow a statement that never completes
+// (see [query.reachable]).
+func (q *query) inspectReachable(node ast.Node, f func(ast.Node) bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		var (
+			list  []ast.Stmt
+			other []ast.Node
+		)
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			list = n.List
+		case *ast.CaseClause:
+			list = n.Body
+			for _, expr := range n.List {
+				other = append(other, expr)
+			}
+		case *ast.CommClause:
+			list = n.Body
+			if n.Comm != nil {
+				other = append(other, n.Comm)
+			}
+		default:
+			return f(n)
+		}
+
+		if !f(n) {
+			return false
+		}
+		for _, node := range other {
+			q.inspectReachable(node, f)
+		}
+		for _, stmt := range q.reachable(list) {
+			q.inspectReachable(stmt, f)
+		}
+		return false
+	})
+}
+
+// leavesLoop tells whether body,
+// the body of a loop with the given label (or none),
+// contains a break statement that leaves the loop:
+// one with the loop's label,
+// or one without a label that is not in a nested loop,
+// switch, or select statement.
+// Any goto statement is conservatively assumed to leave the loop too.
+// Those in function literals do not count.
+func leavesLoop(body *ast.BlockStmt, label string) bool {
+	var (
+		found bool
+		depth int // of nested statements that an unlabeled break leaves
+		stack []ast.Node
+	)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			switch stack[len(stack)-1].(type) {
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+				depth--
+			}
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			depth++
+		case *ast.BranchStmt:
+			switch {
+			case n.Tok == token.GOTO:
+				found = true
+			case n.Tok != token.BREAK:
+			case n.Label != nil:
+				found = found || n.Label.Name == label
+			default:
+				found = found || depth == 0
+			}
+		}
+		stack = append(stack, n)
+		return !found
+	})
+	return found
+}
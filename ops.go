@@ -0,0 +1,354 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// scanBinary determines the possible values of a binary expression
+// by applying its operator to each combination of the possible values of its operands.
+// Combinations that would panic at run time (division by zero) are omitted.
+func (q *query) scanBinary(expr *ast.BinaryExpr) (map[string]constant.Value, bool) {
+	switch expr.Op {
+	case token.LAND, token.LOR:
+		return q.scanLogical(expr)
+	case token.EQL, token.NEQ:
+		if isAggregate(q.info.TypeOf(expr.X)) && isAggregate(q.info.TypeOf(expr.Y)) {
+			return q.scanAggregateEqual(expr)
+		}
+	}
+
+	xVals, xComplete := q.scan(expr.X)
+	if len(xVals) == 0 {
+		return xVals, xComplete
+	}
+	yVals, yComplete := q.scan(expr.Y)
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = xComplete && yComplete
+		typ, _   = underlying(q.info.TypeOf(expr)).(*types.Basic)
+	)
+
+	for _, x := range xVals {
+		for _, y := range yVals {
+			v, ok := binaryOp(x, expr.Op, y, typ)
+			if !ok {
+				q.incomplete(expr, "cannot compute %s %s %s", x.ExactString(), expr.Op, y.ExactString())
+				complete = false
+				continue
+			}
+			if v == nil {
+				// E.g. division by zero.
+				continue
+			}
+			result[v.ExactString()] = v
+		}
+	}
+
+	return result, complete
+}
+
+// maxAggregateLeaves limits the number of fields and elements
+// that [query.scanAggregateEqual] compares.
+const maxAggregateLeaves = 64
+
+func isAggregate(typ types.Type) bool {
+	switch underlying(typ).(type) {
+	case *types.Struct, *types.Array:
+		return true
+	}
+	return false
+}
+
+// scanAggregateEqual handles == and != on structs and arrays,
+// comparing them field by field and element by element.
+// The operands are equal if every pair of corresponding fields may be,
+// and different if any pair may differ.
+// Only structs and arrays whose fields and elements have basic types
+// (or are structs of the same kind, for fields) are understood.
+func (q *query) scanAggregateEqual(expr *ast.BinaryExpr) (map[string]constant.Value, bool) {
+	xLeaves, ok := q.aggregateLeaves(expr.X)
+	if !ok {
+		q.incomplete(expr, "comparison of %s values is not tracked", q.info.TypeOf(expr.X))
+		return nil, false
+	}
+	yLeaves, ok := q.aggregateLeaves(expr.Y)
+	if !ok || len(yLeaves) != len(xLeaves) {
+		q.incomplete(expr, "comparison of %s values is not tracked", q.info.TypeOf(expr.Y))
+		return nil, false
+	}
+
+	var (
+		result    = make(map[string]constant.Value)
+		complete  = true
+		mayEqual  = true
+		mayDiffer bool
+	)
+	for i, x := range xLeaves {
+		y := yLeaves[i]
+		if !x.complete || !y.complete {
+			complete = false
+			continue
+		}
+		if len(x.vals) == 0 || len(y.vals) == 0 {
+			// An operand has no value,
+			// e.g. because it selects a field through a nil pointer.
+			return result, true
+		}
+		var eq, ne bool
+		for _, xv := range x.vals {
+			for _, yv := range y.vals {
+				if valuesEqual(xv, yv) {
+					eq = true
+				} else {
+					ne = true
+				}
+			}
+		}
+		mayEqual = mayEqual && eq
+		mayDiffer = mayDiffer || ne
+	}
+
+	add := func(equal bool) {
+		v := constant.MakeBool(equal == (expr.Op == token.EQL))
+		result[v.ExactString()] = v
+	}
+	if !mayEqual {
+		// Some pair of fields is never equal,
+		// whatever the others are.
+		add(false)
+		return result, true
+	}
+	if mayDiffer {
+		add(false)
+	}
+	if complete {
+		add(true)
+	}
+	return result, complete
+}
+
+// aggregateLeaves returns the possible values of each field or element of x,
+// a struct or array,
+// descending into fields that are themselves structs.
+// It reports false if x has fields or elements of other types,
+// or too many of them.
+func (q *query) aggregateLeaves(x ast.Expr) ([]valSet, bool) {
+	switch u := underlying(q.info.TypeOf(x)).(type) {
+	case *types.Struct:
+		var paths [][]int
+		if !structLeaves(u, nil, &paths) || len(paths) > maxAggregateLeaves {
+			return nil, false
+		}
+		result := make([]valSet, 0, len(paths))
+		for _, path := range paths {
+			vals, complete := q.scanField(x, path)
+			result = append(result, valSet{vals: vals, complete: complete})
+		}
+		return result, true
+
+	case *types.Array:
+		if _, ok := underlying(u.Elem()).(*types.Basic); !ok || u.Len() > maxAggregateLeaves {
+			return nil, false
+		}
+		ev := q.scanElems(x)
+		result := make([]valSet, 0, u.Len())
+		for i := range u.Len() {
+			vals, complete := ev.at(i)
+			result = append(result, valSet{vals: vals, complete: complete})
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// structLeaves adds to paths the field paths of the fields of st
+// that have basic types,
+// descending into fields that are structs.
+// Blank fields are skipped,
+// since comparisons ignore them.
+// It reports false if st has fields of other types.
+func structLeaves(st *types.Struct, prefix []int, paths *[][]int) bool {
+	for i := range st.NumFields() {
+		f := st.Field(i)
+		if f.Name() == "_" {
+			continue
+		}
+		path := append(slices.Clip(prefix), i)
+		switch u := underlying(f.Type()).(type) {
+		case *types.Basic:
+			*paths = append(*paths, path)
+		case *types.Struct:
+			if !structLeaves(u, path, paths) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// scanLogical handles && and ||,
+// including short-circuit evaluation:
+// if the left operand decides the result,
+// the right operand does not matter.
+func (q *query) scanLogical(expr *ast.BinaryExpr) (map[string]constant.Value, bool) {
+	xVals, xComplete := q.scan(expr.X)
+
+	decisive := expr.Op == token.LOR // x || y is true when x is true; x && y is false when x is false.
+
+	var (
+		result    = make(map[string]constant.Value)
+		complete  = xComplete
+		needRight = !xComplete
+	)
+	for _, x := range xVals {
+		if x.Kind() != constant.Bool {
+			q.incomplete(expr.X, "non-boolean operand %s", x.ExactString())
+			return nil, false
+		}
+		if constant.BoolVal(x) == decisive {
+			v := constant.MakeBool(decisive)
+			result[v.ExactString()] = v
+		} else {
+			needRight = true
+		}
+	}
+	if !needRight {
+		return result, complete
+	}
+
+	yVals, yComplete := q.scan(expr.Y)
+	union(result, yVals)
+	return result, complete && yComplete
+}
+
+// scanUnary determines the possible values of a unary expression.
+func (q *query) scanUnary(expr *ast.UnaryExpr) (map[string]constant.Value, bool) {
+	switch expr.Op {
+	case token.NOT, token.SUB, token.ADD, token.XOR:
+	default:
+		q.incomplete(expr, "unsupported operator %s", expr.Op)
+		return nil, false
+	}
+
+	vals, complete := q.scan(expr.X)
+
+	typ, _ := underlying(q.info.TypeOf(expr)).(*types.Basic)
+
+	var prec uint
+	if typ != nil && typ.Info()&types.IsUnsigned != 0 {
+		prec = uint(8 * sizes.Sizeof(typ))
+	}
+
+	result := make(map[string]constant.Value)
+	for _, v := range vals {
+		if !unaryOK(expr.Op, v) {
+			q.incomplete(expr, "cannot compute %s%s", expr.Op, v.ExactString())
+			complete = false
+			continue
+		}
+		r := constant.UnaryOp(expr.Op, v, prec)
+		if typ != nil && typ.Info()&types.IsInteger != 0 {
+			r = wrapInt(r, typ)
+		}
+		result[r.ExactString()] = r
+	}
+	return result, complete
+}
+
+func unaryOK(op token.Token, v constant.Value) bool {
+	switch op {
+	case token.NOT:
+		return v.Kind() == constant.Bool
+	case token.XOR:
+		return v.Kind() == constant.Int
+	}
+	return isNumeric(v)
+}
+
+// binaryOp computes x op y,
+// where the result has the basic type typ
+// (which may be nil if it is not a basic type).
+// Comparisons yield booleans.
+// Integer arithmetic wraps around as at run time.
+// The result is nil, true if the operation would panic,
+// and nil, false if it cannot be computed.
+func binaryOp(x constant.Value, op token.Token, y constant.Value, typ *types.Basic) (constant.Value, bool) {
+	switch op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if !canCompare(x, y) {
+			return nil, false
+		}
+		if x.Kind() == constant.Bool && op != token.EQL && op != token.NEQ {
+			return nil, false
+		}
+		return constant.MakeBool(constant.Compare(x, op, y)), true
+
+	case token.SHL, token.SHR:
+		if x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return nil, false
+		}
+		if constant.Sign(y) < 0 {
+			// Panics at run time.
+			return nil, true
+		}
+		s, ok := constant.Uint64Val(y)
+		if !ok || s > 1024 {
+			return nil, false
+		}
+		r := constant.Shift(x, op, uint(s))
+		if typ != nil && typ.Info()&types.IsInteger != 0 {
+			r = wrapInt(r, typ)
+		}
+		return r, true
+	}
+
+	if x.Kind() == constant.String || y.Kind() == constant.String {
+		if op != token.ADD || x.Kind() != y.Kind() {
+			return nil, false
+		}
+		return constant.BinaryOp(x, op, y), true
+	}
+
+	if !isNumeric(x) || !isNumeric(y) {
+		return nil, false
+	}
+
+	isInt := typ != nil && typ.Info()&types.IsInteger != 0
+	switch op {
+	case token.QUO, token.REM:
+		if constant.Sign(y) == 0 {
+			if isInt {
+				// Integer division by zero panics.
+				return nil, true
+			}
+			// Floating-point division by zero yields an infinity or NaN,
+			// which constant.Value cannot represent.
+			return nil, false
+		}
+		if isInt && op == token.QUO {
+			op = token.QUO_ASSIGN // integer division
+		}
+	case token.AND, token.OR, token.XOR, token.AND_NOT:
+		if x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return nil, false
+		}
+	}
+
+	r := constant.BinaryOp(x, op, y)
+	if r.Kind() == constant.Unknown {
+		return nil, false
+	}
+	if isInt {
+		r = wrapInt(r, typ)
+	} else if typ != nil && typ.Info()&types.IsFloat != 0 {
+		r, _ = convertValue(r, typ)
+	}
+	return r, true
+}
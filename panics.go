@@ -0,0 +1,126 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// PanicValues is the outcome of [Scanner.ScanPanicValues].
+type PanicValues struct {
+	// Calls are the calls to panic that were found,
+	// in the order they were found.
+	Calls []*ast.CallExpr
+
+	// Types are the possible dynamic types of the values passed to panic,
+	// each with an expression it comes from,
+	// as in [DynamicTypes].
+	Types []DynamicType
+
+	// Values holds the possible values passed to panic
+	// that have basic types,
+	// keyed by their ExactString representation,
+	// as in [Result].
+	Values map[string]constant.Value
+
+	// Complete tells whether Types and Values hold all the possibilities.
+	Complete bool
+
+	// Why explains what made the result incomplete,
+	// as in [Result].
+	Why *Reason
+}
+
+// ScanPanicValues finds the values that may be passed to panic
+// in the body of fn
+// (including the function literals in it),
+// and transitively in the functions it calls whose bodies are in the Scanner's files,
+// for code that panics with sentinel values or types
+// and recovers from them centrally.
+// Panics in goroutines that fn starts are not included,
+// since they do not pass through fn,
+// but those that a deferred call to recover may stop are.
+//
+// With [AssumeMayPanic],
+// a call of a function whose body is not available
+// makes the result incomplete,
+// as it may panic with any value.
+// Run-time panics,
+// such as those from indexing out of range,
+// are not included.
+func (s *Scanner) ScanPanicValues(fn *types.Func) PanicValues {
+	var result PanicValues
+
+	body := funcBody(fn, s.Files)
+	if body == nil {
+		result.Why = &Reason{Pos: fn.Pos(), Msg: "body of " + fn.Name() + " not found"}
+		return result
+	}
+
+	q := s.newQuery()
+	seen := map[*types.Func]bool{fn.Origin(): true}
+
+	var walk func(body *ast.BlockStmt) bool
+	walk = func(body *ast.BlockStmt) bool {
+		complete := true
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.GoStmt:
+				return false
+
+			case *ast.CallExpr:
+				if isBuiltin(n, "panic", q.info) {
+					result.Calls = append(result.Calls, n)
+					complete = q.panicValues(n.Args[0], &result) && complete
+					return true
+				}
+				if q.mayPanic(n) {
+					q.incomplete(n, "%s may panic with any value", types.ExprString(n.Fun))
+					complete = false
+					return true
+				}
+				if _, ok := summaryFor(n, q.info); ok {
+					return true
+				}
+				callee := calleeFunc(n, q.info)
+				if callee == nil || seen[callee.Origin()] {
+					return true
+				}
+				if b := q.calleeBody(callee); b != nil {
+					seen[callee.Origin()] = true
+					complete = q.nestedOK(n, "call to "+types.ExprString(n.Fun), func() bool {
+						return walk(b)
+					}) && complete
+				}
+			}
+			return true
+		})
+		return complete
+	}
+
+	res := q.result(body, "finding values passed to panic in "+fn.Name(), func() (map[string]constant.Value, bool) {
+		result.Values = make(map[string]constant.Value)
+		return result.Values, walk(body)
+	})
+	result.Values, result.Complete, result.Why = res.Values, res.Complete, res.Why
+	return result
+}
+
+// panicValues adds the dynamic types of arg,
+// the argument of a call to panic,
+// and the values of those of basic type,
+// to result.
+// It reports whether it found all of them.
+func (q *query) panicValues(arg ast.Expr, result *PanicValues) bool {
+	complete := true
+	ok := q.dynamicValues(arg, func(expr ast.Expr, typ types.Type) {
+		result.Types = append(result.Types, DynamicType{Type: typ, Expr: expr})
+		if !isBasic(typ) {
+			return
+		}
+		vals, ok := q.scan(expr)
+		union(result.Values, vals)
+		complete = ok && complete
+	})
+	return ok && complete
+}
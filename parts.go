@@ -0,0 +1,163 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"maps"
+	"slices"
+)
+
+// otherParts is the key in [Result.Parts]
+// for the elements or entries at indexes or keys not given separately.
+const otherParts = "[...]"
+
+// parts computes [Result.Parts] for expr.
+// Each part is scanned by a query of its own,
+// so that its Why explains only what is unknown about that part.
+func (s *Scanner) parts(expr ast.Expr) map[string]Result {
+	switch typ := underlying(s.Info.TypeOf(expr)).(type) {
+	case *types.Struct:
+		result := make(map[string]Result)
+		s.fieldParts(expr, typ, "", nil, result)
+		return result
+
+	case *types.Slice:
+		if isBasic(typ.Elem()) {
+			return s.elemParts(expr)
+		}
+
+	case *types.Array:
+		if isBasic(typ.Elem()) {
+			return s.elemParts(expr)
+		}
+
+	case *types.Map:
+		if isBasic(typ.Elem()) {
+			return s.entryParts(expr, typ)
+		}
+	}
+	return nil
+}
+
+// fieldParts adds to result the parts of expr,
+// of struct type typ,
+// for its fields of basic type,
+// and of struct type, recursively.
+// The fields are at the given path (see [query.scanField])
+// and have names beginning with prefix.
+func (s *Scanner) fieldParts(expr ast.Expr, typ *types.Struct, prefix string, path []int, result map[string]Result) {
+	for i := 0; i < typ.NumFields(); i++ {
+		var (
+			field     = typ.Field(i)
+			name      = prefix + "." + field.Name()
+			fieldPath = append(slices.Clip(path), i)
+		)
+		if st, ok := underlying(field.Type()).(*types.Struct); ok {
+			s.fieldParts(expr, st, name, fieldPath, result)
+			continue
+		}
+		if !isBasic(field.Type()) {
+			continue
+		}
+		q := s.newQuery()
+		result[name] = q.result(expr, "scanning field "+types.ExprString(expr)+name, func() (map[string]constant.Value, bool) {
+			return q.scanField(expr, fieldPath)
+		})
+	}
+}
+
+// elemParts returns the parts of expr,
+// of slice or array type,
+// for the elements at the indexes known to [query.scanElems]
+// and for those at other indexes.
+func (s *Scanner) elemParts(expr ast.Expr) map[string]Result {
+	var indexes []int64
+	q := s.newQuery()
+	q.result(expr, "", func() (map[string]constant.Value, bool) {
+		indexes = slices.Sorted(maps.Keys(q.scanElems(expr).byIndex))
+		return nil, true
+	})
+
+	result := make(map[string]Result)
+	for _, idx := range indexes {
+		q := s.newQuery()
+		result[fmt.Sprintf("[%d]", idx)] = q.result(expr, fmt.Sprintf("scanning %s[%d]", types.ExprString(expr), idx), func() (map[string]constant.Value, bool) {
+			return q.scanElems(expr).at(idx)
+		})
+	}
+
+	q = s.newQuery()
+	result[otherParts] = q.result(expr, "scanning other elements of "+types.ExprString(expr), func() (map[string]constant.Value, bool) {
+		ev := q.scanElems(expr)
+		vals := cloneVals(ev.dflt.vals)
+		union(vals, ev.unindexed.vals)
+		return vals, ev.dflt.complete && ev.unindexed.complete
+	})
+	return result
+}
+
+// entryParts returns the parts of expr,
+// of map type typ,
+// for the entries at the keys known to [query.scanMapVals]
+// and for those at other keys.
+// Looking up a key that may be absent gives the zero value,
+// so that is among the values of a part unless its key is always present.
+// The entries of a map with interface keys are not told apart by key
+// (see [interfaceKeyed]).
+func (s *Scanner) entryParts(expr ast.Expr, typ *types.Map) map[string]Result {
+	zero, _ := zeroValue(typ.Elem())
+
+	var (
+		keys          []string
+		interfaceKeys = types.IsInterface(typ.Key())
+	)
+	if !interfaceKeys {
+		q := s.newQuery()
+		q.result(expr, "", func() (map[string]constant.Value, bool) {
+			keys = slices.Sorted(maps.Keys(q.scanMapVals(expr).byKey))
+			return nil, true
+		})
+	}
+
+	result := make(map[string]Result)
+	for _, key := range keys {
+		q := s.newQuery()
+		result["["+key+"]"] = q.result(expr, fmt.Sprintf("scanning %s[%s]", types.ExprString(expr), key), func() (map[string]constant.Value, bool) {
+			return q.scanMapVals(expr).at(key, zero)
+		})
+	}
+
+	q := s.newQuery()
+	result[otherParts] = q.result(expr, "scanning other entries of "+types.ExprString(expr), func() (map[string]constant.Value, bool) {
+		var (
+			mv       = q.scanMapVals(expr)
+			vals     = cloneVals(mv.unkeyed.vals)
+			complete = mv.unkeyed.complete
+		)
+		if interfaceKeys {
+			vals, complete = mv.all()
+		}
+		if zero == nil {
+			return vals, false
+		}
+		vals[zero.ExactString()] = zero
+		return vals, complete
+	})
+	return result
+}
+
+// cloneParts returns a copy of parts
+// whose values callers may change.
+func cloneParts(parts map[string]Result) map[string]Result {
+	if parts == nil {
+		return nil
+	}
+	result := make(map[string]Result, len(parts))
+	for k, r := range parts {
+		r.Values = maps.Clone(r.Values)
+		result[k] = r
+	}
+	return result
+}
@@ -0,0 +1,143 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"path"
+	"path/filepath"
+)
+
+// Summaries of the functions in path and path/filepath,
+// for checks built on the possible values of file paths
+// (e.g. "can the path escape the base directory?",
+// which is filepath.IsLocal of the path relative to the base).
+// The functions in path/filepath behave as on the system doing the scanning.
+
+// addPathSummaries adds the summaries for path and path/filepath to m.
+func addPathSummaries(m map[string]summary) {
+	for name, f := range map[string]func(...string) constant.Value{
+		"path.Base":  stringFunc1(path.Base),
+		"path.Clean": stringFunc1(path.Clean),
+		"path.Dir":   stringFunc1(path.Dir),
+		"path.Ext":   stringFunc1(path.Ext),
+		"path.IsAbs": boolFunc1(path.IsAbs),
+		"path.Join":  stringFuncN(path.Join),
+
+		"path/filepath.Base":    stringFunc1(filepath.Base),
+		"path/filepath.Clean":   stringFunc1(filepath.Clean),
+		"path/filepath.Dir":     stringFunc1(filepath.Dir),
+		"path/filepath.Ext":     stringFunc1(filepath.Ext),
+		"path/filepath.IsAbs":   boolFunc1(filepath.IsAbs),
+		"path/filepath.IsLocal": boolFunc1(filepath.IsLocal),
+		"path/filepath.Join":    stringFuncN(filepath.Join),
+		"path/filepath.ToSlash": stringFunc1(filepath.ToSlash),
+	} {
+		m[name] = summarizeStringFunc(f)
+	}
+	m["path/filepath.Abs"] = summarizeAbs
+}
+
+func stringFunc1(f func(string) string) func(...string) constant.Value {
+	return func(args ...string) constant.Value {
+		return constant.MakeString(f(args[0]))
+	}
+}
+
+func boolFunc1(f func(string) bool) func(...string) constant.Value {
+	return func(args ...string) constant.Value {
+		return constant.MakeBool(f(args[0]))
+	}
+}
+
+func stringFuncN(f func(...string) string) func(...string) constant.Value {
+	return func(args ...string) constant.Value {
+		return constant.MakeString(f(args...))
+	}
+}
+
+// summarizeStringFunc produces a summary for a function
+// whose arguments are strings
+// and whose single result, computed by f, depends only on them.
+func summarizeStringFunc(f func(...string) constant.Value) summary {
+	return func(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+		if idx != 0 || call.Ellipsis.IsValid() {
+			q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+			return nil, false
+		}
+
+		argVals, complete := q.scanStringArgs(call.Args)
+
+		result := make(map[string]constant.Value)
+		forEachCombination(argVals, func(args []string) {
+			v := f(args...)
+			result[v.ExactString()] = v
+		})
+		return result, complete
+	}
+}
+
+// summarizeAbs handles filepath.Abs.
+// The absolute form of a relative path depends on the working directory,
+// so only absolute paths (which are merely cleaned) have known results.
+func summarizeAbs(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	if idx != 0 || len(call.Args) != 1 {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+
+	argVals, complete := q.scanStringArgs(call.Args)
+
+	result := make(map[string]constant.Value)
+	for _, p := range argVals[0] {
+		if !filepath.IsAbs(p) {
+			q.incomplete(call, "%q is relative, so its absolute form depends on the working directory", p)
+			complete = false
+			continue
+		}
+		v := constant.MakeString(filepath.Clean(p))
+		result[v.ExactString()] = v
+	}
+	return result, complete
+}
+
+// scanStringArgs determines the possible string values of each of args.
+func (q *query) scanStringArgs(args []ast.Expr) ([][]string, bool) {
+	var (
+		result   [][]string
+		complete = true
+	)
+	for _, arg := range args {
+		vals, ok := q.scan(arg)
+		complete = complete && ok
+
+		var strs []string
+		for _, v := range vals {
+			if v.Kind() != constant.String {
+				q.incomplete(arg, "non-string value %s", v.ExactString())
+				complete = false
+				continue
+			}
+			strs = append(strs, constant.StringVal(v))
+		}
+		result = append(result, strs)
+	}
+	return result, complete
+}
+
+// forEachCombination calls f with each combination of one value from each of vals.
+func forEachCombination(vals [][]string, f func([]string)) {
+	args := make([]string, len(vals))
+	var recur func(int)
+	recur = func(i int) {
+		if i == len(vals) {
+			f(args)
+			return
+		}
+		for _, v := range vals[i] {
+			args[i] = v
+			recur(i + 1)
+		}
+	}
+	recur(0)
+}
@@ -0,0 +1,283 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// maxRangeKeys limits the number of indexes
+// that a range statement is taken to produce.
+const maxRangeKeys = 256
+
+// scanRange determines the possible values that the range statement rs
+// assigns to its key (if value is false)
+// or to its value (if value is true),
+// on any iteration.
+func (q *query) scanRange(rs *ast.RangeStmt, value bool) (map[string]constant.Value, bool) {
+	what := "key"
+	if value {
+		what = "value"
+	}
+	return q.nested(rs, what+" of range over "+types.ExprString(rs.X), func() (map[string]constant.Value, bool) {
+		typ := underlying(q.info.TypeOf(rs.X))
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = underlying(ptr.Elem())
+		}
+
+		switch typ := typ.(type) {
+		case *types.Slice, *types.Array:
+			if value {
+				return q.scanElems(rs.X).all()
+			}
+			return q.rangeIndexes(rs.X, typ)
+
+		case *types.Map:
+			if value {
+				return q.rangeMapValues(rs.X)
+			}
+			return q.scanMapKeys(rs.X)
+
+		case *types.Chan:
+			if !value {
+				return q.scanReceive(rs.X, true)
+			}
+
+		case *types.Signature:
+			idx := 0
+			if value {
+				idx = 1
+			}
+			return q.rangeFunc(rs.X, idx)
+
+		case *types.Basic:
+			switch {
+			case typ.Info()&types.IsString != 0:
+				return q.rangeString(rs.X, value)
+			case typ.Info()&types.IsInteger != 0 && !value:
+				return q.rangeInt(rs.X)
+			}
+		}
+
+		q.incomplete(rs, "unsupported range over %s", types.ExprString(rs.X))
+		return map[string]constant.Value{}, false
+	})
+}
+
+// yieldsKey identifies the arguments of the calls of yield in an iterator function in query.active.
+type yieldsKey struct {
+	body *ast.BlockStmt
+	idx  int
+}
+
+// rangeFunc determines the values produced by ranging over expr,
+// an iterator function:
+// the idx'th arguments of its calls of yield.
+// The iterator may be a function literal,
+// a function or method in the query's files,
+// or the result of calling one.
+// Iterators from slices.Values, slices.All, maps.Keys, and maps.All
+// are understood too.
+func (q *query) rangeFunc(expr ast.Expr, idx int) (map[string]constant.Value, bool) {
+	expr = ast.Unparen(expr)
+
+	if call, ok := expr.(*ast.CallExpr); ok {
+		if vals, complete, ok := q.rangeStdIter(call, idx); ok {
+			return vals, complete
+		}
+
+		callees, complete := q.callees(call.Fun)
+		if len(callees) == 0 && !complete {
+			return map[string]constant.Value{}, false
+		}
+		result := make(map[string]constant.Value)
+		for _, c := range callees {
+			q.frames = append(q.frames, newFrame(call, call.Fun, c.sig, len(q.frames), q.info))
+			q.forEachReturn(c.body, c.sig.Results().Len(), 0, func(ret *ast.ReturnStmt, expr ast.Expr, _ *ast.CallExpr) {
+				if expr == nil {
+					q.incomplete(ret, "unsupported return of iterator")
+					complete = false
+					return
+				}
+				vals, ok := q.rangeFunc(expr, idx)
+				union(result, vals)
+				complete = complete && ok
+			})
+			q.frames = q.frames[:len(q.frames)-1]
+		}
+		return result, complete
+	}
+
+	callees, complete := q.callees(expr)
+	result := make(map[string]constant.Value)
+	for _, c := range callees {
+		vals, ok := q.yieldArgs(c, idx)
+		union(result, vals)
+		complete = complete && ok
+	}
+	return result, complete
+}
+
+// yieldArgs determines the possible values of the idx'th arguments
+// of the calls of yield,
+// the function parameter of the iterator c.
+// If yield is used other than by calling it
+// (for instance, by passing it to another iterator),
+// the result is incomplete.
+func (q *query) yieldArgs(c callee, idx int) (map[string]constant.Value, bool) {
+	key := yieldsKey{body: c.body, idx: idx}
+	if !q.enter(key) {
+		return nil, true
+	}
+	defer q.leave(key)
+
+	if c.sig.Params().Len() != 1 {
+		q.incomplete(c.body, "unsupported iterator signature %s", c.sig)
+		return map[string]constant.Value{}, false
+	}
+	yield := c.sig.Params().At(0)
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+	inspectWithStack(c.body, func(n ast.Node, stack []ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || !identIsVar(ident, yield, q.info) {
+			return true
+		}
+		parent, child, _ := parentOf(ident, stack)
+		call, ok := parent.(*ast.CallExpr)
+		if !ok || call.Fun != child || idx >= len(call.Args) {
+			q.incomplete(ident, "%s is used other than by calling it", ident.Name)
+			complete = false
+			return true
+		}
+		vals, ok := q.scan(call.Args[idx])
+		union(result, vals)
+		complete = complete && ok
+		return true
+	})
+	return result, complete
+}
+
+// rangeStdIter determines the values produced by ranging over the result of call,
+// if it is a call of slices.Values, slices.All, maps.Keys, or maps.All.
+// The last result tells whether it is.
+func (q *query) rangeStdIter(call *ast.CallExpr, idx int) (map[string]constant.Value, bool, bool) {
+	fn := calleeFunc(call, q.info)
+	if fn == nil || len(call.Args) != 1 {
+		return nil, false, false
+	}
+
+	var (
+		vals     map[string]constant.Value
+		complete bool
+	)
+	switch fn.FullName() {
+	case "slices.Values":
+		vals, complete = q.scanElems(call.Args[0]).all()
+	case "slices.All":
+		if idx == 0 {
+			vals, complete = q.rangeIndexes(call.Args[0], underlying(q.info.TypeOf(call.Args[0])))
+		} else {
+			vals, complete = q.scanElems(call.Args[0]).all()
+		}
+	case "maps.Keys", "maps.All":
+		if idx == 0 {
+			vals, complete = q.scanMapKeys(call.Args[0])
+		} else {
+			vals, complete = q.rangeMapValues(call.Args[0])
+		}
+	default:
+		return nil, false, false
+	}
+	return vals, complete, true
+}
+
+// rangeIndexes determines the indexes produced by ranging over expr,
+// which has type typ,
+// a slice or array type.
+// The length of an array is known from its type,
+// and the length of a slice only if expr is a composite literal.
+func (q *query) rangeIndexes(expr ast.Expr, typ types.Type) (map[string]constant.Value, bool) {
+	n := int64(-1)
+	if arr, ok := typ.(*types.Array); ok {
+		n = arr.Len()
+	} else if lit, ok := ast.Unparen(expr).(*ast.CompositeLit); ok {
+		n = 0
+		for idx := range q.scanCompositeElems(lit).byIndex {
+			n = max(n, idx+1)
+		}
+	}
+	switch {
+	case n < 0:
+		q.incomplete(expr, "length of %s is not tracked", types.ExprString(expr))
+		return map[string]constant.Value{}, false
+	case n > maxRangeKeys:
+		q.incomplete(expr, "too many indexes of %s", types.ExprString(expr))
+		return map[string]constant.Value{}, false
+	}
+
+	result := make(map[string]constant.Value, n)
+	for i := range n {
+		v := constant.MakeInt64(i)
+		result[v.ExactString()] = v
+	}
+	return result, true
+}
+
+// rangeMapValues determines the values produced by ranging over expr,
+// which has map type.
+func (q *query) rangeMapValues(expr ast.Expr) (map[string]constant.Value, bool) {
+	return q.scanMapVals(expr).all()
+}
+
+// rangeString determines the byte offsets (if value is false)
+// or the runes (if value is true)
+// produced by ranging over expr,
+// which has string type.
+func (q *query) rangeString(expr ast.Expr, value bool) (map[string]constant.Value, bool) {
+	vals, complete := q.scan(expr)
+	result := make(map[string]constant.Value)
+	for _, val := range vals {
+		if val.Kind() != constant.String {
+			continue
+		}
+		s := constant.StringVal(val)
+		if !value && len(s) > maxRangeKeys {
+			q.incomplete(expr, "too many indexes of %s", types.ExprString(expr))
+			complete = false
+			continue
+		}
+		for i, r := range s {
+			v := constant.MakeInt64(int64(i))
+			if value {
+				v = constant.MakeInt64(int64(r))
+			}
+			result[v.ExactString()] = v
+		}
+	}
+	return result, complete
+}
+
+// rangeInt determines the values produced by ranging over expr,
+// which has integer type:
+// 0 up to but not including each of its possible values.
+func (q *query) rangeInt(expr ast.Expr) (map[string]constant.Value, bool) {
+	vals, complete := q.scan(expr)
+	result := make(map[string]constant.Value)
+	for _, val := range vals {
+		n, ok := constant.Int64Val(constant.ToInt(val))
+		if !ok || n > maxRangeKeys {
+			q.incomplete(expr, "too many iterations of range over %s", types.ExprString(expr))
+			complete = false
+			continue
+		}
+		for i := range n {
+			v := constant.MakeInt64(i)
+			result[v.ExactString()] = v
+		}
+	}
+	return result, complete
+}
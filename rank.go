@@ -0,0 +1,132 @@
+package exprvals
+
+import (
+	"go/ast"
+	"slices"
+)
+
+// Ranked is the outcome of [Scanner.Rank].
+type Ranked struct {
+	// Result holds the values of the expression,
+	// as found by [Scanner.Scan].
+	Result
+
+	// Order holds the keys of the Values,
+	// the most likely first.
+	Order []string
+}
+
+// Rank finds the values of node,
+// as [Scanner.Scan] does,
+// and orders them by how likely they are,
+// for UIs and reports that show only the first few of a large set.
+// The estimate is a simple heuristic
+// over the writers of each value
+// (see [Scanner.Writers]),
+// taking the most likely writer of each:
+//
+//   - values written explicitly come before zero values
+//     of variables declared without one;
+//   - then those written under fewer conditions
+//     (in the body of an if statement,
+//     or a case of a switch or select statement other than the default);
+//   - then those written outside loops,
+//     such as a variable's initial value,
+//     before those written in them;
+//   - then those written as constants
+//     before those computed by operations.
+//
+// Values with no writer found
+// (as for those from s.Assume)
+// come last.
+// Ties are broken by the ExactString representations of the values.
+func (s *Scanner) Rank(node ast.Expr) Ranked {
+	res := s.Scan(node)
+	ranked := Ranked{Result: res}
+
+	best := make(map[string]likelihood)
+	for _, w := range s.Writers(node).Writers {
+		l := s.likelihood(w)
+		vals, _ := s.newQuery().scan(w.Expr)
+		for k := range vals {
+			if _, ok := res.Values[k]; !ok {
+				continue
+			}
+			if prev, ok := best[k]; !ok || l.less(prev) {
+				best[k] = l
+			}
+		}
+	}
+
+	ranked.Order = exactStrings(res.Values)
+	slices.SortStableFunc(ranked.Order, func(a, b string) int {
+		la, aok := best[a]
+		lb, bok := best[b]
+		switch {
+		case aok && !bok, aok && bok && la.less(lb):
+			return -1
+		case bok && !aok, aok && bok && lb.less(la):
+			return 1
+		}
+		return 0
+	})
+	return ranked
+}
+
+// likelihood is the evidence about how likely the value of a [Writer] is,
+// for [Scanner.Rank].
+type likelihood struct {
+	zero     bool
+	guards   int
+	inLoop   bool
+	computed bool
+}
+
+// less tells whether l is more likely than other.
+func (l likelihood) less(other likelihood) bool {
+	switch {
+	case l.zero != other.zero:
+		return !l.zero
+	case l.guards != other.guards:
+		return l.guards < other.guards
+	case l.inLoop != other.inLoop:
+		return !l.inLoop
+	}
+	return !l.computed && other.computed
+}
+
+// likelihood gathers the evidence about how likely the value of w is,
+// from the statements around it in its function.
+func (s *Scanner) likelihood(w Writer) likelihood {
+	l := likelihood{zero: w.Zero}
+	if tv, ok := s.Info.Types[w.Expr]; !w.Zero && (!ok || tv.Value == nil) {
+		l.computed = true
+	}
+
+	path := pathTo(s.Files, w.Expr)
+	child := ast.Node(w.Expr)
+	for i := len(path) - 1; i >= 0; i-- {
+		switch n := path[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return l
+		case *ast.IfStmt:
+			if child == n.Body || child == n.Else {
+				l.guards++
+			}
+		case *ast.CaseClause:
+			if n.List != nil {
+				l.guards++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				l.guards++
+			}
+		case *ast.ForStmt:
+			l.inLoop = l.inLoop || child == n.Body
+		case *ast.RangeStmt:
+			l.inLoop = l.inLoop || child == n.Body
+		}
+		child = path[i]
+	}
+	return l
+}
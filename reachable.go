@@ -0,0 +1,431 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// Reachable tells whether stmt may be executed,
+// given the assumptions in s.Assume.
+// It returns false only when stmt is provably unreachable within its function:
+// because a statement before it never completes
+// (see [Scan] on calls that never return),
+// because the condition of an enclosing if, for, or switch statement
+// cannot have the value needed to reach it,
+// or because it is in a select case on a channel that is always nil.
+//
+// Conditions are evaluated with [Scan],
+// and variables compared in the conditions of enclosing statements
+// are narrowed accordingly,
+// so that in
+//
+//	if mode == "dev" {
+//	  if mode != "dev" {
+//	    log.Fatal("impossible")
+//	  }
+//	}
+//
+// the call to log.Fatal is unreachable.
+// Likewise, an interface variable that a switch compares
+// with constants of concrete types
+// holds only values of those types in the case's body,
+// so type assertions and type switches on it there
+// can fail or succeed for certain.
+//
+// Whether the function containing stmt is ever called is not considered.
+func (s *Scanner) Reachable(stmt ast.Stmt) bool {
+	if missingInfo(s.Info) != "" {
+		return true
+	}
+	return s.newQuery().reachableStmt(stmt)
+}
+
+func (q *query) reachableStmt(stmt ast.Stmt) bool {
+	path := pathTo(q.files, stmt)
+
+	// Consider only the innermost function containing stmt.
+	for i := len(path) - 1; i >= 0; i-- {
+		switch path[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			path = path[i+1:]
+			i = -1
+		}
+	}
+
+	saved := q.narrowed
+	q.narrowed = make(map[*types.Var]valSet)
+	defer func() { q.narrowed = saved }()
+
+	for i, anc := range path {
+		var child ast.Node = stmt
+		if i+1 < len(path) {
+			child = path[i+1]
+		}
+
+		switch anc := anc.(type) {
+		case *ast.BlockStmt:
+			if !slices.Contains(q.reachable(anc.List), child.(ast.Stmt)) {
+				return false
+			}
+
+		case *ast.CaseClause:
+			if s, ok := child.(ast.Stmt); ok && !slices.Contains(q.reachable(anc.Body), s) {
+				return false
+			}
+
+		case *ast.CommClause:
+			if s, ok := child.(ast.Stmt); ok && !slices.Contains(q.reachable(anc.Body), s) {
+				return false
+			}
+
+		case *ast.IfStmt:
+			switch child {
+			case anc.Body:
+				if !q.mayBe(anc.Cond, true) {
+					return false
+				}
+				q.narrow(anc.Cond, true, anc.Body)
+
+			case anc.Else:
+				if !q.mayBe(anc.Cond, false) {
+					return false
+				}
+				q.narrow(anc.Cond, false, anc.Else)
+			}
+
+		case *ast.ForStmt:
+			if child == anc.Body && anc.Cond != nil {
+				if !q.mayBe(anc.Cond, true) {
+					return false
+				}
+				// The post statement runs between evaluations of the condition too.
+				q.narrow(anc.Cond, true, anc)
+			}
+
+		case *ast.SwitchStmt:
+			if child != anc.Body || i+2 >= len(path) {
+				continue
+			}
+			clause, ok := path[i+2].(*ast.CaseClause)
+			if !ok {
+				continue
+			}
+			if !q.clauseReachable(anc, clause) {
+				return false
+			}
+
+		case *ast.SelectStmt:
+			if child != anc.Body || i+2 >= len(path) {
+				continue
+			}
+			if clause, ok := path[i+2].(*ast.CommClause); ok && !q.commReachable(clause) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// mayBe tells whether the boolean expression cond may have the value truth.
+func (q *query) mayBe(cond ast.Expr, truth bool) bool {
+	vals, complete := q.scan(cond)
+	if !complete {
+		return true
+	}
+	v := constant.MakeBool(truth)
+	_, ok := vals[v.ExactString()]
+	return ok
+}
+
+// clauseReachable tells whether the given clause of sw may be chosen,
+// narrowing the switch tag, and the dynamic type of an interface tag, if possible.
+func (q *query) clauseReachable(sw *ast.SwitchStmt, clause *ast.CaseClause) bool {
+	if sw.Tag == nil {
+		if clause.List == nil {
+			return true
+		}
+		for _, expr := range clause.List {
+			if q.mayBe(expr, true) {
+				return true
+			}
+		}
+		return false
+	}
+
+	tagVals, tagComplete := q.scan(sw.Tag)
+
+	if clause.List == nil {
+		// The default clause is reachable
+		// unless every possible value of the tag is matched by some case.
+		if !tagComplete {
+			return true
+		}
+		var caseVals []constant.Value
+		for _, c := range sw.Body.List {
+			for _, expr := range c.(*ast.CaseClause).List {
+				vals, complete := q.scan(expr)
+				if !complete {
+					return true
+				}
+				for _, v := range vals {
+					caseVals = append(caseVals, v)
+				}
+			}
+		}
+		for _, t := range tagVals {
+			if !slices.ContainsFunc(caseVals, func(v constant.Value) bool { return valuesEqual(t, v) }) {
+				return true
+			}
+		}
+		return false
+	}
+
+	matched := make(map[string]constant.Value)
+	for _, expr := range clause.List {
+		vals, ok := q.scan(expr)
+		if !ok {
+			return true
+		}
+		for _, v := range vals {
+			if !tagComplete {
+				matched[v.ExactString()] = v
+				continue
+			}
+			for _, t := range tagVals {
+				if valuesEqual(t, v) {
+					matched[t.ExactString()] = t
+				}
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return !tagComplete
+	}
+	if ident, ok := ast.Unparen(sw.Tag).(*ast.Ident); ok {
+		if v, ok := q.info.ObjectOf(ident).(*types.Var); ok && q.canNarrow(v, clause) {
+			q.narrowed[v.Origin()] = valSet{vals: matched, complete: true, dynTypes: q.caseTypes(v, clause)}
+		}
+	}
+	return true
+}
+
+// caseTypes returns the types of the expressions of clause,
+// a clause of a switch on the interface variable v,
+// which are the possible dynamic types of v when the clause is chosen.
+// The result is nil if v is not an interface
+// or if any of the expressions is itself an interface,
+// whose dynamic type is not known.
+func (q *query) caseTypes(v *types.Var, clause *ast.CaseClause) []types.Type {
+	if !isInterface(v.Type()) {
+		return nil
+	}
+	var result []types.Type
+	for _, expr := range clause.List {
+		typ := q.info.TypeOf(expr)
+		if typ == nil || types.IsInterface(typ) {
+			return nil
+		}
+		// An untyped constant is converted to its default type.
+		typ = types.Default(typ)
+		if !slices.ContainsFunc(result, func(t types.Type) bool { return types.Identical(t, typ) }) {
+			result = append(result, typ)
+		}
+	}
+	return result
+}
+
+// narrow records what is known about the variables in cond
+// when cond has the value truth,
+// for use within branch.
+func (q *query) narrow(cond ast.Expr, truth bool, branch ast.Node) {
+	switch cond := ast.Unparen(cond).(type) {
+	case *ast.UnaryExpr:
+		if cond.Op == token.NOT {
+			q.narrow(cond.X, !truth, branch)
+		}
+
+	case *ast.Ident:
+		v, ok := q.info.ObjectOf(cond).(*types.Var)
+		if !ok || !q.canNarrow(v, branch) {
+			return
+		}
+		b := constant.MakeBool(truth)
+		q.narrowed[v.Origin()] = valSet{vals: map[string]constant.Value{b.ExactString(): b}, complete: true}
+
+	case *ast.BinaryExpr:
+		switch cond.Op {
+		case token.LAND:
+			if truth {
+				q.narrow(cond.X, true, branch)
+				q.narrow(cond.Y, true, branch)
+			}
+			return
+		case token.LOR:
+			if !truth {
+				q.narrow(cond.X, false, branch)
+				q.narrow(cond.Y, false, branch)
+			}
+			return
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		default:
+			return
+		}
+
+		op, ident, other := cond.Op, ast.Unparen(cond.X), cond.Y
+		if _, ok := ident.(*ast.Ident); !ok {
+			op, ident, other = swapComparison(op), ast.Unparen(cond.Y), cond.X
+		}
+		id, ok := ident.(*ast.Ident)
+		if !ok {
+			return
+		}
+		v, ok := q.info.ObjectOf(id).(*types.Var)
+		if !ok || !q.canNarrow(v, branch) {
+			return
+		}
+
+		otherVals, otherComplete := q.scan(other)
+		if !otherComplete {
+			return
+		}
+		vVals, vComplete := q.scan(id)
+		if !vComplete && (op == token.EQL) == truth && (op == token.EQL || op == token.NEQ) {
+			// v equals one of the other values.
+			q.narrowed[v.Origin()] = valSet{vals: cloneVals(otherVals), complete: true}
+			return
+		}
+		// Otherwise keep the values of v that satisfy the condition.
+		// If those are incomplete,
+		// the ones left out may still be there,
+		// but the ones ruled out are not,
+		// as in the clamping idiom
+		//
+		//	if n > 100 {
+		//	  n = 100
+		//	}
+		//
+		// where afterwards n is not any value above 100 that was found for it.
+
+		want := constant.MakeBool(truth)
+		result := make(map[string]constant.Value)
+		for k, x := range vVals {
+			for _, y := range otherVals {
+				r, ok := binaryOp(x, op, y, nil)
+				if !ok || r == nil || valuesEqual(r, want) {
+					result[k] = x
+					break
+				}
+			}
+		}
+		q.narrowed[v.Origin()] = valSet{vals: result, complete: vComplete}
+	}
+}
+
+func swapComparison(op token.Token) token.Token {
+	switch op {
+	case token.LSS:
+		return token.GTR
+	case token.GTR:
+		return token.LSS
+	case token.LEQ:
+		return token.GEQ
+	case token.GEQ:
+		return token.LEQ
+	}
+	return op
+}
+
+// canNarrow tells whether what is known about v from a condition
+// still holds throughout branch:
+// v is a local variable,
+// branch does not assign to it,
+// and it is not assigned in a function literal or through a pointer.
+// In [Scanner.AnalyzeFunc],
+// which follows assignments statement by statement,
+// it is enough for v to be one of the variables so followed.
+func (q *query) canNarrow(v *types.Var, branch ast.Node) bool {
+	v = v.Origin()
+	if q.tracked != nil {
+		return q.tracked[v]
+	}
+	if v.Pkg() == nil || v.Parent() == nil || v.Parent() == v.Pkg().Scope() {
+		return false
+	}
+	scope := findSmallestEnclosingNode(q.files, v.Parent())
+	if scope == nil {
+		return false
+	}
+
+	ok := true
+	inspectWithStack(scope, func(n ast.Node, stack []ast.Node) bool {
+		if !ok {
+			return false
+		}
+		var targets []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			targets = n.Lhs
+		case *ast.IncDecStmt:
+			targets = []ast.Expr{n.X}
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				targets = []ast.Expr{n.Key, n.Value}
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && exprIsVar(n.X, v, q.info) {
+				ok = false
+			}
+			return ok
+		default:
+			return true
+		}
+		for _, target := range targets {
+			if target == nil || !exprIsVar(target, v, q.info) {
+				continue
+			}
+			if nodeContains(branch, n) || slices.ContainsFunc(stack, isFuncLit) {
+				ok = false
+			}
+		}
+		return ok
+	})
+	return ok
+}
+
+func isFuncLit(n ast.Node) bool {
+	_, ok := n.(*ast.FuncLit)
+	return ok
+}
+
+// pathTo returns the ancestors of node in files,
+// outermost first.
+func pathTo(files []*ast.File, node ast.Node) []ast.Node {
+	for _, file := range files {
+		if !nodeContains(file, node) {
+			continue
+		}
+		var (
+			path  []ast.Node
+			found bool
+		)
+		inspectWithStack(file, func(n ast.Node, stack []ast.Node) bool {
+			if found || !nodeContains(n, node) {
+				return false
+			}
+			if n == node {
+				path = slices.Clone(stack)
+				found = true
+				return false
+			}
+			return true
+		})
+		if found {
+			return path
+		}
+	}
+	return nil
+}
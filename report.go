@@ -0,0 +1,465 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ReportEntry is the value set of one variable, parameter, or function result,
+// as produced by [Report].
+type ReportEntry struct {
+	// Kind is "var", "param", or "result".
+	Kind string
+
+	// Func is the name of the enclosing function,
+	// as in [types.Func.FullName],
+	// or "" for a package-level variable.
+	Func string
+
+	// Name is the name of the variable or parameter,
+	// or for a result, its name (if it has one) or its index.
+	Name string
+
+	// Pos is the position of the declaration,
+	// as adjusted by //line directives,
+	// so that in generated code it points into the source it was generated from.
+	Pos token.Position
+
+	// Generated tells whether the declaration is in a generated file
+	// (see [ast.IsGenerated]).
+	Generated bool
+
+	// Values holds the representations of the possible values,
+	// as rendered by [FormatValue]
+	// (by default, their ExactString representations),
+	// sorted by their ExactString representations.
+	Values []string
+
+	Complete bool
+
+	// Truncated tells whether the values were truncated
+	// (see [Scanner.MaxValues]).
+	Truncated bool
+}
+
+// Report determines the possible values
+// of every variable, parameter, and function result in pkg
+// that has a basic type
+// (such as a string, number, or boolean, or a named type based on one).
+// It is meant for offline analysis, metrics, and code-understanding tools.
+// If maxValues is positive,
+// it limits the size of value sets,
+// as in [Scanner.MaxValues].
+//
+// The entries are in source order,
+// except that a function's results come before its other entries.
+func Report(pkg *Package, maxValues int) []ReportEntry {
+	return report(&Scanner{Files: pkg.Files, Info: pkg.Info, MaxValues: maxValues}, pkg, false, FormatOptions{})
+}
+
+// report produces the entries for [Report] and [ReportPackages],
+// leaving out those in generated files if skipGenerated is true
+// and rendering the values according to format.
+func report(s *Scanner, pkg *Package, skipGenerated bool, format FormatOptions) []ReportEntry {
+	var (
+		result    []ReportEntry
+		generated bool
+	)
+	add := func(kind string, fn *types.Func, name string, pos token.Pos, typ types.Type, res Result) {
+		if generated && skipGenerated {
+			return
+		}
+		entry := ReportEntry{
+			Kind:      kind,
+			Name:      name,
+			Pos:       pkg.Fset.Position(pos),
+			Generated: generated,
+			Values:    formatValues(res.Values, typ, format),
+			Complete:  res.Complete,
+			Truncated: res.Truncated,
+		}
+		if fn != nil {
+			entry.Func = fn.FullName()
+		}
+		result = append(result, entry)
+	}
+
+	for _, file := range pkg.Files {
+		generated = ast.IsGenerated(file)
+		for _, decl := range file.Decls {
+			var (
+				fn     *types.Func
+				params = make(map[*ast.Ident]bool) // including receivers
+			)
+			if fdecl, ok := decl.(*ast.FuncDecl); ok {
+				fn, _ = pkg.Info.Defs[fdecl.Name].(*types.Func)
+				if fn != nil && fdecl.Body != nil {
+					s.reportResults(fn, fdecl, add)
+				}
+				if fdecl.Recv != nil {
+					for _, field := range fdecl.Recv.List {
+						for _, name := range field.Names {
+							params[name] = true
+						}
+					}
+				}
+			}
+			if generated && skipGenerated {
+				// The results of fn are established (see reportResults),
+				// but nothing else is needed.
+				continue
+			}
+
+			ast.Inspect(decl, func(n ast.Node) bool {
+				if ft, ok := n.(*ast.FuncType); ok {
+					for _, field := range ft.Params.List {
+						for _, name := range field.Names {
+							params[name] = true
+						}
+					}
+					return true
+				}
+
+				ident, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				v, ok := pkg.Info.Defs[ident].(*types.Var)
+				if !ok || v.IsField() || ident.Name == "_" || !isBasic(v.Type()) {
+					return true
+				}
+				if fn != nil && tupleHas(fn.Signature().Results(), v) {
+					// Reported by reportResults.
+					return true
+				}
+				kind := "var"
+				if params[ident] {
+					kind = "param"
+				}
+				add(kind, fn, ident.Name, ident.Pos(), v.Type(), s.Scan(ident))
+				return true
+			})
+		}
+	}
+
+	return result
+}
+
+// reportResults adds a report entry for each basic-typed result of fn,
+// declared by decl.
+// Its values are those fn may return to any caller,
+// including those left by deferred functions
+// and by recovering from a panic.
+func (s *Scanner) reportResults(fn *types.Func, decl *ast.FuncDecl, add func(string, *types.Func, string, token.Pos, types.Type, Result)) {
+	results := fn.Signature().Results()
+	for i := 0; i < results.Len(); i++ {
+		r := results.At(i)
+		if !isBasic(r.Type()) {
+			continue
+		}
+
+		q := s.newQuery()
+		res := q.result(decl, fmt.Sprintf("scanning result %d of %s", i, fn.FullName()), func() (map[string]constant.Value, bool) {
+			return q.scanFuncResult(nil, nil, callee{sig: fn.Signature(), body: decl.Body}, i)
+		})
+
+		if res.Complete {
+			s.facts.add(fn, i, res.Values)
+		}
+
+		name := r.Name()
+		if name == "" || name == "_" {
+			name = strconv.Itoa(i)
+		}
+		add("result", fn, name, r.Pos(), r.Type(), res)
+	}
+}
+
+// returnResults scans result i of each reachable return statement
+// of the function declared by decl.
+func (s *Scanner) returnResults(decl *ast.FuncDecl, i int) []Result {
+	var names []*ast.Ident
+	if decl.Type.Results != nil {
+		for _, field := range decl.Type.Results.List {
+			names = append(names, field.Names...)
+		}
+	}
+
+	var result []Result
+	s.newQuery().forEachReturn(decl.Body, decl.Type.Results.NumFields(), i, func(_ *ast.ReturnStmt, expr ast.Expr, call *ast.CallExpr) {
+		switch {
+		case expr != nil:
+			result = append(result, s.Scan(expr))
+		case call != nil:
+			result = append(result, s.ScanCallResult(call, i))
+		default:
+			// A bare return of a named result.
+			result = append(result, s.Scan(names[i]))
+		}
+	})
+	return result
+}
+
+// tupleHas tells whether v is one of the variables in t.
+func tupleHas(t *types.Tuple, v *types.Var) bool {
+	for i := 0; i < t.Len(); i++ {
+		if t.At(i) == v {
+			return true
+		}
+	}
+	return false
+}
+
+func isBasic(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Basic)
+	return ok
+}
+
+// exactStrings returns the keys of vals, sorted.
+func exactStrings(vals map[string]constant.Value) []string {
+	result := make([]string, 0, len(vals))
+	for k := range vals {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ReportOptions configures [ReportPackages].
+type ReportOptions struct {
+	// MaxValues, if positive,
+	// limits the size of value sets,
+	// as in [Scanner.MaxValues].
+	MaxValues int
+
+	// Workers is the number of packages to analyze at a time,
+	// or if it is not positive,
+	// the number of CPUs.
+	Workers int
+
+	// Progress, if non-nil,
+	// is called as each package is finished,
+	// with the number of packages finished so far.
+	// Calls to Progress do not overlap.
+	Progress func(pkg *Package, done int)
+
+	// Detail tells how much to retain about the results of the functions
+	// in the packages matching each pattern
+	// (see [SummaryDetail]).
+	// The first matching rule applies.
+	// Packages matching none retain full detail.
+	// Reducing the detail for the packages of others,
+	// such as vendored ones,
+	// saves memory at the cost of precision in the packages that import them.
+	Detail []DetailRule
+
+	// Stats, if non-nil,
+	// is filled in with the sizes of the retained results.
+	Stats *SummaryStats
+
+	// Summaries, if set,
+	// receives the retained results as they are established,
+	// and supplies those of functions not in pkgs
+	// (see [SummaryCache]).
+	Summaries SummaryCache
+
+	// SkipGenerated leaves out the entries in generated files
+	// (see [ast.IsGenerated]).
+	// The results of their functions are still established,
+	// for the sake of the packages that call them.
+	SkipGenerated bool
+
+	// Format tells how to render the values in the entries
+	// (see [FormatValue]).
+	Format FormatOptions
+}
+
+// SummaryStats gives the sizes of the function results
+// retained by [ReportPackages].
+type SummaryStats struct {
+	// Bytes maps the import path of each package
+	// to the approximate number of bytes retained for its functions.
+	Bytes map[string]int
+}
+
+// ReportPackages is like calling [Report] on each of pkgs,
+// but analyzes several packages at a time
+// (see [ReportOptions]),
+// each one after the packages it imports.
+// The results of functions that the analysis of a package determines completely
+// are then known when analyzing the packages that import it,
+// where otherwise the functions' bodies would be out of view.
+//
+// The result holds the entries for pkgs[i] at index i.
+func ReportPackages(pkgs []*Package, opts ReportOptions) [][]ReportEntry {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var (
+		result   = make([][]ReportEntry, len(pkgs))
+		finished = make(map[*Package]chan struct{}, len(pkgs))
+		sem      = make(chan struct{}, workers)
+		f        = &facts{results: make(map[*types.Func][]*factResult), rules: opts.Detail, bytes: make(map[string]int), cache: opts.Summaries}
+		wg       sync.WaitGroup
+		mu       sync.Mutex // protects ndone and serializes calls to opts.Progress
+		ndone    int
+	)
+	for _, pkg := range pkgs {
+		finished[pkg] = make(chan struct{})
+	}
+
+	for i, pkg := range pkgs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(finished[pkg])
+
+			for _, imp := range pkg.Imports {
+				if ch, ok := finished[imp]; ok {
+					<-ch
+				}
+			}
+
+			sem <- struct{}{}
+			s := &Scanner{Files: pkg.Files, Info: pkg.Info, MaxValues: opts.MaxValues, facts: f}
+			result[i] = report(s, pkg, opts.SkipGenerated, opts.Format)
+			<-sem
+
+			mu.Lock()
+			defer mu.Unlock()
+			ndone++
+			if opts.Progress != nil {
+				opts.Progress(pkg, ndone)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if opts.Stats != nil {
+		opts.Stats.Bytes = f.bytes
+	}
+
+	return result
+}
+
+// facts holds the results of functions
+// established by the analysis of their packages,
+// for use in the analysis of other packages
+// (see [ReportPackages]).
+// It is safe for concurrent use.
+// A nil *facts holds nothing.
+type facts struct {
+	mu sync.RWMutex
+
+	// results maps a function to what is retained about each of its results,
+	// nil for those not completely known.
+	results map[*types.Func][]*factResult
+
+	// rules tell how much to retain about the functions of each package.
+	rules []DetailRule
+
+	// bytes maps a package's import path
+	// to the approximate size of what is retained for its functions.
+	bytes map[string]int
+
+	// cache, if set,
+	// is also given what is retained,
+	// and consulted for the functions that results lacks.
+	cache SummaryCache
+}
+
+// add records vals as the complete set of values of fn's result idx,
+// at the detail that f's rules give for fn's package.
+// Since callers in other packages rely on vals,
+// it must account for deferred assignments to the result
+// and for recovery from panics,
+// as [query.scanFuncResult] does.
+func (f *facts) add(fn *types.Func, idx int, vals map[string]constant.Value) {
+	if f == nil || fn.Pkg() == nil {
+		return
+	}
+
+	r := newFactResult(vals, detailFor(f.rules, fn.Pkg().Path()))
+	if r == nil {
+		return
+	}
+
+	if f.cache != nil {
+		f.cache.Put(summaryKey(fn, idx), encodeFactResult(r))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := f.results[fn]
+	if res == nil {
+		res = make([]*factResult, fn.Signature().Results().Len())
+		f.results[fn] = res
+	}
+	f.bytes[fn.Pkg().Path()] += r.size() - res[idx].size()
+	res[idx] = r
+}
+
+// lookup returns what is retained about fn's result idx,
+// or nil if nothing is.
+// Entries in f.cache that cannot be decoded are ignored.
+func (f *facts) lookup(fn *types.Func, idx int) *factResult {
+	f.mu.RLock()
+	res := f.results[fn]
+	f.mu.RUnlock()
+	if res != nil {
+		if idx >= len(res) {
+			return nil
+		}
+		return res[idx]
+	}
+
+	if f.cache == nil {
+		return nil
+	}
+	data, ok := f.cache.Get(summaryKey(fn, idx))
+	if !ok {
+		return nil
+	}
+	r, err := decodeFactResult(data)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// fact returns the values of the idx'th result of call from q.facts,
+// if anything is retained about them
+// and the body of the called function is not among q.files.
+// (If it is, scanning it,
+// with the call's arguments bound to its parameters,
+// may give more precise results.)
+// The last result tells whether that is the case,
+// and the others give the values and whether they are complete.
+func (q *query) fact(call *ast.CallExpr, idx int) (map[string]constant.Value, bool, bool) {
+	if q.facts == nil {
+		return nil, false, false
+	}
+	fn := calleeFunc(call, q.info)
+	if fn == nil || funcBody(fn, q.files) != nil {
+		return nil, false, false
+	}
+	r := q.facts.lookup(fn.Origin(), idx)
+	switch {
+	case r == nil:
+		return nil, false, false
+	case r.detail == SummaryHash:
+		q.incomplete(call, "only a fingerprint of the %d values of result %d of %s is retained", r.n, idx, fn.FullName())
+		return nil, false, true
+	}
+	return cloneVals(r.vals), true, true
+}
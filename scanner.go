@@ -0,0 +1,396 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scanner scans expressions in a set of type-checked files.
+// Files and Info must be set
+// (but see "Type information" in the package documentation
+// for what Info needs to contain);
+// the remaining fields are options.
+//
+// Unlike the package-level [Scan] and [ScanCallResult],
+// the methods of Scanner return a [Result],
+// which explains any incompleteness.
+//
+// A Scanner caches the results of Scan and ScanCallResult.
+// The cache is discarded when summaries are registered with [RegisterSummary].
+// Call [Scanner.Invalidate] after changing any of the Scanner's fields
+// (or the files or type information they refer to).
+type Scanner struct {
+	Files []*ast.File
+	Info  *types.Info
+
+	// Assume, if set, maps variables and struct fields
+	// to values they are assumed to have,
+	// for exploring how the results change under different configurations
+	// (e.g. "assume cfg.Debug is true").
+	// For a parameter, the assumption replaces the values of its arguments,
+	// but assignments to the parameter within the function still count.
+	// For other variables and for struct fields,
+	// the assumption gives the possible values outright,
+	// and the result of scanning them is complete.
+	Assume map[*types.Var][]constant.Value
+
+	// MaxValues, if positive,
+	// bounds the number of values kept for any expression or variable,
+	// so that scanning large programs
+	// (e.g. with [Report])
+	// does not exhaust memory.
+	// A set of values that would be larger is truncated,
+	// keeping the MaxValues values whose ExactString representations sort first,
+	// and is incomplete;
+	// the result's Truncated field is set.
+	MaxValues int
+
+	// Timeout, if positive,
+	// bounds the time spent on each query
+	// (each call to Scan, ScanCallResult, and so on),
+	// for interactive uses that cannot wait indefinitely.
+	// When it expires,
+	// the query returns the values found so far,
+	// the result is incomplete,
+	// and its TimedOut field is set.
+	// Timed-out results are not cached.
+	Timeout time.Duration
+
+	// UnknownCalls is a dial between optimism and soundness
+	// for calls to functions whose bodies are not among Files.
+	// By default they are assumed to return normally.
+	UnknownCalls UnknownCalls
+
+	// Audit, if true,
+	// double-checks each complete result
+	// against uses of the local variables it depends on
+	// that could change them in ways the analysis does not see:
+	// taking their addresses,
+	// assigning them in goroutines,
+	// or assigning them in function literals that may run at any time,
+	// where the conclusions drawn from conditions on them may not hold.
+	// A result that depends on any such variable is made incomplete,
+	// with reasons beginning "audit:".
+	// Auditing trades precision for confidence
+	// when the results must not be wrong.
+	Audit bool
+
+	// Join, if set,
+	// combines the possible values of variables
+	// where control-flow paths meet in [Scanner.AnalyzeFunc]
+	// (and so [Scanner.History]),
+	// in place of the default [UnionJoin],
+	// for trading the precision of the analysis for its speed,
+	// as with [KLimitJoin],
+	// or for domain-specific joins.
+	Join JoinFunc
+
+	// Summaries, if set,
+	// supplies the results of functions whose bodies are not among Files,
+	// as established by [ReportPackages] with [ReportOptions.Summaries]
+	// (see [SummaryCache]).
+	Summaries SummaryCache
+
+	// Intraprocedural, if true,
+	// confines the analysis to the function containing the expression scanned:
+	// calls of other functions are opaque,
+	// as if their bodies were not among Files,
+	// and no results of functions are taken from [ReportPackages] or Summaries.
+	// Function literals called in that function are still followed,
+	// as are the models of standard library functions
+	// (see [RegisterSummary]).
+	// That makes results fast and predictable,
+	// as editors need,
+	// and lets them be compared with those of the full analysis.
+	Intraprocedural bool
+
+	// facts, if set, holds the results of functions in other packages
+	// (see [ReportPackages]).
+	facts *facts
+
+	// survey, if set, records the syntax that scans examine
+	// (see [Survey]).
+	survey *survey
+
+	mu           sync.Mutex
+	cache        map[cacheKey]Result
+	cacheVersion uint64
+}
+
+// cacheKey identifies a result in Scanner.cache.
+type cacheKey struct {
+	node ast.Node
+	idx  int // for ScanCallResult; -1 for Scan, -2 for MapKeys
+}
+
+// Result is the outcome of a scan by a [Scanner].
+type Result struct {
+	// Values holds the possible values,
+	// keyed by their ExactString representation.
+	Values map[string]constant.Value
+
+	// Complete tells whether Values holds all the possible values.
+	Complete bool
+
+	// Why explains what made the result incomplete.
+	// It is nil when Complete is true.
+	Why *Reason
+
+	// Truncated tells whether some set of values,
+	// for this expression or one it depends on,
+	// was truncated because of [Scanner.MaxValues].
+	Truncated bool
+
+	// TimedOut tells whether the scan was cut short by [Scanner.Timeout].
+	TimedOut bool
+
+	// Parts holds the results for the parts of a struct, array, slice, or map value
+	// whose values are tracked separately,
+	// so that what is unknown about one part
+	// does not make the others incomplete.
+	// The keys are .F for field F
+	// (and .F.G for field G of struct field F),
+	// [i] for the element at constant index i,
+	// [k] for the map entry with the key whose ExactString representation is k,
+	// and [...] for the elements or entries at all other indexes or keys.
+	// Only parts of basic type are given.
+	// Parts is nil for values of other types.
+	Parts map[string]Result
+
+	// key identifies the scan that produced the result,
+	// for [Scanner.Explain].
+	key cacheKey
+}
+
+// Reason explains why a scan is incomplete.
+// Reasons form a tree.
+// The causes of a reason are the nested scans
+// (of variables, calls, and so on)
+// that were themselves incomplete,
+// down to the specific code that could not be analyzed.
+type Reason struct {
+	// Pos is the position of the code the reason is about.
+	Pos token.Pos
+
+	// Msg describes the reason.
+	Msg string
+
+	// Causes are the reasons for this one.
+	Causes []*Reason
+}
+
+// Format renders the reason tree as text,
+// one reason per line,
+// with causes indented beneath the reasons they explain.
+// If fset is non-nil,
+// each line begins with the position of the reason.
+func (r *Reason) Format(fset *token.FileSet) string {
+	buf := new(strings.Builder)
+	r.format(buf, fset, 0)
+	return buf.String()
+}
+
+func (r *Reason) format(buf *strings.Builder, fset *token.FileSet, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	if fset != nil && r.Pos.IsValid() {
+		fmt.Fprintf(buf, "%s: ", fset.Position(r.Pos))
+	}
+	buf.WriteString(r.Msg)
+	buf.WriteByte('\n')
+	for _, c := range r.Causes {
+		c.format(buf, fset, depth+1)
+	}
+}
+
+// Leaves returns the reasons at the bottom of the tree rooted at r:
+// the specific code that could not be analyzed.
+func (r *Reason) Leaves() []*Reason {
+	if len(r.Causes) == 0 {
+		return []*Reason{r}
+	}
+	var result []*Reason
+	for _, c := range r.Causes {
+		result = append(result, c.Leaves()...)
+	}
+	return result
+}
+
+// Scan is like the package-level [Scan] function,
+// but returns a [Result].
+func (s *Scanner) Scan(node ast.Expr) Result {
+	return s.cached(cacheKey{node: node, idx: -1}, func() Result {
+		q := s.newQuery()
+		res := q.result(node, "scanning "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+			return q.scan(node)
+		})
+		res.Parts = s.parts(node)
+		return res
+	})
+}
+
+// ScanCallResult is like the package-level [ScanCallResult] function,
+// but returns a [Result].
+func (s *Scanner) ScanCallResult(call *ast.CallExpr, idx int) Result {
+	return s.cached(cacheKey{node: call, idx: idx}, func() Result {
+		q := s.newQuery()
+		return q.result(call, fmt.Sprintf("scanning result %d of %s", idx, types.ExprString(call)), func() (map[string]constant.Value, bool) {
+			return q.scanCallResult(call, idx)
+		})
+	})
+}
+
+// Invalidate discards the Scanner's cached results.
+func (s *Scanner) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = nil
+}
+
+// cached returns the cached result for key,
+// computing it with f if necessary.
+// Callers get their own copy of the values.
+func (s *Scanner) cached(key cacheKey, f func() Result) Result {
+	version := summariesVersion()
+
+	s.mu.Lock()
+	if s.cache == nil || s.cacheVersion != version {
+		s.cache = make(map[cacheKey]Result)
+		s.cacheVersion = version
+	}
+	res, ok := s.cache[key]
+	s.mu.Unlock()
+
+	if !ok {
+		res = f()
+		s.mu.Lock()
+		if s.cache != nil && s.cacheVersion == version && !res.TimedOut {
+			s.cache[key] = res
+		}
+		s.mu.Unlock()
+	}
+
+	res.Values = maps.Clone(res.Values)
+	res.Parts = cloneParts(res.Parts)
+	res.key = key
+	return res
+}
+
+func (s *Scanner) newQuery() *query {
+	q := newQuery(s.Files, s.Info)
+	for v, vals := range s.Assume {
+		m := make(map[string]constant.Value, len(vals))
+		for _, val := range vals {
+			m[val.ExactString()] = val
+		}
+		q.assume[v.Origin()] = m
+	}
+	q.maxValues = s.MaxValues
+	q.unknownCalls = s.UnknownCalls
+	q.facts = s.facts
+	q.survey = s.survey
+	if q.facts == nil && s.Summaries != nil {
+		q.facts = &facts{cache: s.Summaries}
+	}
+	if s.Intraprocedural {
+		q.intraprocedural = true
+		q.facts = nil
+	}
+	if s.Audit {
+		q.audited = make(map[*types.Var]bool)
+	}
+	if s.Timeout > 0 {
+		q.deadline = time.Now().Add(s.Timeout)
+	}
+	return q
+}
+
+// result runs f as the root of a reason tree and packages its outcome as a Result.
+func (q *query) result(node ast.Node, msg string, f func() (map[string]constant.Value, bool)) Result {
+	root := &Reason{Pos: node.Pos(), Msg: msg}
+	if missing := missingInfo(q.info); missing != "" {
+		root.Causes = []*Reason{{Pos: node.Pos(), Msg: missing}}
+		return Result{Why: root}
+	}
+
+	q.why = []*Reason{root}
+	vals, complete := f()
+	vals, complete = q.limit(root, vals, complete)
+	if complete && q.audited != nil {
+		complete = q.audit()
+	}
+	q.why = nil
+
+	res := Result{Values: vals, Complete: complete, Truncated: q.truncated, TimedOut: q.timedOut}
+	if !complete {
+		res.Why = root
+	}
+	return res
+}
+
+// nested runs f as a nested scan,
+// about node and described by msg,
+// for the purpose of building the reason tree.
+// If f's result is incomplete,
+// the reasons it records become a cause of the enclosing scan.
+// If the query's deadline has passed,
+// f is not run and the result is incomplete.
+func (q *query) nested(node ast.Node, msg string, f func() (map[string]constant.Value, bool)) (map[string]constant.Value, bool) {
+	if !q.deadline.IsZero() && time.Now().After(q.deadline) {
+		q.timedOut = true
+		q.incomplete(node, "timed out before %s", msg)
+		return nil, false
+	}
+
+	q.examine(node)
+	r := &Reason{Pos: node.Pos(), Msg: msg}
+	q.why = append(q.why, r)
+	vals, complete := q.traced(node, msg, f)
+	vals, complete = q.limit(r, vals, complete)
+	q.why = q.why[:len(q.why)-1]
+
+	if !complete && len(q.why) > 0 {
+		parent := q.why[len(q.why)-1]
+		parent.Causes = append(parent.Causes, r)
+	}
+	return vals, complete
+}
+
+// incomplete records a specific reason that the current scan is incomplete.
+func (q *query) incomplete(node ast.Node, format string, args ...any) {
+	if len(q.why) == 0 {
+		return
+	}
+	var pos token.Pos
+	if node != nil {
+		pos = node.Pos()
+		q.punt(node)
+	}
+	top := q.why[len(q.why)-1]
+	top.Causes = append(top.Causes, &Reason{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// limit truncates vals if it has more than q.maxValues values,
+// recording the reason in r.
+func (q *query) limit(r *Reason, vals map[string]constant.Value, complete bool) (map[string]constant.Value, bool) {
+	if q.maxValues <= 0 || len(vals) <= q.maxValues {
+		return vals, complete
+	}
+
+	keys := slices.Sorted(maps.Keys(vals))
+	result := make(map[string]constant.Value, q.maxValues)
+	for _, k := range keys[:q.maxValues] {
+		result[k] = vals[k]
+	}
+
+	r.Causes = append(r.Causes, &Reason{Pos: r.Pos, Msg: fmt.Sprintf("%d values exceed the limit of %d", len(vals), q.maxValues)})
+	q.truncated = true
+	return result, false
+}
@@ -0,0 +1,217 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// A source is an expression whose value,
+// unchanged, becomes the value of another.
+// See [query.sources].
+type source struct {
+	expr ast.Expr
+
+	// zero is true for a variable declared without a value,
+	// in which case expr is the variable's name in the declaration.
+	zero bool
+}
+
+// sourcesKey identifies the sources of a variable in query.active.
+type sourcesKey struct {
+	v *types.Var
+}
+
+// callSourcesKey identifies the sources of a result of a call in query.active.
+type callSourcesKey struct {
+	call *ast.CallExpr
+	idx  int
+}
+
+// sources finds the expressions whose values expr may have,
+// following (when follow allows it)
+// variables to the values assigned to them,
+// calls to the values their functions return,
+// and conversions to interface types to their operands.
+// Each expression that is not followed is passed to f.
+// The result tells whether all the sources were found.
+func (q *query) sources(expr ast.Expr, follow func(ast.Expr) bool, f func(source)) bool {
+	expr = ast.Unparen(expr)
+
+	if follow(expr) {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			if v, ok := q.info.ObjectOf(e).(*types.Var); ok {
+				return q.nestedOK(e, "variable "+e.Name, func() bool {
+					return q.varSources(v, follow, f)
+				})
+			}
+
+		case *ast.CallExpr:
+			tv, ok := q.info.Types[e.Fun]
+			switch {
+			case !ok:
+			case tv.IsType():
+				if types.IsInterface(tv.Type) && len(e.Args) == 1 {
+					return q.sources(e.Args[0], follow, f)
+				}
+			case tv.IsValue():
+				return q.nestedOK(e, "call to "+types.ExprString(e.Fun), func() bool {
+					return q.callSources(e, 0, follow, f)
+				})
+			}
+		}
+	}
+
+	f(source{expr: expr})
+	return true
+}
+
+// nestedOK is like [query.nested] for functions that produce no values.
+func (q *query) nestedOK(node ast.Node, msg string, f func() bool) bool {
+	_, ok := q.nested(node, msg, func() (map[string]constant.Value, bool) {
+		return nil, f()
+	})
+	return ok
+}
+
+// varSources finds the sources of the values of v.
+// See [query.sources].
+func (q *query) varSources(v *types.Var, follow func(ast.Expr) bool, f func(source)) bool {
+	v = v.Origin()
+
+	key := sourcesKey{v: v}
+	if !q.enter(key) {
+		return true
+	}
+	defer q.leave(key)
+
+	node := q.varScopeNode(v)
+	if node == nil {
+		return false
+	}
+
+	complete := true
+
+	if isParam(node, v, q.info) {
+		if arg, depth, ok := q.paramArg(v); ok {
+			q.inFrames(depth, func() {
+				complete = q.sources(arg, follow, f) && complete
+			})
+		} else {
+			q.incomplete(node, "%s is a parameter and its argument is unknown", v.Name())
+			complete = false
+		}
+	}
+
+	// fromCall handles a call that produces several values,
+	// as in x, err := g().
+	fromCall := func(n ast.Node, rhs ast.Expr, idx int) {
+		call, ok := ast.Unparen(rhs).(*ast.CallExpr)
+		if !ok {
+			q.incomplete(n, "unsupported assignment to %s", v.Name())
+			complete = false
+			return
+		}
+		complete = q.nestedOK(call, "call to "+types.ExprString(call.Fun), func() bool {
+			return q.callSources(call, idx, follow, f)
+		}) && complete
+	}
+
+	q.inspectReachable(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if !exprIsVar(lhs, v, q.info) {
+					continue
+				}
+				switch {
+				case n.Tok != token.ASSIGN && n.Tok != token.DEFINE:
+					q.incomplete(n, "unsupported assignment to %s", v.Name())
+					complete = false
+				case len(n.Lhs) == len(n.Rhs):
+					complete = q.sources(n.Rhs[i], follow, f) && complete
+				case len(n.Rhs) == 1:
+					fromCall(n, n.Rhs[0], i)
+				default:
+					q.incomplete(n, "unsupported assignment to %s", v.Name())
+					complete = false
+				}
+			}
+
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if !identIsVar(name, v, q.info) {
+					continue
+				}
+				switch len(n.Values) {
+				case 0:
+					f(source{expr: name, zero: true})
+				case len(n.Names):
+					complete = q.sources(n.Values[i], follow, f) && complete
+				case 1:
+					fromCall(n, n.Values[0], i)
+				default:
+					q.incomplete(n, "unsupported declaration of %s", v.Name())
+					complete = false
+				}
+			}
+
+		case *ast.RangeStmt:
+			if (n.Key != nil && exprIsVar(n.Key, v, q.info)) || (n.Value != nil && exprIsVar(n.Value, v, q.info)) {
+				q.incomplete(n, "%s is assigned by a range statement", v.Name())
+				complete = false
+			}
+
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && exprIsVar(n.X, v, q.info) {
+				q.incomplete(n, "address of %s is taken", v.Name())
+				complete = false
+			}
+		}
+		return true
+	})
+
+	return complete
+}
+
+// callSources finds the sources of the idx'th result of call.
+// See [query.sources].
+func (q *query) callSources(call *ast.CallExpr, idx int, follow func(ast.Expr) bool, f func(source)) bool {
+	key := callSourcesKey{call: call, idx: idx}
+	if !q.enter(key) {
+		return true
+	}
+	defer q.leave(key)
+
+	callees, complete := q.callees(call.Fun)
+	if len(callees) == 0 && !complete {
+		return false
+	}
+
+	for _, c := range callees {
+		results := c.sig.Results()
+		if idx >= results.Len() {
+			q.incomplete(call, "%s has no result %d", types.ExprString(call.Fun), idx)
+			complete = false
+			continue
+		}
+
+		q.frames = append(q.frames, newFrame(call, call.Fun, c.sig, len(q.frames), q.info))
+		q.forEachReturn(c.body, results.Len(), idx, func(_ *ast.ReturnStmt, expr ast.Expr, inner *ast.CallExpr) {
+			switch {
+			case expr != nil:
+				complete = q.sources(expr, follow, f) && complete
+			case inner != nil:
+				complete = q.callSources(inner, idx, follow, f) && complete
+			default:
+				// A bare return of a named result.
+				complete = q.varSources(results.At(idx), follow, f) && complete
+			}
+		})
+		q.frames = q.frames[:len(q.frames)-1]
+	}
+
+	return complete
+}
@@ -0,0 +1,245 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// squirrelPath is the import path of the squirrel SQL builder.
+const squirrelPath = "github.com/Masterminds/squirrel"
+
+// selectState is the state of a squirrel SelectBuilder tracked by a [builderWalker]:
+// the clauses it has been given.
+type selectState struct {
+	prefixes, options, columns []string
+
+	from    string
+	hasFrom bool
+
+	joins, where, groupBy, having, orderBy []string
+
+	limit, offset string
+
+	suffixes []string
+}
+
+func (s *selectState) key() string { return fmt.Sprintf("%#v", *s) }
+
+// clone returns a copy of s that shares none of its slices.
+func (s *selectState) clone() *selectState {
+	c := *s
+	for _, p := range []*[]string{&c.prefixes, &c.options, &c.columns, &c.joins, &c.where, &c.groupBy, &c.having, &c.orderBy, &c.suffixes} {
+		*p = slices.Clone(*p)
+	}
+	return &c
+}
+
+// sql renders s as SelectBuilder.ToSql does
+// (with the default, question-mark placeholders).
+// A statement without columns renders as the empty string,
+// since ToSql fails for it.
+func (s *selectState) sql() string {
+	if len(s.columns) == 0 {
+		return ""
+	}
+
+	buf := new(strings.Builder)
+	if len(s.prefixes) > 0 {
+		buf.WriteString(strings.Join(s.prefixes, " "))
+		buf.WriteString(" ")
+	}
+	buf.WriteString("SELECT ")
+	if len(s.options) > 0 {
+		buf.WriteString(strings.Join(s.options, " "))
+		buf.WriteString(" ")
+	}
+	buf.WriteString(strings.Join(s.columns, ", "))
+	if s.hasFrom {
+		buf.WriteString(" FROM " + s.from)
+	}
+	if len(s.joins) > 0 {
+		buf.WriteString(" " + strings.Join(s.joins, " "))
+	}
+	if len(s.where) > 0 {
+		buf.WriteString(" WHERE " + strings.Join(s.where, " AND "))
+	}
+	if len(s.groupBy) > 0 {
+		buf.WriteString(" GROUP BY " + strings.Join(s.groupBy, ", "))
+	}
+	if len(s.having) > 0 {
+		buf.WriteString(" HAVING " + strings.Join(s.having, " AND "))
+	}
+	if len(s.orderBy) > 0 {
+		buf.WriteString(" ORDER BY " + strings.Join(s.orderBy, ", "))
+	}
+	if s.limit != "" {
+		buf.WriteString(" LIMIT " + s.limit)
+	}
+	if s.offset != "" {
+		buf.WriteString(" OFFSET " + s.offset)
+	}
+	if len(s.suffixes) > 0 {
+		buf.WriteString(" " + strings.Join(s.suffixes, " "))
+	}
+	return buf.String()
+}
+
+// summarizeToSql summarizes SelectBuilder.ToSql and MustSql,
+// whose first result is the SQL text built up by the chain of calls producing the receiver.
+// See [newSelectWalker].
+func summarizeToSql(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || idx != 0 {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+
+	states, complete := newSelectWalker(q).walk(sel.X)
+	result := make(map[string]constant.Value)
+	for _, st := range states {
+		v := constant.MakeString(st.(*selectState).sql())
+		result[v.ExactString()] = v
+	}
+	return result, complete
+}
+
+// squirrelFunc returns the function or method of squirrel called by call,
+// or nil if it is not one of them.
+func squirrelFunc(call *ast.CallExpr, info *types.Info) *types.Func {
+	fn := calleeFunc(call, info)
+	if fn == nil || fn.Pkg() == nil || canonicalPath(fn.Pkg().Path()) != squirrelPath {
+		return nil
+	}
+	return fn
+}
+
+// newSelectWalker returns a [builderWalker] for squirrel SelectBuilders.
+// It follows squirrel.Select and the SelectBuilder methods that add clauses
+// from strings,
+// treating a Where or Having predicate that is not a string
+// (such as squirrel.Eq),
+// a non-default placeholder format,
+// and other methods as unknown.
+func newSelectWalker(q *query) *builderWalker {
+	w := &builderWalker{q: q}
+
+	w.isStep = func(call *ast.CallExpr) bool {
+		fn := squirrelFunc(call, q.info)
+		if fn == nil {
+			return false
+		}
+		results := fn.Signature().Results()
+		if results.Len() == 0 {
+			return false
+		}
+		named, ok := results.At(0).Type().(*types.Named)
+		return ok && named.Obj().Name() == "SelectBuilder"
+	}
+
+	w.step = func(call *ast.CallExpr) (map[string]builderState, bool) {
+		fn := squirrelFunc(call, q.info)
+
+		// apply applies f to each of the recv states
+		// and each combination of the values of args.
+		apply := func(recv map[string]builderState, args []ast.Expr, f func(s *selectState, vals []string)) (map[string]builderState, bool) {
+			return w.extend(call, recv, args, func(st builderState, vals []string) builderState {
+				s := st.(*selectState).clone()
+				f(s, vals)
+				return s
+			})
+		}
+
+		if fn.Signature().Recv() == nil || isStatementBuilder(call, q.info) {
+			if fn.Name() != "Select" {
+				q.incomplete(call, "unsupported call of %s", types.ExprString(call.Fun))
+				return nil, false
+			}
+			st := &selectState{}
+			return apply(map[string]builderState{st.key(): st}, call.Args, func(s *selectState, vals []string) {
+				s.columns = append(s.columns, vals...)
+			})
+		}
+
+		recv, complete := w.states(ast.Unparen(call.Fun).(*ast.SelectorExpr).X)
+
+		var (
+			states map[string]builderState
+			ok     bool
+		)
+		switch name := fn.Name(); name {
+		case "Columns", "Column":
+			states, ok = apply(recv, call.Args, func(s *selectState, vals []string) { s.columns = append(s.columns, vals...) })
+		case "Options":
+			states, ok = apply(recv, call.Args, func(s *selectState, vals []string) { s.options = append(s.options, vals...) })
+		case "Distinct":
+			states, ok = apply(recv, nil, func(s *selectState, _ []string) { s.options = append(s.options, "DISTINCT") })
+		case "From":
+			states, ok = apply(recv, call.Args, func(s *selectState, vals []string) { s.from, s.hasFrom = vals[0], true })
+		case "Join", "LeftJoin", "RightJoin", "InnerJoin", "CrossJoin":
+			kind := strings.ToUpper(strings.TrimSuffix(name, "Join"))
+			if kind != "" {
+				kind += " "
+			}
+			states, ok = apply(recv, call.Args[:1], func(s *selectState, vals []string) { s.joins = append(s.joins, kind+"JOIN "+vals[0]) })
+		case "Where", "Having":
+			if !isString(q.info.TypeOf(call.Args[0])) {
+				q.incomplete(call.Args[0], "unsupported predicate %s", types.ExprString(call.Args[0]))
+				return nil, false
+			}
+			states, ok = apply(recv, call.Args[:1], func(s *selectState, vals []string) {
+				switch {
+				case vals[0] == "":
+					// Squirrel omits empty predicates.
+				case name == "Where":
+					s.where = append(s.where, vals[0])
+				default:
+					s.having = append(s.having, vals[0])
+				}
+			})
+		case "GroupBy":
+			states, ok = apply(recv, call.Args, func(s *selectState, vals []string) { s.groupBy = append(s.groupBy, vals...) })
+		case "OrderBy":
+			states, ok = apply(recv, call.Args, func(s *selectState, vals []string) { s.orderBy = append(s.orderBy, vals...) })
+		case "Limit":
+			states, ok = apply(recv, call.Args, func(s *selectState, vals []string) { s.limit = vals[0] })
+		case "Offset":
+			states, ok = apply(recv, call.Args, func(s *selectState, vals []string) { s.offset = vals[0] })
+		case "Prefix":
+			states, ok = apply(recv, call.Args[:1], func(s *selectState, vals []string) { s.prefixes = append(s.prefixes, vals[0]) })
+		case "Suffix":
+			states, ok = apply(recv, call.Args[:1], func(s *selectState, vals []string) { s.suffixes = append(s.suffixes, vals[0]) })
+		case "RunWith":
+			states, ok = recv, true
+		default:
+			q.incomplete(call, "unsupported call of %s", types.ExprString(call.Fun))
+			return nil, false
+		}
+		return states, ok && complete
+	}
+
+	return w
+}
+
+// isStatementBuilder tells whether call is a method call on squirrel.StatementBuilder itself,
+// with the default placeholder format.
+func isStatementBuilder(call *ast.CallExpr, info *types.Info) bool {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	var ident *ast.Ident
+	switch x := ast.Unparen(sel.X).(type) {
+	case *ast.Ident:
+		ident = x
+	case *ast.SelectorExpr:
+		ident = x.Sel
+	default:
+		return false
+	}
+	v, ok := info.ObjectOf(ident).(*types.Var)
+	return ok && v.Name() == "StatementBuilder" && v.Pkg() != nil && canonicalPath(v.Pkg().Path()) == squirrelPath
+}
@@ -0,0 +1,118 @@
+// Package ssavals connects exprvals to code in SSA form,
+// as built by golang.org/x/tools/go/ssa,
+// so that tools working with [ssa.Value]s can ask about their possible values
+// without re-deriving the corresponding syntax.
+package ssavals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ssa"
+
+	"github.com/bobg/exprvals"
+)
+
+// Scan determines the possible values of v
+// by scanning the expression it comes from
+// (see [Expr])
+// with s.
+// The Scanner's files must include the one containing v's function.
+func Scan(s *exprvals.Scanner, v ssa.Value) (exprvals.Result, error) {
+	expr, ok := Expr(v, s.Files)
+	if !ok {
+		return exprvals.Result{}, fmt.Errorf("no expression found for %s", v.Name())
+	}
+	return s.Scan(expr), nil
+}
+
+// Expr finds the expression in files whose value is v.
+// If v's function was built with debug information
+// (see [ssa.Package.SetDebugMode]),
+// the correspondence is exact.
+// Otherwise v is matched by position,
+// which works for values that come from expressions,
+// such as calls, operations, and loads of variables,
+// but not for values that the SSA builder synthesizes.
+func Expr(v ssa.Value, files []*ast.File) (ast.Expr, bool) {
+	if fn := v.Parent(); fn != nil {
+		if syntax := fn.Syntax(); syntax != nil {
+			var found ast.Expr
+			ast.Inspect(syntax, func(n ast.Node) bool {
+				if found != nil {
+					return false
+				}
+				if e, ok := n.(ast.Expr); ok {
+					if val, _ := fn.ValueForExpr(e); val == v {
+						found = e
+						return false
+					}
+				}
+				return true
+			})
+			if found != nil {
+				return found, true
+			}
+		}
+	}
+
+	pos := v.Pos()
+	if !pos.IsValid() {
+		return nil, false
+	}
+	for _, file := range files {
+		if pos < file.FileStart || pos > file.FileEnd {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+		for _, n := range path {
+			if e, ok := n.(ast.Expr); ok && ssaPos(e) == pos {
+				return e, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Value finds the SSA value of expr,
+// which must be in the syntax of fn.
+// The function must have been built with debug information
+// (see [ssa.Package.SetDebugMode]).
+func Value(fn *ssa.Function, expr ast.Expr) (ssa.Value, bool) {
+	v, _ := fn.ValueForExpr(expr)
+	return v, v != nil
+}
+
+// ssaPos returns the position that the SSA builder gives to the value of expr,
+// as documented for [ssa.Value.Pos].
+func ssaPos(expr ast.Expr) token.Pos {
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		return e.Lparen
+	case *ast.BinaryExpr:
+		return e.OpPos
+	case *ast.UnaryExpr:
+		return e.OpPos
+	case *ast.StarExpr:
+		return e.Star
+	case *ast.IndexExpr:
+		return e.Lbrack
+	case *ast.IndexListExpr:
+		return e.Lbrack
+	case *ast.SliceExpr:
+		return e.Lbrack
+	case *ast.SelectorExpr:
+		return e.Sel.Pos()
+	case *ast.CompositeLit:
+		return e.Lbrace
+	case *ast.TypeAssertExpr:
+		return e.Lparen
+	case *ast.FuncLit:
+		return e.Type.Func
+	case *ast.ParenExpr:
+		return token.NoPos
+	}
+	return expr.Pos()
+}
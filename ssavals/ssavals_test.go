@@ -0,0 +1,77 @@
+package ssavals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+
+	"github.com/bobg/exprvals"
+)
+
+func TestScan(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		mode := ssa.BuilderMode(0)
+		if debug {
+			mode = ssa.GlobalDebug
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "testdata/mode.go", nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := []*ast.File{file}
+		pkg := types.NewPackage("main", "main")
+		ssaPkg, info, err := ssautil.BuildPackage(&types.Config{Importer: importer.Default()}, fset, pkg, files, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fn := ssaPkg.Func("f")
+		var ret *ssa.Return
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if r, ok := instr.(*ssa.Return); ok {
+					ret = r
+				}
+			}
+		}
+		if ret == nil {
+			t.Fatal("no return in f")
+		}
+
+		s := &exprvals.Scanner{Files: files, Info: info}
+		res, err := Scan(s, ret.Results[0])
+		if err != nil {
+			t.Fatalf("debug=%v: %s", debug, err)
+		}
+		want := map[string]constant.Value{
+			`"fast"`: constant.MakeString("fast"),
+			`"slow"`: constant.MakeString("slow"),
+		}
+		if !reflect.DeepEqual(res.Values, want) {
+			t.Errorf("debug=%v: got %v, want %v", debug, res.Values, want)
+		}
+		if !res.Complete {
+			t.Errorf("debug=%v: got incomplete result", debug)
+		}
+
+		if debug {
+			expr, ok := Expr(ret.Results[0], files)
+			if !ok {
+				t.Fatal("no expression for result")
+			}
+			if v, ok := Value(fn, expr); !ok || v != ret.Results[0] {
+				t.Errorf("got value %v for %s, want %v", v, types.ExprString(expr), ret.Results[0])
+			}
+		}
+	}
+}
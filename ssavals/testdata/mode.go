@@ -0,0 +1,15 @@
+package main
+
+import "os"
+
+func mode() string {
+	if os.Getenv("FAST") != "" {
+		return "fast"
+	}
+	return "slow"
+}
+
+func f() string {
+	m := mode()
+	return m
+}
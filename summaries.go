@@ -0,0 +1,395 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+	"sync"
+)
+
+// A summary computes the possible values of the idx'th result of a call
+// to a function whose body is not (or need not be) available.
+type summary func(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool)
+
+// summaries maps the full names of functions and methods
+// (see [types.Func.FullName])
+// to their summaries.
+// It is populated in init to avoid an initialization cycle
+// (summaries call back into the query, which consults summaries).
+var summaries map[string]summary
+
+func init() {
+	summaries = map[string]summary{
+		"cmp.Compare": summarizeCompare,
+		"cmp.Or":      summarizeOr,
+
+		"math.Max": summarizeMinMax(token.GTR),
+		"math.Min": summarizeMinMax(token.LSS),
+
+		"github.com/samber/lo.CoalesceOrEmpty": summarizeOr,
+		"github.com/samber/lo.Ternary":         summarizeTernary,
+
+		"unique.Make":              summarizeUniqueMake,
+		"(unique.Handle[T]).Value": summarizeHandleValue,
+
+		"(*net/url.URL).Hostname": summarizeURLMethod,
+		"(*net/url.URL).Port":     summarizeURLMethod,
+
+		"(" + squirrelPath + ".SelectBuilder).ToSql":   summarizeToSql,
+		"(" + squirrelPath + ".SelectBuilder).MustSql": summarizeToSql,
+	}
+	addPathSummaries(summaries)
+
+	fieldSummaries = map[string]fieldSummary{
+		"net/url.Parse":           summarizeURLParse,
+		"net/url.ParseRequestURI": summarizeURLParse,
+	}
+}
+
+// A fieldSummary is like a summary,
+// but computes the possible values of the field at path
+// (see [query.scanField])
+// in the idx'th result of a call.
+type fieldSummary func(q *query, call *ast.CallExpr, idx int, path []int) (map[string]constant.Value, bool)
+
+// fieldSummaries maps full names of functions to their field summaries.
+var fieldSummaries map[string]fieldSummary
+
+// A Summary computes the possible values of the idx'th result of call,
+// for use in place of the body of the called function.
+// The scan function determines the possible values of an expression,
+// such as one of the call's arguments,
+// in the context of the call.
+// The boolean result tells whether the values are complete.
+type Summary func(call *ast.CallExpr, idx int, scan func(ast.Expr) (map[string]constant.Value, bool)) (map[string]constant.Value, bool)
+
+var (
+	registryMu      sync.RWMutex
+	registry        = make(map[string]summary)
+	registryVersion uint64
+)
+
+// RegisterSummary registers s as the summary for the function with the given name,
+// in the form "package/path.Name",
+// or for a method, "(package/path.Type).Name" or "(*package/path.Type).Name"
+// (see [types.Func.FullName]).
+// A vendored copy of a package,
+// with a path like "example.com/mod/vendor/package/path"
+// or (in the standard library) "vendor/package/path",
+// is known by the path of the original.
+// A method of a generic type is known by the name of the generic method,
+// as in "(unique.Handle[T]).Value".
+// It takes precedence over the body of the function, if available,
+// and over any built-in summary.
+// A method with a summary is taken not to change its receiver.
+// If s is nil, the registration for name is removed.
+//
+// Results cached by a [Scanner] are discarded when the registrations change.
+func RegisterSummary(name string, s Summary) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if s == nil {
+		delete(registry, name)
+	} else {
+		registry[name] = func(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+			return s(call, idx, q.scan)
+		}
+	}
+	registryVersion++
+}
+
+// summariesVersion returns a number that changes whenever [RegisterSummary] is called.
+func summariesVersion() uint64 {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registryVersion
+}
+
+// summaryFor returns the summary for the function called by call, if there is one.
+func summaryFor(call *ast.CallExpr, info *types.Info) (summary, bool) {
+	fun := calleeFunc(call, info)
+	if fun == nil || fun.Pkg() == nil {
+		return nil, false
+	}
+	name := funcName(fun.Origin())
+
+	registryMu.RLock()
+	s, ok := registry[name]
+	registryMu.RUnlock()
+	if ok {
+		return s, true
+	}
+
+	s, ok = summaries[name]
+	return s, ok
+}
+
+// fieldSummaryFor returns the field summary for the function called by call, if there is one.
+func fieldSummaryFor(call *ast.CallExpr, info *types.Info) (fieldSummary, bool) {
+	fun := calleeFunc(call, info)
+	if fun == nil || fun.Pkg() == nil {
+		return nil, false
+	}
+	s, ok := fieldSummaries[funcName(fun.Origin())]
+	return s, ok
+}
+
+// funcName returns the full name of fn (see [types.Func.FullName]),
+// with the path of its package made canonical by [canonicalPath],
+// for looking up summaries and other information kept by name.
+func funcName(fn *types.Func) string {
+	name := fn.FullName()
+	if fn.Pkg() == nil {
+		return name
+	}
+	if p := fn.Pkg().Path(); canonicalPath(p) != p {
+		name = strings.Replace(name, p, canonicalPath(p), 1)
+	}
+	return name
+}
+
+// canonicalPath returns the import path of the package that path is a vendored copy of,
+// or path itself if it is not in a vendor directory.
+// Vendored packages have paths like "example.com/mod/vendor/github.com/x/y"
+// when vendored in GOPATH mode,
+// and the standard library's have paths like "vendor/golang.org/x/net/http2/hpack".
+// (In module mode,
+// vendored packages have their original paths.)
+func canonicalPath(path string) string {
+	if i := strings.LastIndex(path, "/vendor/"); i >= 0 {
+		return path[i+len("/vendor/"):]
+	}
+	return strings.TrimPrefix(path, "vendor/")
+}
+
+// calleeFunc returns the package-level function or method called by call,
+// or nil if it cannot be determined statically.
+// Unlike info.Selections,
+// this handles package-qualified calls like fmt.Println.
+// Since identifiers are resolved with info.ObjectOf,
+// dot imports, renamed imports,
+// and local names that shadow an imported package
+// all resolve to the right object.
+func calleeFunc(call *ast.CallExpr, info *types.Info) *types.Func {
+	var ident *ast.Ident
+	switch f := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = f
+	case *ast.SelectorExpr:
+		ident = f.Sel
+	case *ast.IndexExpr: // explicit instantiation, f[T](...)
+		return calleeFunc(&ast.CallExpr{Fun: f.X}, info)
+	case *ast.IndexListExpr:
+		return calleeFunc(&ast.CallExpr{Fun: f.X}, info)
+	default:
+		return nil
+	}
+	fun, _ := info.ObjectOf(ident).(*types.Func)
+	return fun
+}
+
+// summarizeOr handles cmp.Or and similar functions,
+// which return the first of their arguments that is not the zero value,
+// or the zero value if there is none.
+func summarizeOr(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	if idx != 0 || call.Ellipsis.IsValid() {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+
+	zero, zeroOK := zeroValue(q.info.TypeOf(call))
+	if !zeroOK {
+		q.incomplete(call, "zero value of %s is not a constant", q.info.TypeOf(call))
+		complete = false
+	}
+
+	for _, arg := range call.Args {
+		argVals, argComplete := q.scan(arg)
+		complete = complete && argComplete
+
+		mayBeZero := !argComplete
+		for k, v := range argVals {
+			if zeroOK && valuesEqual(v, zero) {
+				mayBeZero = true
+				continue
+			}
+			result[k] = v
+		}
+		if !mayBeZero {
+			// Later arguments are never reached.
+			return result, complete
+		}
+	}
+
+	// All the arguments may be zero.
+	if zeroOK {
+		result[zero.ExactString()] = zero
+	}
+	return result, complete
+}
+
+// summarizeCompare handles cmp.Compare,
+// which returns -1, 0, or +1.
+func summarizeCompare(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	if idx != 0 || len(call.Args) != 2 {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+
+	var (
+		minus1 = constant.MakeInt64(-1)
+		zero   = constant.MakeInt64(0)
+		plus1  = constant.MakeInt64(1)
+		all    = map[string]constant.Value{
+			minus1.ExactString(): minus1,
+			zero.ExactString():   zero,
+			plus1.ExactString():  plus1,
+		}
+	)
+
+	xVals, xComplete := q.scan(call.Args[0])
+	yVals, yComplete := q.scan(call.Args[1])
+	if !xComplete || !yComplete {
+		return all, true
+	}
+
+	result := make(map[string]constant.Value)
+	for _, x := range xVals {
+		for _, y := range yVals {
+			if !canCompare(x, y) {
+				return all, true
+			}
+			switch {
+			case constant.Compare(x, token.LSS, y):
+				result[minus1.ExactString()] = minus1
+			case constant.Compare(x, token.GTR, y):
+				result[plus1.ExactString()] = plus1
+			default:
+				result[zero.ExactString()] = zero
+			}
+		}
+	}
+	return result, true
+}
+
+// summarizeMinMax returns a summary for functions like math.Min and math.Max,
+// and the min and max builtins,
+// that choose among their arguments:
+// the one that compares op (token.LSS for min, token.GTR for max)
+// to all the others.
+// Each combination of the arguments' values contributes its choice.
+func summarizeMinMax(op token.Token) summary {
+	return func(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+		if idx != 0 || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+			q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+			return nil, false
+		}
+
+		result, complete := q.scan(call.Args[0])
+		for _, arg := range call.Args[1:] {
+			vals, ok := q.scan(arg)
+			complete = complete && ok
+
+			chosen := make(map[string]constant.Value)
+			for _, x := range result {
+				for _, y := range vals {
+					if !canCompare(x, y) {
+						q.incomplete(call, "cannot compare %s and %s", x.ExactString(), y.ExactString())
+						complete = false
+						continue
+					}
+					if constant.Compare(y, op, x) {
+						chosen[y.ExactString()] = y
+					} else {
+						chosen[x.ExactString()] = x
+					}
+				}
+			}
+			result = chosen
+		}
+
+		if basic, ok := underlying(q.info.TypeOf(call)).(*types.Basic); ok {
+			// E.g. min(x, 1) where x is a float64 gives float64(1).
+			converted := make(map[string]constant.Value, len(result))
+			for _, v := range result {
+				if c, ok := convertValue(v, basic); ok {
+					v = c
+				}
+				converted[v.ExactString()] = v
+			}
+			result = converted
+		}
+
+		return result, complete
+	}
+}
+
+// summarizeTernary handles lo.Ternary(cond, a, b),
+// which returns a if cond is true and b otherwise.
+func summarizeTernary(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	if idx != 0 || len(call.Args) != 3 {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+
+	mayBeTrue, mayBeFalse := true, true
+	if condVals, ok := q.scan(call.Args[0]); ok {
+		mayBeTrue, mayBeFalse = false, false
+		for _, v := range condVals {
+			if v.Kind() != constant.Bool {
+				mayBeTrue, mayBeFalse = true, true
+				break
+			}
+			if constant.BoolVal(v) {
+				mayBeTrue = true
+			} else {
+				mayBeFalse = true
+			}
+		}
+	}
+
+	var (
+		result   = make(map[string]constant.Value)
+		complete = true
+	)
+	if mayBeTrue {
+		vals, ok := q.scan(call.Args[1])
+		union(result, vals)
+		complete = complete && ok
+	}
+	if mayBeFalse {
+		vals, ok := q.scan(call.Args[2])
+		union(result, vals)
+		complete = complete && ok
+	}
+	return result, complete
+}
+
+// canCompare tells whether x and y can be compared with [constant.Compare].
+func canCompare(x, y constant.Value) bool {
+	if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+		return false
+	}
+	return x.Kind() == y.Kind() || (isNumeric(x) && isNumeric(y))
+}
+
+// valuesEqual tells whether x and y are comparable and equal.
+func valuesEqual(x, y constant.Value) bool {
+	return canCompare(x, y) && constant.Compare(x, token.EQL, y)
+}
+
+func isNumeric(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Int, constant.Float, constant.Complex:
+		return true
+	}
+	return false
+}
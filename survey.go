@@ -0,0 +1,160 @@
+package exprvals
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"sort"
+)
+
+// Support tells how well the analysis handles a kind of syntax,
+// as found by [Survey].
+type Support int
+
+const (
+	// SupportUnexamined means no scan examined syntax of the kind.
+	SupportUnexamined Support = iota
+
+	// SupportFull means every scan that examined syntax of the kind
+	// understood it.
+	SupportFull
+
+	// SupportPartial means some of the syntax of the kind
+	// made scans incomplete
+	// and some did not.
+	SupportPartial
+
+	// SupportNone means all the syntax of the kind that scans examined
+	// made them incomplete.
+	SupportNone
+)
+
+func (s Support) String() string {
+	switch s {
+	case SupportUnexamined:
+		return "unexamined"
+	case SupportFull:
+		return "full"
+	case SupportPartial:
+		return "partial"
+	case SupportNone:
+		return "none"
+	}
+	return fmt.Sprintf("Support(%d)", int(s))
+}
+
+// SurveyEntry describes one kind of statement or expression,
+// as found by [Survey].
+type SurveyEntry struct {
+	// Kind is the name of the syntax node type,
+	// as in "RangeStmt" for [ast.RangeStmt].
+	Kind string
+
+	// Count is the number of nodes of the kind in the packages.
+	Count int
+
+	// Examined is the number of them that the scans examined.
+	Examined int
+
+	// Punted is the number of those that made a scan incomplete:
+	// the specific code that could not be analyzed
+	// (see [Reason.Leaves]).
+	Punted int
+
+	// Support summarizes Examined and Punted.
+	Support Support
+}
+
+// Survey scans pkgs as [Report] does,
+// and tells which kinds of statements and expressions appear in them,
+// which of those the scans examined,
+// and how well the analysis handled them,
+// for judging whether it will work on a code base
+// and what to improve if not.
+// Packages are analyzed independently,
+// as by separate calls to [Report].
+//
+// The entries are sorted by Kind.
+func Survey(pkgs []*Package) []SurveyEntry {
+	var (
+		counts = make(map[string]int)
+		sv     = &survey{examined: make(map[ast.Node]bool), punted: make(map[ast.Node]bool)}
+	)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if kind := syntaxKind(n); kind != "" {
+					counts[kind]++
+				}
+				return true
+			})
+		}
+		report(&Scanner{Files: pkg.Files, Info: pkg.Info, survey: sv}, pkg, false, FormatOptions{})
+	}
+
+	var (
+		examined = make(map[string]int)
+		punted   = make(map[string]int)
+	)
+	for n := range sv.examined {
+		examined[syntaxKind(n)]++
+	}
+	for n := range sv.punted {
+		punted[syntaxKind(n)]++
+	}
+
+	result := make([]SurveyEntry, 0, len(counts))
+	for kind, count := range counts {
+		e := SurveyEntry{Kind: kind, Count: count, Examined: examined[kind], Punted: punted[kind]}
+		switch {
+		case e.Examined == 0:
+			e.Support = SupportUnexamined
+		case e.Punted == 0:
+			e.Support = SupportFull
+		case e.Punted < e.Examined:
+			e.Support = SupportPartial
+		default:
+			e.Support = SupportNone
+		}
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Kind < result[j].Kind })
+	return result
+}
+
+// survey records the syntax that scans examine,
+// and the syntax that makes them incomplete,
+// for [Survey].
+type survey struct {
+	examined map[ast.Node]bool
+	punted   map[ast.Node]bool
+}
+
+// examine records that the query examined n,
+// for [Survey] and [Scanner.ScanDeps].
+func (q *query) examine(n ast.Node) {
+	if q.survey != nil && syntaxKind(n) != "" {
+		q.survey.examined[n] = true
+	}
+	q.consult(n)
+}
+
+// punt records that n made the query incomplete.
+func (q *query) punt(n ast.Node) {
+	if q.survey != nil && syntaxKind(n) != "" {
+		q.survey.examined[n] = true
+		q.survey.punted[n] = true
+	}
+}
+
+// syntaxKind gives the name of the type of n,
+// as in "RangeStmt",
+// if it is a statement or an expression,
+// and otherwise "".
+func syntaxKind(n ast.Node) string {
+	switch n.(type) {
+	case ast.Stmt, ast.Expr:
+		return reflect.TypeOf(n).Elem().Name()
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// TemplateTexts finds the possible texts of the template node,
+// a *text/template.Template or *html/template.Template:
+// the concatenations of the strings parsed into it,
+// for auditing what a program may render.
+// It follows template.New(...), Template.New, and Template.Parse
+// (and template.Must and the methods that return their receiver,
+// such as Funcs and Delims),
+// through variables and calls of functions in the Scanner's files.
+// Templates read from files,
+// or parsed in place by a call outside the chains leading to node,
+// make the result incomplete.
+//
+// The Values of the result are strings.
+func (s *Scanner) TemplateTexts(node ast.Expr) Result {
+	q := s.newQuery()
+	return q.result(node, "finding texts of template "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+		states, complete := newTemplateWalker(q).walk(node)
+		result := make(map[string]constant.Value)
+		for _, st := range states {
+			v := constant.MakeString(string(st.(templateText)))
+			result[v.ExactString()] = v
+		}
+		return result, complete
+	})
+}
+
+// templateText is the state of a template tracked by a [builderWalker]:
+// the text parsed into it.
+type templateText string
+
+func (t templateText) key() string { return string(t) }
+
+// templateFunc returns the function or method of text/template or html/template called by call,
+// or nil if it is not one of them.
+func templateFunc(call *ast.CallExpr, info *types.Info) *types.Func {
+	fn := calleeFunc(call, info)
+	if fn == nil || fn.Pkg() == nil {
+		return nil
+	}
+	switch canonicalPath(fn.Pkg().Path()) {
+	case "text/template", "html/template":
+		return fn
+	}
+	return nil
+}
+
+// newTemplateWalker returns a [builderWalker] for templates.
+func newTemplateWalker(q *query) *builderWalker {
+	w := &builderWalker{q: q}
+
+	w.isStep = func(call *ast.CallExpr) bool {
+		fn := templateFunc(call, q.info)
+		if fn == nil {
+			return false
+		}
+		results := fn.Signature().Results()
+		if results.Len() == 0 {
+			return false
+		}
+		ptr, ok := results.At(0).Type().(*types.Pointer)
+		if !ok {
+			return false
+		}
+		named, ok := ptr.Elem().(*types.Named)
+		return ok && named.Obj().Name() == "Template"
+	}
+
+	w.inPlace = func(call *ast.CallExpr) bool {
+		fn := templateFunc(call, q.info)
+		if fn == nil || fn.Signature().Recv() == nil {
+			return false
+		}
+		switch fn.Name() {
+		case "Parse", "ParseFiles", "ParseGlob", "ParseFS", "AddParseTree":
+			return true
+		}
+		return false
+	}
+
+	w.step = func(call *ast.CallExpr) (map[string]builderState, bool) {
+		fn := templateFunc(call, q.info)
+		if fn.Signature().Recv() == nil {
+			switch fn.Name() {
+			case "New":
+				return map[string]builderState{"": templateText("")}, true
+			case "Must":
+				return w.states(call.Args[0])
+			}
+			q.incomplete(call, "text of template from %s is not known", types.ExprString(call.Fun))
+			return nil, false
+		}
+
+		sel := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		switch fn.Name() {
+		case "New":
+			// A new template associated with the receiver,
+			// with its own (empty) text.
+			return map[string]builderState{"": templateText("")}, true
+
+		case "Parse":
+			recv, complete := w.states(sel.X)
+			states, ok := w.extend(call, recv, call.Args, func(st builderState, vals []string) builderState {
+				return templateText(string(st.(templateText)) + vals[0])
+			})
+			return states, ok && complete
+
+		case "Funcs", "Delims", "Option", "Clone":
+			return w.states(sel.X)
+		}
+
+		q.incomplete(call, "text of template from %s is not known", types.ExprString(call.Fun))
+		return nil, false
+	}
+
+	return w
+}
@@ -0,0 +1,9 @@
+package main
+
+func f() string {
+	mode := "fast"
+	go func() {
+		mode = "slow"
+	}()
+	return mode
+}
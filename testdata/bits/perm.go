@@ -0,0 +1,24 @@
+package main
+
+import "os"
+
+const (
+	read = 1 << iota
+	write
+	exec
+	admin
+)
+
+func f() int {
+	perm := read | admin
+	if len(os.Args) > 1 {
+		perm |= write
+	}
+	if len(os.Args) > 2 {
+		perm |= exec
+	}
+	if os.Getenv("SAFE") != "" {
+		perm &^= admin
+	}
+	return perm
+}
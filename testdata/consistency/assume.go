@@ -0,0 +1,10 @@
+package main
+
+func mode() string {
+	m := "fast"
+	return m
+}
+
+func f() string {
+	return mode()
+}
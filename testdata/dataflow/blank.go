@@ -0,0 +1,15 @@
+package main
+
+func two() (string, string) { return "a", "b" }
+
+func f() string {
+	m := map[string]int{"k": 1}
+	_, x := two()
+	n, _ := m["k"]
+	var y, _ = two()
+	if n == 0 {
+		// Unreachable, since "k" is always in m.
+		x = "zero"
+	}
+	return x + y
+}
@@ -0,0 +1,32 @@
+package main
+
+func f(items []string) string {
+	// A break in a switch leaves only the switch,
+	// so the assignment after it still runs.
+	state := "start"
+	for _, item := range items {
+		switch item {
+		case "skip":
+			state = "skipped"
+			break
+		}
+		state = "seen"
+	}
+
+	// A labeled continue goes to the next iteration of the outer loop,
+	// passing over the rest of its body.
+	mode := "none"
+outer:
+	for _, item := range items {
+		for range items {
+			if item == "x" {
+				mode = "inner"
+				continue outer
+			}
+		}
+		mode = "outer"
+	}
+
+	println(state)
+	return mode
+}
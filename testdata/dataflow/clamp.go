@@ -0,0 +1,17 @@
+package main
+
+import "strconv"
+
+func f(verbose bool, arg string) int {
+	retries := 3
+	if verbose {
+		retries = 50
+	}
+	if n, err := strconv.Atoi(arg); err == nil {
+		retries = n
+	}
+	if retries > 10 {
+		retries = 10
+	}
+	return retries
+}
@@ -0,0 +1,16 @@
+package main
+
+func f(verbose bool) string {
+	level := 1
+	if verbose {
+		level = 2
+	}
+	mode := "fast"
+	mode = "slow"
+	last := -1
+	for i := 0; i < 3; i++ {
+		last = i
+	}
+	println(level, last)
+	return mode
+}
@@ -0,0 +1,20 @@
+package main
+
+import "os"
+
+func f(n int) string {
+	mode := "none"
+	switch n {
+	case 1:
+		mode = "read"
+	case 2:
+		mode = "write"
+	case 3:
+		mode = "append"
+	}
+	return mode
+}
+
+func main() {
+	f(len(os.Args))
+}
@@ -0,0 +1,28 @@
+package main
+
+func f(quit chan bool) string {
+	ch := make(chan string, 1)
+	ch <- "a"
+
+	// Without a default clause, one of the cases runs.
+	state := "none"
+	select {
+	case v := <-ch:
+		state = v
+	case <-quit:
+		state = "quit"
+	}
+
+	// With one, none of them may.
+	mode := "none"
+	select {
+	case v, ok := <-ch:
+		if ok {
+			mode = v + "!"
+		}
+	default:
+	}
+
+	println(mode)
+	return state
+}
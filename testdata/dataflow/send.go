@@ -0,0 +1,20 @@
+package main
+
+func fatal() string {
+	panic("fatal")
+}
+
+func f(flag bool) string {
+	ch := make(chan string, 2)
+	x := "a"
+	ch <- x
+	x = "b"
+	got := <-ch
+
+	state := "ok"
+	if flag {
+		ch <- fatal()
+		state = "unreached"
+	}
+	return got + state
+}
@@ -0,0 +1,21 @@
+package main
+
+type config struct {
+	mode  string
+	limit struct {
+		n int
+	}
+}
+
+func f(other config) string {
+	cfg := config{mode: "a"}
+	cfg.mode = "b"
+	cfg.limit.n = 3
+	cfg = config{mode: "c"}
+	if cfg.limit.n > 0 {
+		cfg = other
+	}
+	cfg = config{mode: "d"}
+	cfg.limit.n = 7
+	return cfg.mode
+}
@@ -0,0 +1,20 @@
+package main
+
+type pair struct {
+	first, second string
+}
+
+func f() string {
+	x, y := "a", "b"
+	x, y = y, x
+
+	// Each right-hand side is evaluated before any assignment.
+	i, j := 1, 2
+	i, j = j, i+j
+
+	p := pair{first: "left", second: "right"}
+	p.first, p.second = p.second, p.first
+
+	println(x, y, i, j, p.first, p.second)
+	return x
+}
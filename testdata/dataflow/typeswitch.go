@@ -0,0 +1,18 @@
+package main
+
+func f(flag bool) string {
+	var v any = "a"
+	if flag {
+		v = 3
+	}
+
+	s := "none"
+	switch x := v.(type) {
+	case string:
+		x = x + "!"
+		s = x
+	case int:
+		s = "int"
+	}
+	return s
+}
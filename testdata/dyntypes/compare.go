@@ -0,0 +1,34 @@
+package main
+
+type key struct {
+	name string
+}
+
+func tags() any {
+	return []string{"a", "b"}
+}
+
+func name() any {
+	return key{name: "x"}
+}
+
+func pick(useTags bool) any {
+	if useTags {
+		return tags()
+	}
+	return name()
+}
+
+func main() {
+	a, b := pick(true), pick(false)
+	if a == b { // may panic
+		println("same")
+	}
+	if name() == name() {
+		println("same")
+	}
+	var x any = 3
+	if x == a {
+		println("same")
+	}
+}
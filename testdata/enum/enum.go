@@ -0,0 +1,22 @@
+package main
+
+type flag uint
+
+const (
+	flagA flag = 1 << iota
+	flagB
+	_
+	flagD
+)
+
+type level int
+
+const (
+	levelLow level = iota * 100
+	levelMid
+	levelHigh
+
+	levelDefault = levelMid
+)
+
+const notLevel = 100
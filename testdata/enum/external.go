@@ -0,0 +1,7 @@
+package main
+
+import "go/token"
+
+func f(tok token.Token) bool {
+	return tok == token.ADD
+}
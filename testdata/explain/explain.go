@@ -0,0 +1,15 @@
+package test
+
+import "os"
+
+func f() string {
+	m := "fast"
+	if len(os.Args) > 1 {
+		m = pick("verbose")
+	}
+	return m
+}
+
+func pick(s string) string {
+	return s
+}
@@ -0,0 +1,12 @@
+package main
+
+type Delim byte
+
+var (
+	b byte
+	r rune
+	d Delim
+	u uint8
+	i int32
+	s string
+)
@@ -0,0 +1,7 @@
+package test
+
+var third float64
+
+func defaultThird() float64 {
+	return third
+}
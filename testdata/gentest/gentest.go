@@ -0,0 +1,18 @@
+package test
+
+import "os"
+
+func defaultMode() string {
+	m := "fast"
+	if len(os.Args) > 1 {
+		m = pick("verbose")
+	}
+	if len(os.Args) > 2 {
+		return "slow"
+	}
+	return m
+}
+
+func pick(s string) string {
+	return s
+}
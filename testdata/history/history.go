@@ -0,0 +1,19 @@
+package main
+
+import "os"
+
+func f() string {
+	mode := "boot"
+	println(mode)
+	mode = "run"
+	if len(os.Args) > 1 {
+		mode = "debug"
+	}
+	println(mode) // The query point.
+	mode = "done"
+	return mode
+}
+
+func main() {
+	f()
+}
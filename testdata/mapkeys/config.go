@@ -0,0 +1,23 @@
+package main
+
+import "maps"
+
+const keyTimeout = "timeout"
+
+func defaults() map[string]string {
+	return map[string]string{"host": "localhost", "port": "8080"}
+}
+
+func f() map[string]string {
+	cfg := map[string]string{"mode": "fast"}
+	cfg[keyTimeout] = "30s"
+	maps.Copy(cfg, map[string]string{"retries": "3"})
+	maps.Copy(cfg, defaults())
+	if _, ok := cfg["legacy"]; ok {
+		delete(cfg, "legacy")
+	}
+	for k, v := range cfg {
+		_, _ = k, v
+	}
+	return cfg
+}
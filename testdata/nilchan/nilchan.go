@@ -0,0 +1,44 @@
+package main
+
+import "os"
+
+type worker struct {
+	jobs chan string
+}
+
+func run() {
+	var done chan bool
+	results := make(chan int, 1)
+
+	go func() {
+		results <- 1
+		done <- true // blocks forever: done is never made
+	}()
+
+	<-results
+	<-done
+	println("unreachable")
+}
+
+func drain() {
+	var events chan string
+	if len(os.Args) > 1 {
+		events = make(chan string)
+	}
+	for e := range events {
+		println(e)
+	}
+}
+
+func wait(w *worker) {
+	var stop chan struct{}
+	select {
+	case j := <-w.jobs:
+		println(j)
+	case <-stop:
+	}
+
+	select {
+	case <-stop:
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "os"
+
+type errNotFound struct{ name string }
+
+func (e errNotFound) Error() string { return e.name + " not found" }
+
+const errClosed = "closed"
+
+func check(n int) {
+	if n < 0 {
+		panic(errClosed)
+	}
+	if n == 0 {
+		panic(errNotFound{name: "item"})
+	}
+}
+
+func lookup(n int) int {
+	code := 1
+	if n > 10 {
+		code = 2
+	}
+	check(n)
+	if n > 100 {
+		panic(code)
+	}
+	go func() {
+		panic("ignored")
+	}()
+	return n
+}
+
+func main() {
+	lookup(len(os.Args))
+}
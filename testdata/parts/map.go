@@ -0,0 +1,9 @@
+package main
+
+import "os"
+
+func f() string {
+	m := map[string]string{"a": "x"}
+	m["b"] = os.Getenv("B")
+	return m["a"]
+}
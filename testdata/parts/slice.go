@@ -0,0 +1,9 @@
+package main
+
+import "os"
+
+func f() string {
+	s := []string{"a", "b"}
+	s[1] = os.Getenv("B")
+	return s[0]
+}
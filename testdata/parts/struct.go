@@ -0,0 +1,18 @@
+package main
+
+import "os"
+
+type Config struct {
+	Mode  string
+	Port  int
+	Limit struct {
+		Max int
+	}
+}
+
+func f() int {
+	c := Config{Port: 80}
+	c.Mode = os.Getenv("MODE")
+	c.Limit.Max = 10
+	return c.Port
+}
@@ -0,0 +1,21 @@
+package main
+
+func f(n int, verbose bool) string {
+	level := "info"
+	switch n {
+	case 1:
+		level = "debug"
+	default:
+		level = "warn"
+	}
+	for i := range n {
+		if i > 3 {
+			level = "trace"
+		}
+	}
+	suffix := "x"
+	if verbose {
+		level = "verbose-" + suffix
+	}
+	return level
+}
@@ -0,0 +1,11 @@
+package main
+
+func f(json bool) string {
+	var format string
+	if json {
+		format = "json"
+	} else {
+		format = "text"
+	}
+	return format
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func reached()   {}
+func unreached() {}
+
+func f() {
+	mode := "dev"
+	if len(os.Args) > 1 {
+		mode = "test"
+	}
+
+	if mode == "prod" {
+		unreached()
+	}
+	if mode == "dev" {
+		reached()
+		if mode != "dev" {
+			unreached()
+		}
+	}
+
+	switch mode {
+	case "dev", "test":
+		reached()
+	case "prod":
+		unreached()
+	default:
+		unreached()
+	}
+
+	debug := false
+	if debug && mode == "dev" {
+		unreached()
+	}
+	if !debug {
+		reached()
+	} else {
+		unreached()
+	}
+
+	for n := len(mode); n > 10; n-- {
+		reached()
+	}
+
+	log.Fatal("done")
+	unreached()
+}
+
+func g(env string) {
+	if env == "production" {
+		log.Fatal("not allowed in production")
+	}
+}
+
+func h(ready chan bool) {
+	var never chan int
+	select {
+	case <-never:
+		unreached()
+	case v := <-never:
+		_ = v
+		unreached()
+	case never <- 1:
+		unreached()
+	case <-ready:
+		reached()
+	default:
+		reached()
+	}
+
+	select {
+	case <-never:
+		unreached()
+	}
+	unreached()
+}
+
+func k() {
+	var x any = 7
+	if _, ok := x.(int); ok {
+		reached()
+	} else {
+		unreached()
+	}
+	if _, ok := x.(string); ok {
+		unreached()
+	}
+
+	defaults := map[string]int{"a": 1}
+	if _, ok := defaults["b"]; ok {
+		unreached()
+	}
+	if _, ok := defaults["a"]; ok {
+		reached()
+	}
+}
+
+func m() {
+	var x any = "a"
+	if len(os.Args) > 1 {
+		x = 2
+	}
+	switch x {
+	case "a":
+		if _, ok := x.(string); ok {
+			reached()
+		}
+		if _, ok := x.(int); ok {
+			unreached()
+		}
+		switch y := x.(type) {
+		case int:
+			if y == 2 {
+				unreached()
+			}
+		}
+	case 2:
+		if _, ok := x.(string); ok {
+			unreached()
+		}
+		if _, ok := x.(int); ok {
+			reached()
+		}
+	}
+	if _, ok := x.(int); ok {
+		reached()
+	}
+}
+
+func mustReady() bool {
+	panic("not ready")
+}
+
+func n(ready chan bool) {
+	if len(os.Args) > 1 {
+		ready <- mustReady()
+		unreached()
+	}
+	if len(os.Args) > 2 {
+		ready <- len(os.Args) > 3 || mustReady()
+		reached()
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	. "strconv"
+	xtime "time"
+)
+
+type (
+	month           = xtime.Month
+	myMonth         = month
+	optional[T any] = xtime.Month
+)
+
+const april = xtime.April
+
+func f() myMonth {
+	var m myMonth
+	switch len(os.Args) {
+	case 1:
+		m = xtime.March
+	case 2:
+		m = myMonth(april)
+	case 3:
+		m = optional[string](xtime.May)
+	case 4:
+		m = month(IntSize / 8)
+	case 5:
+		m = g()
+	}
+	return m
+}
+
+func g() month {
+	return myMonth(xtime.December)
+}
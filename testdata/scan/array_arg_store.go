@@ -0,0 +1,9 @@
+package main
+
+func set(a [2]int) { a[0] = 42 }
+
+func f() int {
+	a := [2]int{1, 2}
+	set(a)
+	return a[0]
+}
@@ -0,0 +1,8 @@
+package main
+
+func f() int {
+	a := [2]int{1, 2}
+	b := a
+	b[0] = 42
+	return a[0]
+}
@@ -0,0 +1,6 @@
+package main
+
+func f() bool {
+	a := [2]int{1, 2}
+	return a != [2]int{1, 3}
+}
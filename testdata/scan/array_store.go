@@ -0,0 +1,7 @@
+package main
+
+func f() int {
+	var a [3]int
+	a[0] = 42
+	return a[0]
+}
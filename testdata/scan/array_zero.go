@@ -0,0 +1,6 @@
+package main
+
+func f(i int) int {
+	a := [3]int{1, 2}
+	return a[i]
+}
@@ -0,0 +1,11 @@
+package main
+
+import "os"
+
+func f() int {
+	n := 2
+	if len(os.Args) > 1 {
+		n = 3
+	}
+	return n*10 + 1
+}
@@ -0,0 +1,9 @@
+package main
+
+func two() (string, string) { return "a", "b" }
+
+func f() string {
+	x := "none"
+	_, x = two()
+	return x
+}
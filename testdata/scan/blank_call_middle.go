@@ -0,0 +1,8 @@
+package main
+
+func three() (int, int, int) { return 1, 2, 3 }
+
+func f() int {
+	_, x, _ := three()
+	return x
+}
@@ -0,0 +1,7 @@
+package main
+
+func f() bool {
+	var v any = 1
+	_, ok := v.(string)
+	return ok
+}
@@ -0,0 +1,8 @@
+package main
+
+func f() string {
+	m := map[string]string{"k": "v"}
+	x := "none"
+	x, _ = m["k"]
+	return x
+}
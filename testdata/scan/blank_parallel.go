@@ -0,0 +1,7 @@
+package main
+
+func f() string {
+	var x string
+	_, x, _ = 1, "b", 3.0
+	return x
+}
@@ -0,0 +1,29 @@
+package main
+
+import "os"
+
+func mode() string {
+	if len(os.Args) > 2 {
+		return "b"
+	}
+	return "a"
+}
+
+func f() string {
+	var result string
+	{
+		const k = "x"
+		var y = mode()
+		var (
+			z, w = "z", k + y
+		)
+		if len(os.Args) > 1 {
+			result = k
+		} else if len(os.Args) > 3 {
+			result = z
+		} else {
+			result = w
+		}
+	}
+	return result
+}
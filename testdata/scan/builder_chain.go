@@ -0,0 +1,26 @@
+package main
+
+type config struct {
+	mode string
+}
+
+type builder struct {
+	mode string
+}
+
+func newBuilder() *builder {
+	return &builder{mode: "default"}
+}
+
+func (b *builder) withMode(mode string) *builder {
+	b.mode = mode
+	return b
+}
+
+func (b *builder) build() config {
+	return config{mode: b.mode}
+}
+
+func f() string {
+	return newBuilder().withMode("fast").build().mode
+}
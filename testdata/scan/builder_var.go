@@ -0,0 +1,16 @@
+package main
+
+type options struct {
+	mode    string
+	verbose bool
+}
+
+func (o *options) setMode(mode string) {
+	o.mode = mode
+}
+
+func f() string {
+	var opts options
+	opts.setMode("fast")
+	return opts.mode
+}
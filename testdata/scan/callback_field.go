@@ -0,0 +1,20 @@
+package main
+
+import "os"
+
+type router struct {
+	name   string
+	handle func() string
+}
+
+func serveIndex() string { return "index" }
+func serveAdmin() string { return "admin" }
+
+func f() string {
+	r := router{name: "main", handle: serveIndex}
+	if len(os.Args) > 1 {
+		r.handle = serveAdmin
+	}
+	s := r.handle()
+	return s
+}
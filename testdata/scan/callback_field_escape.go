@@ -0,0 +1,18 @@
+package main
+
+type router struct {
+	handle func() string
+}
+
+func serveIndex() string { return "index" }
+
+func install(r *router) {
+	r.handle = func() string { return "other" }
+}
+
+func f() string {
+	r := router{handle: serveIndex}
+	install(&r)
+	s := r.handle()
+	return s
+}
@@ -0,0 +1,19 @@
+package main
+
+type router struct {
+	handle func() string
+}
+
+func serveIndex() string { return "index" }
+func serveOther() string { return "other" }
+
+func (r *router) install() {
+	r.handle = serveOther
+}
+
+func f() string {
+	r := &router{handle: serveIndex}
+	r.install()
+	s := r.handle()
+	return s
+}
@@ -0,0 +1,15 @@
+package main
+
+type handlerFunc func(string) string
+
+type router struct {
+	handle handlerFunc
+}
+
+func serve(path string) string { return "served" }
+
+func f() string {
+	r := &router{handle: handlerFunc(serve)}
+	s := r.handle("/")
+	return s
+}
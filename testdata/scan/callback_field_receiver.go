@@ -0,0 +1,17 @@
+package main
+
+type router struct {
+	handle func() string
+}
+
+func serveIndex() string { return "index" }
+
+func (r *router) dispatch() string {
+	return r.handle()
+}
+
+func f() string {
+	r := &router{handle: serveIndex}
+	s := r.dispatch()
+	return s
+}
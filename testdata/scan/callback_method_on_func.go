@@ -0,0 +1,13 @@
+package main
+
+type handlerFunc func() string
+
+func (f handlerFunc) serve() string { return f() }
+
+func serveIndex() string { return "index" }
+
+func f() string {
+	h := handlerFunc(serveIndex)
+	s := h.serve()
+	return s
+}
@@ -0,0 +1,9 @@
+package main
+
+type handlerFunc func() string
+
+func f() string {
+	h := handlerFunc(func() string { return "a" })
+	s := h()
+	return s
+}
@@ -0,0 +1,8 @@
+package main
+
+func f() int {
+	ch := make(chan int, 1)
+	ch <- 3
+	x := <-ch
+	return x
+}
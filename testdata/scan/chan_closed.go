@@ -0,0 +1,11 @@
+package main
+
+func f() string {
+	results := make(chan string, 2)
+	results <- "ok"
+	results <- "retry"
+	close(results)
+	<-results
+	<-results
+	return <-results
+}
@@ -0,0 +1,28 @@
+package main
+
+func produce(out chan<- string, n int) {
+	defer close(out)
+	for i := range n {
+		if i%2 == 0 {
+			out <- "even"
+		} else {
+			out <- "odd"
+		}
+	}
+}
+
+func watch(in <-chan string) {
+	for range in {
+	}
+}
+
+func f() string {
+	ch := make(chan string)
+	go produce(ch, 3)
+	go watch(ch)
+	last := "none"
+	for s := range ch {
+		last = s
+	}
+	return last
+}
@@ -0,0 +1,13 @@
+package main
+
+func f(loud bool) string {
+	greet := func(name string) string {
+		return name
+	}
+	if loud {
+		greet = func(string) string {
+			return "HELLO"
+		}
+	}
+	return greet("hello")
+}
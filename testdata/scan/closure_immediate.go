@@ -0,0 +1,11 @@
+package main
+
+func f() string {
+	return func() string {
+		inner := func() string {
+			return "not this"
+		}
+		_ = inner
+		return "hello"
+	}()
+}
@@ -0,0 +1,11 @@
+package main
+
+func f() func() string {
+	return func() string {
+		x := "hello"
+		if len(x) > 3 {
+			x = "goodbye"
+		}
+		return x
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+import "cmp"
+
+func f() int {
+	x := 1
+	if len("x") > 0 {
+		x = 2
+	}
+	return cmp.Compare(x, 2)
+}
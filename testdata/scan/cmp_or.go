@@ -0,0 +1,11 @@
+package main
+
+import (
+	"cmp"
+	"os"
+)
+
+func f() string {
+	mode := cmp.Or(os.Getenv("MODE"), "default")
+	return mode
+}
@@ -0,0 +1,11 @@
+package main
+
+import "cmp"
+
+func f(fast bool) string {
+	mode := ""
+	if fast {
+		mode = "fast"
+	}
+	return cmp.Or(mode, "slow", "unreachable")
+}
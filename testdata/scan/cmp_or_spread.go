@@ -0,0 +1,9 @@
+package main
+
+import "cmp"
+
+func f() string {
+	modes := []string{"fast", "slow"}
+	mode := cmp.Or(modes...)
+	return mode
+}
@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+type name string
+
+func (n name) String() string { return string(n) }
+
+func f() bool {
+	var s fmt.Stringer = name("x")
+	_, ok := s.(name)
+	return ok
+}
@@ -0,0 +1,7 @@
+package main
+
+func f() bool {
+	m := map[string]int{"a": 1, "b": 2}
+	_, ok := m["c"]
+	return ok
+}
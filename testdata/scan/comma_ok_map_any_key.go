@@ -0,0 +1,7 @@
+package main
+
+func f() bool {
+	m := map[any]int{int64(1): 5}
+	_, ok := m[1]
+	return ok
+}
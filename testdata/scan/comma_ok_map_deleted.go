@@ -0,0 +1,8 @@
+package main
+
+func f() bool {
+	m := map[string]int{"a": 1, "b": 2}
+	delete(m, "a")
+	_, ok := m["a"]
+	return ok
+}
@@ -0,0 +1,10 @@
+package main
+
+func change(map[string]int) {}
+
+func f() bool {
+	m := map[string]int{"a": 1, "b": 2}
+	change(m)
+	_, ok := m["a"]
+	return ok
+}
@@ -0,0 +1,13 @@
+package main
+
+import "os"
+
+func f() int {
+	m := map[string]int{"a": 1, "b": 2}
+	key := "b"
+	if len(os.Args) > 1 {
+		key = "c"
+	}
+	v, _ := m[key]
+	return v
+}
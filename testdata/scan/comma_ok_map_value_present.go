@@ -0,0 +1,9 @@
+package main
+
+func f() int {
+	m := map[string]int{"a": 1, "b": 2}
+	m["a"] = 3
+	v, ok := m["a"]
+	_ = ok
+	return v
+}
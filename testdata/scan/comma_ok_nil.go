@@ -0,0 +1,8 @@
+package main
+
+func f() int {
+	var x any
+	var n, ok = x.(int)
+	println(ok)
+	return n
+}
@@ -0,0 +1,10 @@
+package main
+
+func f() string {
+	ch := make(chan string, 1)
+	ch <- "a"
+	close(ch)
+	v, ok := <-ch
+	_ = ok
+	return v
+}
@@ -0,0 +1,9 @@
+package main
+
+func f() bool {
+	ch := make(chan string, 1)
+	ch <- "a"
+	close(ch)
+	_, ok := <-ch
+	return ok
+}
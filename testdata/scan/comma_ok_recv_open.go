@@ -0,0 +1,8 @@
+package main
+
+func f() bool {
+	ch := make(chan string, 1)
+	ch <- "a"
+	_, ok := <-ch
+	return ok
+}
@@ -0,0 +1,7 @@
+package main
+
+func f(ch chan string) string {
+	v, ok := <-ch
+	_ = ok
+	return v
+}
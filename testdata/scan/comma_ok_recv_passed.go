@@ -0,0 +1,13 @@
+package main
+
+func produce(ch chan string) {
+	ch <- "a"
+	close(ch)
+}
+
+func f() bool {
+	ch := make(chan string)
+	go produce(ch)
+	_, ok := <-ch
+	return ok
+}
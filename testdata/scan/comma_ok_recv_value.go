@@ -0,0 +1,8 @@
+package main
+
+func f() string {
+	ch := make(chan string, 1)
+	ch <- "a"
+	v, _ := <-ch
+	return v
+}
@@ -0,0 +1,13 @@
+package main
+
+import "os"
+
+const mode = "prod"
+
+func f() string {
+	mode := "dev" // shadows the package-level constant
+	if len(os.Args) > 1 {
+		mode = "test"
+	}
+	return mode
+}
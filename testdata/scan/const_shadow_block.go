@@ -0,0 +1,13 @@
+package main
+
+import "os"
+
+const level = 3
+
+func f() int {
+	if len(os.Args) > 1 {
+		level := 5 // shadows the constant only in this block
+		println(level)
+	}
+	return level
+}
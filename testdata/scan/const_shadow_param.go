@@ -0,0 +1,11 @@
+package main
+
+const mode = "prod"
+
+func f() string {
+	return g("dev")
+}
+
+func g(mode string) string {
+	return mode
+}
@@ -0,0 +1,11 @@
+package main
+
+import "os"
+
+func f() int8 {
+	n := 300
+	if len(os.Args) > 1 {
+		n = 5
+	}
+	return int8(n)
+}
@@ -0,0 +1,7 @@
+package main
+
+func f() int {
+	x := 0
+	x = x + 1
+	return x
+}
@@ -0,0 +1,9 @@
+package main
+
+func f(n int) int {
+	a := []int{1}
+	for i := 0; i < n; i++ {
+		a[0] = a[0] * 2
+	}
+	return a[0]
+}
@@ -0,0 +1,11 @@
+package main
+
+type S struct {
+	N int
+}
+
+func f() int {
+	s := S{N: 1}
+	s.N = s.N + 1
+	return s.N
+}
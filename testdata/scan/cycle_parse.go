@@ -0,0 +1,11 @@
+package main
+
+const base = 1000
+
+func f(digits []int) int64 {
+	var n int64
+	for _, d := range digits {
+		n = n*base + int64(d)
+	}
+	return n
+}
@@ -0,0 +1,12 @@
+package main
+
+func g(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return g(n-1) + 1
+}
+
+func f() int {
+	return g(3)
+}
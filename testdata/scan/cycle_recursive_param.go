@@ -0,0 +1,12 @@
+package main
+
+func g(s string, n int) string {
+	if n == 0 {
+		return s
+	}
+	return g(s+"x", n-1)
+}
+
+func f() string {
+	return g("a", 3)
+}
@@ -0,0 +1,9 @@
+package main
+
+func f(list []string) int {
+	i := 0
+	for j := len(list) - 1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return i
+}
@@ -0,0 +1,12 @@
+package main
+
+func f() string {
+	return last()
+}
+
+func last() (s string) {
+	for _, name := range []string{"a", "b"} {
+		defer func() { s = name }()
+	}
+	return "none"
+}
@@ -0,0 +1,20 @@
+package main
+
+import "os"
+
+func f() string {
+	return status()
+}
+
+func status() (s string) {
+	defer func() {
+		s = "done"
+		if len(os.Args) > 1 {
+			s = "done (verbose)"
+		}
+	}()
+	if len(os.Args) > 2 {
+		return "early"
+	}
+	return "normal"
+}
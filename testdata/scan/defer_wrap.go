@@ -0,0 +1,19 @@
+package main
+
+import "os"
+
+func wrap(s string) string {
+	return "[" + s + "]"
+}
+
+func get() (s string) {
+	defer func() { s = wrap(s) }()
+	if len(os.Args) > 1 {
+		return "a"
+	}
+	return "b"
+}
+
+func f() string {
+	return get()
+}
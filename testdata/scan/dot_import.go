@@ -0,0 +1,7 @@
+package main
+
+import . "flag"
+
+func f() string {
+	return *String("mode", "fast", "speed mode")
+}
@@ -0,0 +1,17 @@
+package main
+
+type options struct {
+	mode string
+}
+
+var saved *options
+
+func configure(o *options) {
+	saved = o
+}
+
+func f() string {
+	opts := &options{mode: "fast"}
+	configure(opts)
+	return opts.mode
+}
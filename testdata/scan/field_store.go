@@ -0,0 +1,15 @@
+package main
+
+type config struct {
+	mode string
+	n    int
+}
+
+func f() string {
+	var c config
+	c.mode = "x"
+	if c.n > 0 {
+		c.mode = "y"
+	}
+	return c.mode
+}
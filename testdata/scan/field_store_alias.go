@@ -0,0 +1,11 @@
+package main
+
+type config struct{ mode string }
+
+func f() string {
+	c := &config{mode: "a"}
+	c.mode = "b"
+	d := c
+	d.mode = "c"
+	return c.mode
+}
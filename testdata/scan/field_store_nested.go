@@ -0,0 +1,10 @@
+package main
+
+type inner struct{ name string }
+type outer struct{ in inner }
+
+func f() string {
+	o := outer{}
+	o.in.name = "deep"
+	return o.in.name
+}
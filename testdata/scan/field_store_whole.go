@@ -0,0 +1,10 @@
+package main
+
+type config struct{ mode, other string }
+
+func f() string {
+	c := config{mode: "a"}
+	c.other = "zzz"
+	c = config{mode: "b"}
+	return c.mode
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func f() bool {
+	name := "config.yaml"
+	if os.Getenv("LEGACY") != "" {
+		name = "../../etc/config.yaml"
+	}
+	return filepath.IsLocal(filepath.Join("conf", name))
+}
@@ -0,0 +1,10 @@
+package main
+
+import "flag"
+
+func f(args []string) string {
+	fs := flag.NewFlagSet("f", flag.ExitOnError)
+	mode := fs.String("mode", "fast", "processing mode")
+	fs.Parse(args)
+	return *mode
+}
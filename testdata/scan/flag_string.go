@@ -0,0 +1,10 @@
+package main
+
+import "flag"
+
+var mode = flag.String("mode", "fast", "processing mode")
+
+func f() string {
+	flag.Parse()
+	return *mode
+}
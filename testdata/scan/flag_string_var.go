@@ -0,0 +1,10 @@
+package main
+
+import "flag"
+
+func f() string {
+	var mode string
+	flag.StringVar(&mode, "mode", "fast", "processing mode")
+	flag.Parse()
+	return mode
+}
@@ -0,0 +1,17 @@
+package main
+
+import "os"
+
+type level int8
+
+func clamp[T ~int8 | ~int16](n int) T {
+	return T(n)
+}
+
+func f() level {
+	n := 300
+	if len(os.Args) > 1 {
+		n = 5
+	}
+	return clamp[level](n)
+}
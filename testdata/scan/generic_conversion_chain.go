@@ -0,0 +1,15 @@
+package main
+
+type name string
+
+func wrap[T ~string | ~int](n int) T {
+	return T(n)
+}
+
+func convert[T ~string | ~int](n int) T {
+	return wrap[T](n)
+}
+
+func f() name {
+	return convert[name](65)
+}
@@ -0,0 +1,11 @@
+package main
+
+type code uint8
+
+func convert[T ~uint8 | ~int](n int) T {
+	return T(n)
+}
+
+func f() code {
+	return convert[code](-1)
+}
@@ -0,0 +1,11 @@
+package main
+
+import "os"
+
+func f() string {
+	v := os.Getenv("MODE")
+	if v == "" {
+		v = "default"
+	}
+	return v
+}
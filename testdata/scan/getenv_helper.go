@@ -0,0 +1,14 @@
+package main
+
+import "os"
+
+func getenv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func f() string {
+	return getenv("MODE", "default")
+}
@@ -0,0 +1,13 @@
+package main
+
+func f(upper, bad bool) string {
+	n := 97
+	if upper {
+		n = 65
+	}
+	if bad {
+		n = -1
+	}
+	// The integer is converted to the rune with its value, not formatted.
+	return string(rune(n))
+}
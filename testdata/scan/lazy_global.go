@@ -0,0 +1,23 @@
+package main
+
+import "os"
+
+var cached string
+
+func compute() string {
+	if len(os.Args) > 1 {
+		return "dev"
+	}
+	return "prod"
+}
+
+func get() string {
+	if cached == "" {
+		cached = compute()
+	}
+	return cached
+}
+
+func f() string {
+	return get()
+}
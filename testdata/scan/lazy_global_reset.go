@@ -0,0 +1,18 @@
+package main
+
+var cached string
+
+func compute() string { return "prod" }
+
+func get() string {
+	if cached == "" {
+		cached = compute()
+	}
+	return cached
+}
+
+func reset(s string) { cached = s }
+
+func f() string {
+	return get()
+}
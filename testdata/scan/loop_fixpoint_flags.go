@@ -0,0 +1,13 @@
+package main
+
+func f(names []string) int {
+	mask := 0
+	for _, name := range names {
+		flag := 1
+		if name == "b" {
+			flag = 2
+		}
+		mask = mask | flag
+	}
+	return mask
+}
@@ -0,0 +1,9 @@
+package main
+
+func f(n int) int {
+	x := 0
+	for i := 0; i < n; i++ {
+		x = (x + 1) % 3
+	}
+	return x
+}
@@ -0,0 +1,9 @@
+package main
+
+func f(n int) bool {
+	on := false
+	for range n {
+		on = !on
+	}
+	return on
+}
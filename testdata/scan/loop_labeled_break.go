@@ -0,0 +1,14 @@
+package main
+
+func f(ch chan string) string {
+	mode := "serve"
+loop:
+	for {
+		switch <-ch {
+		case "stop":
+			break loop
+		}
+	}
+	mode = "done"
+	return mode
+}
@@ -0,0 +1,14 @@
+package main
+
+func f(ch chan string) string {
+	mode := "serve"
+	for {
+		switch <-ch {
+		case "stop":
+			// This leaves only the switch statement.
+			break
+		}
+	}
+	mode = "done"
+	return mode
+}
@@ -0,0 +1,7 @@
+package main
+
+func f() int {
+	a := make([]int, 3)
+	a[1] = 7
+	return a[1]
+}
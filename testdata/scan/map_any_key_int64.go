@@ -0,0 +1,6 @@
+package main
+
+func f() int {
+	m := map[any]int{int64(1): 5}
+	return m[1]
+}
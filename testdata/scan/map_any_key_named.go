@@ -0,0 +1,8 @@
+package main
+
+type K string
+
+func f() int {
+	m := map[any]int{K("a"): 5}
+	return m["a"]
+}
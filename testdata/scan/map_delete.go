@@ -0,0 +1,7 @@
+package main
+
+func f() string {
+	m := map[string]string{"a": "x", "b": "y"}
+	delete(m, "a")
+	return m["a"] + m["b"]
+}
@@ -0,0 +1,11 @@
+package main
+
+func fill(m map[string]string) {
+	m["a"] = "z"
+}
+
+func f() string {
+	m := map[string]string{"a": "x"}
+	fill(m)
+	return m["a"]
+}
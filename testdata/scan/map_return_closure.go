@@ -0,0 +1,8 @@
+package main
+
+func f() string {
+	m := map[string]string{"k": "a"}
+	get := func() map[string]string { return m }
+	get()["k"] = "b"
+	return m["k"]
+}
@@ -0,0 +1,7 @@
+package main
+
+func f() string {
+	m := map[string]string{"a": "x"}
+	m["a"] = "z"
+	return m["a"]
+}
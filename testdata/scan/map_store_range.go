@@ -0,0 +1,11 @@
+package main
+
+func f() string {
+	m := make(map[string]string)
+	m["b"] = "y"
+	v := ""
+	for _, x := range m {
+		v = x
+	}
+	return v
+}
@@ -0,0 +1,7 @@
+package main
+
+func f(k string) string {
+	m := map[string]string{"a": "x"}
+	m[k] = "y"
+	return m["a"]
+}
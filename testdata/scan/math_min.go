@@ -0,0 +1,14 @@
+package main
+
+import (
+	"math"
+	"os"
+)
+
+func f() float64 {
+	x := 1.5
+	if len(os.Args) > 1 {
+		x = 4
+	}
+	return math.Min(x, 2.5)
+}
@@ -0,0 +1,15 @@
+package main
+
+type mode string
+
+func (m mode) name() string {
+	if m == "" {
+		return "default"
+	}
+	return string(m)
+}
+
+func f() string {
+	var m mode
+	return m.name()
+}
@@ -0,0 +1,14 @@
+package main
+
+import "os"
+
+func f() int {
+	n := 3
+	if len(os.Args) > 1 {
+		n = 500
+	}
+	if len(os.Args) > 2 {
+		n = -4
+	}
+	return min(max(n, 1), 100)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func die(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
+
+func f() string {
+	mode := "fast"
+	if len(os.Args) > 2 {
+		die("too many arguments")
+		mode = "slow"
+	}
+	return mode
+}
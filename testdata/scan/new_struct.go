@@ -0,0 +1,16 @@
+package main
+
+import "os"
+
+type Config struct {
+	Mode  string
+	Level int
+}
+
+func f() string {
+	p := new(Config)
+	if len(os.Args) > 1 {
+		p.Mode = "debug"
+	}
+	return p.Mode
+}
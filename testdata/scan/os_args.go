@@ -0,0 +1,7 @@
+package main
+
+import "os"
+
+func f() string {
+	return os.Args[0]
+}
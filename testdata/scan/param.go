@@ -0,0 +1,8 @@
+package main
+
+func f(x string) string {
+	if x == "" {
+		x = "hello"
+	}
+	return x
+}
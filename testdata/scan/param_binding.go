@@ -0,0 +1,10 @@
+package main
+
+func id(s string) string {
+	return s
+}
+
+func f() string {
+	x := id("hello")
+	return id(x)
+}
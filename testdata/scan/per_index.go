@@ -0,0 +1,6 @@
+package main
+
+func f(mode string) string {
+	modes := []string{"fast", mode}
+	return modes[0]
+}
@@ -0,0 +1,7 @@
+package main
+
+func f(mode string) string {
+	modes := []string{"fast"}
+	modes = append(modes, "slow", mode)
+	return modes[0]
+}
@@ -0,0 +1,15 @@
+package main
+
+const (
+	fast = iota
+	medium
+	slow
+)
+
+func f() string {
+	names := [...]string{fast: "", slow: "slow"}
+	if len(names) > 5 {
+		names = [3]string{}
+	}
+	return names[slow]
+}
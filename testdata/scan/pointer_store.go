@@ -0,0 +1,8 @@
+package main
+
+func f() string {
+	x := "a"
+	p := &x
+	*p = "b"
+	return x
+}
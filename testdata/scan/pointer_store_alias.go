@@ -0,0 +1,11 @@
+package main
+
+var sink *string
+
+func f() string {
+	x := "a"
+	p := &x
+	sink = p
+	*sink = "c"
+	return x
+}
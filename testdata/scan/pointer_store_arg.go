@@ -0,0 +1,11 @@
+package main
+
+func set(p *string, val string) {
+	*p = val
+}
+
+func f() string {
+	x := "a"
+	set(&x, "b")
+	return x
+}
@@ -0,0 +1,17 @@
+package main
+
+type config struct {
+	mode string
+}
+
+func reset(c *config) {
+	*c = config{mode: "reset"}
+}
+
+func f() string {
+	c := config{mode: "fast"}
+	p := &c.mode
+	*p = "slow"
+	reset(&c)
+	return c.mode
+}
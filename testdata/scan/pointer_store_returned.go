@@ -0,0 +1,10 @@
+package main
+
+func keep(p *string) *string { return p }
+
+func f() string {
+	x := "a"
+	r := keep(&x)
+	*r = "c"
+	return x
+}
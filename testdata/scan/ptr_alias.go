@@ -0,0 +1,17 @@
+package main
+
+import "os"
+
+type Config struct {
+	Mode  string
+	Level int
+}
+
+func f() string {
+	p := new(Config)
+	q := p
+	if len(os.Args) > 1 {
+		q.Mode = "debug"
+	}
+	return p.Mode
+}
@@ -0,0 +1,9 @@
+package main
+
+func f() int {
+	var port int
+	for i := range [3]string{"x"} {
+		port = 8080 + i
+	}
+	return port
+}
@@ -0,0 +1,9 @@
+package main
+
+func f() int {
+	last := -1
+	for i := range 5 {
+		last = i
+	}
+	return last
+}
@@ -0,0 +1,11 @@
+package main
+
+import "os"
+
+func f() int {
+	var last int
+	for i := range len(os.Args) {
+		last = i
+	}
+	return last
+}
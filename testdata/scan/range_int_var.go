@@ -0,0 +1,15 @@
+package main
+
+import "os"
+
+func f() int {
+	n := 2
+	if len(os.Args) > 1 {
+		n = 3
+	}
+	var shard int
+	for i := range n {
+		shard = 10 * i
+	}
+	return shard
+}
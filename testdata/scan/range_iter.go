@@ -0,0 +1,21 @@
+package main
+
+import "iter"
+
+func levels(prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, l := range []string{"debug", "info"} {
+			if !yield(prefix + l) {
+				return
+			}
+		}
+	}
+}
+
+func f() string {
+	var level string
+	for l := range levels("log.") {
+		level = l
+	}
+	return level
+}
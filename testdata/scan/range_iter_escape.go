@@ -0,0 +1,18 @@
+package main
+
+func each(yield func(string) bool) {
+	yield("a")
+	more(yield)
+}
+
+func more(yield func(string) bool) {
+	yield("b")
+}
+
+func f() string {
+	var s string
+	for x := range each {
+		s = x
+	}
+	return s
+}
@@ -0,0 +1,20 @@
+package main
+
+type flags struct{}
+
+func (flags) All(yield func(string, int) bool) {
+	_ = yield("verbose", 1) && yield("quiet", 2)
+}
+
+func f() int {
+	var (
+		fl  flags
+		bit int
+	)
+	for name, b := range fl.All {
+		if name != "" {
+			bit = b
+		}
+	}
+	return bit
+}
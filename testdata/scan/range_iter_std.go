@@ -0,0 +1,11 @@
+package main
+
+import "slices"
+
+func f() string {
+	var region string
+	for r := range slices.Values([]string{"us-east-1", "eu-west-1"}) {
+		region = r
+	}
+	return region
+}
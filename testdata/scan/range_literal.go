@@ -0,0 +1,9 @@
+package main
+
+func f() string {
+	last := "none"
+	for _, s := range []string{"a", "b"} {
+		last = s + "!"
+	}
+	return last
+}
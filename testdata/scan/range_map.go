@@ -0,0 +1,9 @@
+package main
+
+func f() string {
+	var dsn string
+	for name, host := range map[string]string{"primary": "db1", "replica": "db2"} {
+		dsn = name + "@" + host
+	}
+	return dsn
+}
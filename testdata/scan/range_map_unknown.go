@@ -0,0 +1,11 @@
+package main
+
+import "os"
+
+func f() string {
+	var dsn string
+	for _, host := range map[string]string{"primary": "db1", "replica": os.Getenv("REPLICA")} {
+		dsn = host
+	}
+	return dsn
+}
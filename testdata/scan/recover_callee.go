@@ -0,0 +1,25 @@
+package main
+
+import "strconv"
+
+func f(s string) string {
+	return parse(s)
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func parse(s string) string {
+	defer func() {
+		recover()
+	}()
+	if mustAtoi(s) > 0 {
+		return "positive"
+	}
+	return "other"
+}
@@ -0,0 +1,17 @@
+package main
+
+func f(n int) string {
+	return load(n)
+}
+
+func load(n int) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = "fallback"
+		}
+	}()
+	if n > 10 {
+		panic("too big")
+	}
+	return "loaded"
+}
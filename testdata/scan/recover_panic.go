@@ -0,0 +1,19 @@
+package main
+
+import "os"
+
+func f() string {
+	return load()
+}
+
+func load() (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			println(r)
+		}
+	}()
+	if len(os.Args) > 1 {
+		panic("too many args")
+	}
+	return "loaded"
+}
@@ -0,0 +1,12 @@
+package main
+
+func countdown(s string, n int) string {
+	if n == 0 {
+		return s
+	}
+	return countdown(s, n-1)
+}
+
+func f() string {
+	return countdown("hello", 3)
+}
@@ -0,0 +1,19 @@
+package main
+
+type level string
+
+func (l *level) raise() {
+	*l = "high"
+}
+
+func (l level) lower() {
+	l = "low"
+	_ = l
+}
+
+func f() level {
+	l := level("mid")
+	l.lower()
+	l.raise()
+	return l
+}
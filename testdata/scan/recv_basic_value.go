@@ -0,0 +1,21 @@
+package main
+
+type level string
+
+func (l level) lower() {
+	l = "low"
+	_ = l
+}
+
+func (l level) name() string {
+	if l == "" {
+		return "none"
+	}
+	return string(l)
+}
+
+func f() string {
+	l := level("mid")
+	l.lower()
+	return l.name()
+}
@@ -0,0 +1,15 @@
+package main
+
+type config struct {
+	mode string
+}
+
+func (c *config) reset() {
+	c.mode = "reset"
+}
+
+func f() string {
+	cfg := config{mode: "fast"}
+	cfg.reset()
+	return cfg.mode
+}
@@ -0,0 +1,16 @@
+package main
+
+type config struct {
+	mode string
+}
+
+// reset changes only its copy of the receiver.
+func (c config) reset() {
+	c.mode = "reset"
+}
+
+func f() string {
+	cfg := config{mode: "fast"}
+	cfg.reset()
+	return cfg.mode
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	xos "os"
+)
+
+func f() string {
+	mode := "fast"
+	if len(xos.Args) > 2 {
+		fmt.Fprintln(xos.Stderr, "too many arguments")
+		xos.Exit(1)
+		mode = "slow"
+	}
+	return mode
+}
@@ -0,0 +1,20 @@
+package main
+
+func f(quit chan bool) string {
+	ch := make(chan string, 2)
+	ch <- "a"
+	ch <- "b"
+	got := "none"
+	select {
+	case v := <-ch:
+		got = v
+	case v, ok := <-ch:
+		if ok {
+			got = v + "?"
+		}
+	case <-quit:
+	default:
+		got = "idle"
+	}
+	return got
+}
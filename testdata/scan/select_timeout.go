@@ -0,0 +1,28 @@
+package main
+
+import "time"
+
+// The time values received from the timers are external inputs,
+// but they do not affect the values of state.
+func f(ch chan string) string {
+	state := "idle"
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case <-timeout:
+			return state
+		case t := <-ticker.C:
+			if t.IsZero() {
+				continue
+			}
+			state = "ticked"
+		case <-time.After(time.Second):
+			state = "waited"
+		case msg := <-ch:
+			_ = msg
+			state = "busy"
+		}
+	}
+}
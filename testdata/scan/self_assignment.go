@@ -0,0 +1,7 @@
+package main
+
+func f() string {
+	x := "hello"
+	x = x
+	return x
+}
@@ -0,0 +1,17 @@
+package main
+
+import "os"
+
+type exiter struct{}
+
+func (exiter) Exit(int) {}
+
+func f() string {
+	mode := "fast"
+	if len(os.Args) > 2 {
+		os := exiter{}
+		os.Exit(1)
+		mode = "slow"
+	}
+	return mode
+}
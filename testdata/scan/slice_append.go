@@ -0,0 +1,11 @@
+package main
+
+func f(i int) string {
+	x := "fast"
+	if i > 0 {
+		x = "medium"
+	}
+	modes := []string{x}
+	modes = append(modes, "slow")
+	return modes[i]
+}
@@ -0,0 +1,9 @@
+package main
+
+func f(i int) string {
+	modes := []string{"fast", "slow"}
+	g(modes)
+	return modes[i]
+}
+
+func g([]string) {}
@@ -0,0 +1,6 @@
+package main
+
+func f(i int) string {
+	modes := []string{"fast", "slow"}
+	return modes[i]
+}
@@ -0,0 +1,9 @@
+package main
+
+func pick(modes []string, i int) string {
+	return modes[i]
+}
+
+func f(i int) string {
+	return pick([]string{"fast", "slow"}, i)
+}
@@ -0,0 +1,7 @@
+package main
+
+func f(i int) string {
+	modes := []string{"fast", "slow"}
+	modes[0] = "medium"
+	return modes[i]
+}
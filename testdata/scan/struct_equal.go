@@ -0,0 +1,16 @@
+package main
+
+import "os"
+
+type config struct {
+	mode  string
+	level int
+}
+
+func f() bool {
+	cfg := config{mode: "dev"}
+	if len(os.Args) > 1 {
+		cfg.level = 2
+	}
+	return cfg == config{mode: "dev"}
+}
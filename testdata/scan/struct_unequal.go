@@ -0,0 +1,21 @@
+package main
+
+import "os"
+
+type limits struct {
+	min, max int
+}
+
+type config struct {
+	mode   string
+	limits limits
+}
+
+func f() bool {
+	cfg := config{mode: "prod", limits: limits{max: 10}}
+	if len(os.Args) > 1 {
+		cfg.limits.min = 1
+	}
+	defaults := config{mode: "dev", limits: limits{max: 10}}
+	return cfg == defaults
+}
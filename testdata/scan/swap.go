@@ -0,0 +1,10 @@
+package main
+
+func f(flip bool) string {
+	x, y := "a", "b"
+	if flip {
+		x, y = y, x
+	}
+	println(y)
+	return x
+}
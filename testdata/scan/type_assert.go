@@ -0,0 +1,15 @@
+package main
+
+import "os"
+
+func f() string {
+	var x any = "a"
+	if len(os.Args) > 1 {
+		x = 2
+	}
+	if len(os.Args) > 2 {
+		x = "b"
+	}
+	s := x.(string)
+	return s
+}
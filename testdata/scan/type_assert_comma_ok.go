@@ -0,0 +1,13 @@
+package main
+
+import "os"
+
+func f() int {
+	var x any = "a"
+	if len(os.Args) > 1 {
+		x = 2
+	}
+	n, ok := x.(int)
+	_ = ok
+	return n
+}
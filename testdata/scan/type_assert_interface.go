@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type color int
+
+func (c color) String() string { return "color" }
+
+func f() fmt.Stringer {
+	var x any = color(1)
+	if len(os.Args) > 1 {
+		x = 2
+	}
+	s := x.(fmt.Stringer)
+	return s
+}
@@ -0,0 +1,6 @@
+package main
+
+func f(x any) string {
+	s, _ := x.(string)
+	return s
+}
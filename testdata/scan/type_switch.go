@@ -0,0 +1,26 @@
+package main
+
+import "os"
+
+type name string
+
+func f() string {
+	var v any = "a"
+	if len(os.Args) > 1 {
+		v = name("b")
+	}
+	if len(os.Args) > 2 {
+		v = 3
+	}
+
+	s := "none"
+	switch x := v.(type) {
+	case string:
+		s = x
+	case name:
+		s = string(x) + "!"
+	case int, bool:
+		s = "other"
+	}
+	return s
+}
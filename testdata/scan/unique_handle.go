@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+	"unique"
+)
+
+func f() string {
+	region := "us-east-1"
+	if len(os.Args) > 1 {
+		region = "eu-west-1"
+	}
+	h := unique.Make(region)
+	return h.Value()
+}
@@ -0,0 +1,11 @@
+package main
+
+import "net/url"
+
+func f() string {
+	u := &url.URL{Scheme: "https", Host: "backend.internal"}
+	if len(u.Path) == 0 {
+		u.Host = "backend.internal:9000"
+	}
+	return u.Port()
+}
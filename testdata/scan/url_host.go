@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/url"
+	"os"
+)
+
+func endpoint() string {
+	if os.Getenv("ENV") == "staging" {
+		return "https://api.staging.internal:8443/v1"
+	}
+	return "https://api.internal/v1"
+}
+
+func f() string {
+	u, err := url.Parse(endpoint())
+	if err != nil {
+		panic(err)
+	}
+	return u.Hostname()
+}
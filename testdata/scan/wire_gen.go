@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+type Config struct {
+	Addr  string
+	Debug bool
+}
+
+func NewConfig() (Config, error) {
+	if os.Getenv("DEV") != "" {
+		return Config{Addr: "localhost:8080", Debug: true}, nil
+	}
+	return Config{Addr: ":80"}, nil
+}
+
+type DB struct {
+	dsn string
+}
+
+func NewDB(cfg Config) (*DB, func(), error) {
+	if cfg.Debug {
+		return &DB{dsn: "file::memory:"}, func() {}, nil
+	}
+	return &DB{dsn: "postgres://db/app"}, func() {}, nil
+}
+
+// UseReplica is a provider that changes what it is given.
+func UseReplica(db *DB) {
+	if os.Getenv("REPLICA") != "" {
+		db.dsn = "postgres://replica/app"
+	}
+}
+
+type Server struct {
+	addr string
+	db   *DB
+}
+
+func NewServer(cfg Config, db *DB) *Server {
+	return &Server{addr: cfg.Addr, db: db}
+}
+
+type App struct {
+	Server *Server
+}
+
+func NewApp(server *Server) (*App, error) {
+	if server == nil {
+		return nil, errors.New("no server")
+	}
+	return &App{Server: server}, nil
+}
+
+// InitializeApp is in the style of code generated by wire.
+func InitializeApp() (*App, func(), error) {
+	config, err := NewConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup, err := NewDB(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	UseReplica(db)
+	server := NewServer(config, db)
+	app, err := NewApp(server)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return app, func() {
+		cleanup()
+	}, nil
+}
+
+func f() string {
+	app, cleanup, err := InitializeApp()
+	if err != nil {
+		panic(err)
+	}
+	defer cleanup()
+	return app.Server.db.dsn
+}
+
+func main() {
+	println(f())
+}
@@ -0,0 +1,30 @@
+package main
+
+import "sync"
+
+func check(name string) string {
+	if name == "" {
+		return "missing"
+	}
+	return "ok"
+}
+
+func f(names []string) string {
+	var wg sync.WaitGroup
+	results := make(chan string, len(names))
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- check(name)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	worst := "none"
+	for r := range results {
+		worst = r
+	}
+	return worst
+}
@@ -0,0 +1,43 @@
+package main
+
+import "sync"
+
+// group is like errgroup.Group.
+type group struct {
+	wg sync.WaitGroup
+}
+
+func (g *group) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		f()
+	}()
+}
+
+func (g *group) Wait() error {
+	g.wg.Wait()
+	return nil
+}
+
+func f(hosts []string) string {
+	var (
+		g       group
+		mu      sync.Mutex
+		results []string
+	)
+	for _, host := range hosts {
+		g.Go(func() error {
+			status := "up"
+			if host == "" {
+				status = "down"
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, status)
+			return nil
+		})
+	}
+	g.Wait()
+	return results[len(results)-1]
+}
@@ -0,0 +1,30 @@
+package main
+
+import "sync"
+
+func check(name string) string {
+	if name == "" {
+		return "missing"
+	}
+	return "ok"
+}
+
+func f(names []string) string {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []string
+	)
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := check(name)
+			mu.Lock()
+			results = append(results, status)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results[0]
+}
@@ -0,0 +1,11 @@
+package main
+
+var saved *string
+
+func f() string {
+	x := "hello"
+	g(&x)
+	return x
+}
+
+func g(p *string) { saved = p }
@@ -0,0 +1,24 @@
+package main
+
+import "strconv"
+
+func digit(d int) string {
+	// The values of d are unknown, so there is nothing to report.
+	return string(d)
+}
+
+func f(verbose bool) string {
+	code := 7
+	if verbose {
+		code = 65
+	}
+	a := string(code)
+
+	letter := 66
+	b := string(letter)
+
+	r := rune(10)
+	c := string(r)
+
+	return a + b + c + digit(3) + strconv.Itoa(code)
+}
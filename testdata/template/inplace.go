@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+	"text/template"
+)
+
+func f() *template.Template {
+	t := template.New("page")
+	t.Parse("{{.Body}}")
+	return t
+}
+
+func main() {
+	f().Execute(os.Stdout, nil)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"text/template"
+)
+
+const header = `{{define "header"}}<h1>{{.Title}}</h1>{{end}}`
+
+func body(verbose bool) string {
+	if verbose {
+		return "{{.Body}} ({{.Author}})"
+	}
+	return "{{.Body}}"
+}
+
+func f(verbose bool) *template.Template {
+	base := template.Must(template.New("page").Funcs(template.FuncMap{}).Parse(header))
+	return template.Must(base.Parse(body(verbose)))
+}
+
+func main() {
+	f(len(os.Args) > 1).Execute(os.Stdout, nil)
+}
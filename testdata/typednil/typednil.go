@@ -0,0 +1,59 @@
+package main
+
+type validationError struct {
+	field string
+}
+
+func (e *validationError) Error() string {
+	return "invalid " + e.field
+}
+
+func validate(name string) *validationError {
+	if name == "" {
+		return &validationError{field: "name"}
+	}
+	return nil
+}
+
+// check returns a non-nil error even when validate succeeds.
+func check(name string) error {
+	err := validate(name)
+	return err
+}
+
+// checkGuarded returns the validation error only if there is one.
+func checkGuarded(name string) error {
+	if err := validate(name); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkEarly does the same with an early return.
+func checkEarly(name string) error {
+	err := validate(name)
+	if err == nil {
+		return nil
+	}
+	return err
+}
+
+func lookup() error {
+	var e *validationError
+	return e
+}
+
+func main() {
+	if check("x") != nil { // bad
+		println("failed")
+	}
+	if checkGuarded("x") != nil {
+		println("failed")
+	}
+	if checkEarly("x") != nil {
+		println("failed")
+	}
+	if err := lookup(); err == nil { // bad
+		println("ok")
+	}
+}
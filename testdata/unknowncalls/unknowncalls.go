@@ -0,0 +1,13 @@
+package main
+
+import "strings"
+
+func f() string {
+	return safe()
+}
+
+func safe() string {
+	defer func() { recover() }()
+	strings.Repeat("x", -1)
+	return "ok"
+}
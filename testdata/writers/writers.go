@@ -0,0 +1,20 @@
+package main
+
+import "strings"
+
+func pick(fast bool) string {
+	if fast {
+		return "fast"
+	}
+	return strings.ToUpper("slow")
+}
+
+func f(fast, other bool) string {
+	var mode string
+	if fast {
+		mode = pick(other)
+	} else if other {
+		mode = "default"
+	}
+	return mode
+}
@@ -0,0 +1,294 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// TypedNil is a nil value of a concrete type
+// (such as a nil *T)
+// that an interface value may hold.
+// Such an interface value is not itself nil,
+// which is a frequent source of bugs:
+//
+//	func validate() error {
+//	  var err *ValidationError
+//	  ...
+//	  return err // never a nil error, even when err is nil
+//	}
+type TypedNil struct {
+	// Expr is the expression of concrete type
+	// whose value becomes the interface's dynamic value.
+	Expr ast.Expr
+
+	// Type is the concrete type.
+	Type types.Type
+
+	// Origin is where the nil comes from:
+	// a nil literal,
+	// or the name of a variable declared without a value.
+	Origin ast.Expr
+}
+
+// TypedNils is the outcome of [Scanner.TypedNils].
+type TypedNils struct {
+	TypedNils []TypedNil
+
+	// Complete tells whether all the typed nils were found.
+	Complete bool
+
+	// Why explains what made the result incomplete,
+	// as in [Result].
+	Why *Reason
+}
+
+// TypedNils finds the typed nils that the interface-valued expression node may hold.
+// It follows the values of node back through variables, calls, and conversions
+// to the expressions of concrete type they come from,
+// and each of those back to any nil it may have.
+//
+// Expressions of concrete type guarded by a comparison with nil,
+// as in
+//
+//	if err != nil {
+//	  return err
+//	}
+//
+// are known not to be nil.
+func (s *Scanner) TypedNils(node ast.Expr) TypedNils {
+	var result TypedNils
+	q := s.newQuery()
+	res := q.result(node, "finding typed nils in "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+		var ok bool
+		result.TypedNils, ok = q.typedNils(node)
+		return nil, ok
+	})
+	result.Complete, result.Why = res.Complete, res.Why
+	return result
+}
+
+// NilComparison is a comparison of an interface value with nil
+// whose outcome may be surprising,
+// because the interface value may hold a typed nil.
+type NilComparison struct {
+	// Cmp is the comparison, x == nil or x != nil.
+	Cmp *ast.BinaryExpr
+
+	// TypedNils are the typed nils that x may hold.
+	TypedNils []TypedNil
+}
+
+// CheckNilComparisons finds the comparisons with nil in the Scanner's files
+// of interface values that may hold typed nils
+// (see [Scanner.TypedNils]).
+func (s *Scanner) CheckNilComparisons() []NilComparison {
+	if missingInfo(s.Info) != "" {
+		return nil
+	}
+
+	var result []NilComparison
+	for _, file := range s.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			cmp, ok := n.(*ast.BinaryExpr)
+			if !ok || (cmp.Op != token.EQL && cmp.Op != token.NEQ) {
+				return true
+			}
+			x, y := cmp.X, cmp.Y
+			if tv, ok := s.Info.Types[x]; ok && tv.IsNil() {
+				x, y = y, x
+			}
+			if tv, ok := s.Info.Types[y]; !ok || !tv.IsNil() || !isInterface(s.Info.TypeOf(x)) {
+				return true
+			}
+			if tn := s.TypedNils(x); len(tn.TypedNils) > 0 {
+				result = append(result, NilComparison{Cmp: cmp, TypedNils: tn.TypedNils})
+			}
+			return true
+		})
+	}
+	return result
+}
+
+func (q *query) typedNils(node ast.Expr) ([]TypedNil, bool) {
+	var (
+		result   []TypedNil
+		seen     = make(map[TypedNil]bool)
+		complete = true
+	)
+
+	ok := q.dynamicValues(node, func(expr ast.Expr, typ types.Type) {
+		if !isNilable(typ) {
+			return
+		}
+		complete = q.nilOrigins(expr, func(origin ast.Expr) {
+			tn := TypedNil{Expr: expr, Type: typ, Origin: origin}
+			if !seen[tn] {
+				seen[tn] = true
+				result = append(result, tn)
+			}
+		}) && complete
+	})
+
+	return result, complete && ok
+}
+
+// nilOrigins finds the nils that expr,
+// which has a concrete type,
+// may have,
+// and passes each to f.
+// The result tells whether all of them were found.
+func (q *query) nilOrigins(expr ast.Expr, f func(ast.Expr)) bool {
+	follow := func(e ast.Expr) bool {
+		if id, ok := e.(*ast.Ident); ok {
+			return !q.guardedNonNil(id)
+		}
+		return true
+	}
+
+	complete := true
+	ok := q.sources(expr, follow, func(src source) {
+		if src.zero {
+			f(src.expr)
+			return
+		}
+		if tv, ok := q.info.Types[src.expr]; ok && tv.IsNil() {
+			f(src.expr)
+			return
+		}
+		if !mayBeNil(src.expr, q.info) {
+			return
+		}
+		if id, ok := src.expr.(*ast.Ident); ok && q.guardedNonNil(id) {
+			return
+		}
+		q.incomplete(src.expr, "cannot tell whether %s is nil", types.ExprString(src.expr))
+		complete = false
+	})
+	return complete && ok
+}
+
+// mayBeNil tells whether expr,
+// which is not itself a nil literal,
+// might have a nil value,
+// as far as can be told from expr alone.
+func mayBeNil(expr ast.Expr, info *types.Info) bool {
+	if !isNilable(info.TypeOf(expr)) {
+		return false
+	}
+	switch e := expr.(type) {
+	case *ast.UnaryExpr:
+		return e.Op != token.AND
+	case *ast.CompositeLit, *ast.FuncLit:
+		return false
+	case *ast.CallExpr:
+		return !isBuiltin(e, "new", info) && !isBuiltin(e, "make", info)
+	}
+	return true
+}
+
+// isNilable tells whether typ is a concrete type with a nil value.
+func isNilable(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+	switch typ := typ.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Slice, *types.Signature, *types.Chan:
+		return true
+	case *types.Basic:
+		return typ.Kind() == types.UnsafePointer
+	}
+	return false
+}
+
+// guardedNonNil tells whether the variable that ident refers to
+// is known not to be nil where ident appears,
+// because of an enclosing if statement like
+//
+//	if x != nil {
+//	  ... ident ...
+//	}
+//
+// or an earlier one in the same block like
+//
+//	if x == nil {
+//	  return
+//	}
+func (q *query) guardedNonNil(ident *ast.Ident) bool {
+	v, ok := q.info.ObjectOf(ident).(*types.Var)
+	if !ok {
+		return false
+	}
+
+	path := pathTo(q.files, ident)
+	for i := len(path) - 1; i >= 0; i-- {
+		var child ast.Node = ident
+		if i+1 < len(path) {
+			child = path[i+1]
+		}
+
+		switch anc := path[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+
+		case *ast.IfStmt:
+			switch {
+			case child == anc.Body && nonNilWhen(anc.Cond, true, v, q.info) && q.canNarrow(v, anc.Body):
+				return true
+			case child == anc.Else && nonNilWhen(anc.Cond, false, v, q.info) && q.canNarrow(v, anc.Else):
+				return true
+			}
+
+		case *ast.BlockStmt:
+			idx := slices.IndexFunc(anc.List, func(stmt ast.Stmt) bool { return stmt == child })
+			if idx < 0 {
+				continue
+			}
+			for j, stmt := range anc.List[:idx] {
+				ifStmt, ok := stmt.(*ast.IfStmt)
+				if !ok || ifStmt.Else != nil || !q.stmtNeverCompletes(ifStmt.Body) {
+					continue
+				}
+				if !nonNilWhen(ifStmt.Cond, false, v, q.info) {
+					continue
+				}
+				// The variable must not be assigned after the if statement.
+				after := anc.List[j+1 : idx+1]
+				if !slices.ContainsFunc(after, func(stmt ast.Stmt) bool { return !q.canNarrow(v, stmt) }) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// nonNilWhen tells whether v is known not to be nil
+// when cond has the value truth.
+func nonNilWhen(cond ast.Expr, truth bool, v *types.Var, info *types.Info) bool {
+	switch cond := ast.Unparen(cond).(type) {
+	case *ast.UnaryExpr:
+		return cond.Op == token.NOT && nonNilWhen(cond.X, !truth, v, info)
+
+	case *ast.BinaryExpr:
+		switch cond.Op {
+		case token.LAND:
+			return truth && (nonNilWhen(cond.X, true, v, info) || nonNilWhen(cond.Y, true, v, info))
+		case token.LOR:
+			return !truth && (nonNilWhen(cond.X, false, v, info) || nonNilWhen(cond.Y, false, v, info))
+		case token.EQL, token.NEQ:
+			if (cond.Op == token.NEQ) != truth {
+				return false
+			}
+			x, y := cond.X, cond.Y
+			if tv, ok := info.Types[x]; ok && tv.IsNil() {
+				x, y = y, x
+			}
+			tv, ok := info.Types[y]
+			return ok && tv.IsNil() && exprIsVar(x, v, info)
+		}
+	}
+	return false
+}
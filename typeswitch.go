@@ -0,0 +1,115 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// typeSwitchOf returns the type switch statement that cc is a clause of,
+// if cc declares a variable implicitly,
+// as for x in switch x := y.(type).
+// The variable is the second result.
+func (q *query) typeSwitchOf(cc *ast.CaseClause) (*ast.TypeSwitchStmt, *types.Var) {
+	v, ok := q.info.Implicits[cc].(*types.Var)
+	if !ok {
+		return nil, nil
+	}
+	path := pathTo(q.files, cc)
+	if len(path) < 2 {
+		return nil, nil
+	}
+	ts, ok := path[len(path)-2].(*ast.TypeSwitchStmt)
+	if !ok {
+		return nil, nil
+	}
+	return ts, v
+}
+
+// scanTypeSwitchVar determines the possible values
+// of the variable that cc,
+// a clause of the type switch statement ts,
+// declares implicitly,
+// on entry to the clause:
+// the values of the switch's operand
+// whose dynamic types select cc.
+// Only those of basic type have values.
+func (q *query) scanTypeSwitchVar(ts *ast.TypeSwitchStmt, cc *ast.CaseClause) (map[string]constant.Value, bool) {
+	assign, ok := ts.Assign.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 {
+		return map[string]constant.Value{}, false
+	}
+	ta, ok := ast.Unparen(assign.Rhs[0]).(*ast.TypeAssertExpr)
+	if !ok {
+		return map[string]constant.Value{}, false
+	}
+
+	return q.nested(ts, "operand of type switch on "+types.ExprString(ta.X), func() (map[string]constant.Value, bool) {
+		var (
+			result   = make(map[string]constant.Value)
+			complete = true
+		)
+		ok := q.dynamicValues(ta.X, func(expr ast.Expr, typ types.Type) {
+			if q.selectedClause(ts, typ) != cc || !isBasic(typ) {
+				return
+			}
+			vals, ok := q.scan(expr)
+			union(result, vals)
+			complete = complete && ok
+		})
+		return result, complete && ok
+	})
+}
+
+// scanTypeAssert determines the possible values of the type assertion x.(T):
+// the values of x whose dynamic types satisfy it.
+// Only those of basic type have values.
+// Values that don't satisfy it make the assertion panic,
+// and in the comma-ok form produce the zero value instead
+// (see [query.scanCommaOk]).
+func (q *query) scanTypeAssert(ta *ast.TypeAssertExpr) (map[string]constant.Value, bool) {
+	asserted := q.info.TypeOf(ta.Type)
+	if asserted == nil {
+		q.incomplete(ta, "unknown asserted type in %s", types.ExprString(ta))
+		return nil, false
+	}
+
+	return q.nested(ta, "type assertion "+types.ExprString(ta), func() (map[string]constant.Value, bool) {
+		var (
+			result   = make(map[string]constant.Value)
+			complete = true
+		)
+		ok := q.dynamicValues(ta.X, func(expr ast.Expr, typ types.Type) {
+			if !assertable(typ, asserted) || !isBasic(typ) {
+				return
+			}
+			vals, ok := q.scan(expr)
+			union(result, vals)
+			complete = complete && ok
+		})
+		return result, complete && ok
+	})
+}
+
+// selectedClause returns the clause of the type switch statement ts
+// that a value of the dynamic type typ selects,
+// or nil if there is none.
+func (q *query) selectedClause(ts *ast.TypeSwitchStmt, typ types.Type) *ast.CaseClause {
+	var dflt *ast.CaseClause
+	for _, stmt := range ts.Body.List {
+		cc := stmt.(*ast.CaseClause)
+		if cc.List == nil {
+			dflt = cc
+			continue
+		}
+		for _, expr := range cc.List {
+			if tv, ok := q.info.Types[expr]; !ok || tv.IsNil() {
+				continue
+			}
+			if assertable(typ, q.info.TypeOf(expr)) {
+				return cc
+			}
+		}
+	}
+	return dflt
+}
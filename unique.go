@@ -0,0 +1,33 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// summarizeUniqueMake summarizes unique.Make,
+// which interns its argument.
+// The resulting handle is represented by the values it wraps,
+// which [summarizeHandleValue] recovers.
+// That keeps comparisons of handles right, too,
+// since two handles are equal just when their values are.
+func summarizeUniqueMake(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	if idx != 0 || len(call.Args) != 1 {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+	return q.scan(call.Args[0])
+}
+
+// summarizeHandleValue summarizes unique.Handle.Value,
+// which returns the value a handle wraps
+// (see [summarizeUniqueMake]).
+func summarizeHandleValue(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || idx != 0 {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+	return q.scan(sel.X)
+}
@@ -0,0 +1,105 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"net/url"
+	"reflect"
+)
+
+// URLs are tracked by their components,
+// the fields of [url.URL].
+// A URL parsed from a known string with url.Parse
+// has known components,
+// as does one built with a composite literal
+// (e.g. &url.URL{Scheme: "https", Host: host})
+// or by storing to its fields.
+// So given
+//
+//	u, err := url.Parse(endpoint)
+//
+// scanning u.Host or u.Hostname() yields the possible hosts of endpoint,
+// for checks like "the host is always an internal domain."
+
+// summarizeURLParse handles url.Parse and url.ParseRequestURI.
+// Strings that fail to parse contribute no values,
+// since the resulting URL is nil.
+func summarizeURLParse(q *query, call *ast.CallExpr, idx int, path []int) (map[string]constant.Value, bool) {
+	fun := calleeFunc(call, q.info)
+	if idx != 0 || len(call.Args) != 1 || len(path) != 1 {
+		q.incomplete(call, "unsupported use of %s", types.ExprString(call))
+		return nil, false
+	}
+	st, ok := structOf(fun.Signature().Results().At(0).Type())
+	if !ok || path[0] >= st.NumFields() {
+		q.incomplete(call, "unsupported use of %s", types.ExprString(call))
+		return nil, false
+	}
+	name := st.Field(path[0]).Name()
+
+	parse := url.Parse
+	if fun.Name() == "ParseRequestURI" {
+		parse = url.ParseRequestURI
+	}
+
+	vals, complete := q.scan(call.Args[0])
+
+	result := make(map[string]constant.Value)
+	for _, v := range vals {
+		if v.Kind() != constant.String {
+			continue
+		}
+		u, err := parse(constant.StringVal(v))
+		if err != nil {
+			continue
+		}
+		var c constant.Value
+		switch f := reflect.ValueOf(u).Elem().FieldByName(name); f.Kind() {
+		case reflect.String:
+			c = constant.MakeString(f.String())
+		case reflect.Bool:
+			c = constant.MakeBool(f.Bool())
+		default:
+			q.incomplete(call, "field %s of a parsed URL is not tracked", name)
+			return nil, false
+		}
+		result[c.ExactString()] = c
+	}
+	return result, complete
+}
+
+// summarizeURLMethod handles the methods of *url.URL
+// that depend only on its Host field.
+func summarizeURLMethod(q *query, call *ast.CallExpr, idx int) (map[string]constant.Value, bool) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || idx != 0 {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+	obj, index, _ := types.LookupFieldOrMethod(q.info.TypeOf(sel.X), true, nil, "Host")
+	if _, ok := obj.(*types.Var); !ok {
+		q.incomplete(call, "unsupported call form %s", types.ExprString(call))
+		return nil, false
+	}
+
+	hosts, complete := q.scanField(sel.X, index)
+
+	result := make(map[string]constant.Value)
+	for _, h := range hosts {
+		if h.Kind() != constant.String {
+			continue
+		}
+		u := &url.URL{Host: constant.StringVal(h)}
+		var s string
+		switch sel.Sel.Name {
+		case "Hostname":
+			s = u.Hostname()
+		case "Port":
+			s = u.Port()
+		}
+		v := constant.MakeString(s)
+		result[v.ExactString()] = v
+	}
+	return result, complete
+}
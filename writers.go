@@ -0,0 +1,120 @@
+package exprvals
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Writer is a program point that may supply the value of an expression.
+// See [Scanner.Writers].
+type Writer struct {
+	// Stmt is the statement containing Expr:
+	// typically an assignment, a declaration, or a return statement.
+	// It is nil if Expr is not in a statement
+	// (as for a package-level variable declaration).
+	Stmt ast.Stmt
+
+	// Expr is the expression whose value is written.
+	// For a variable declared without a value,
+	// it is the variable's name in the declaration.
+	Expr ast.Expr
+
+	// Zero tells whether Expr is the name of a variable declared without a value,
+	// which writes the zero value.
+	Zero bool
+}
+
+// Writers is the outcome of [Scanner.Writers].
+type Writers struct {
+	Writers []Writer
+
+	// Complete tells whether all the writers were found.
+	Complete bool
+
+	// Why explains what made the result incomplete,
+	// as in [Result].
+	Why *Reason
+}
+
+// Writers finds the program points whose values node may have:
+// "who writes this value?"
+// It follows the values of node back through variables
+// to the expressions assigned to them,
+// and through calls of functions in the Scanner's files
+// to the expressions they return,
+// stopping at any other expression,
+// such as a literal, an operation, or a call of another function.
+// It is the inverse of [Scanner.Explain],
+// covering all the routes by which values may arrive
+// rather than the route of one value,
+// for navigating from a use to the code that determines it.
+//
+// Unlike [Scanner.Scan],
+// Writers works for expressions of any type.
+// The writers are in the order they are found,
+// each only once.
+func (s *Scanner) Writers(node ast.Expr) Writers {
+	var result Writers
+	q := s.newQuery()
+	res := q.result(node, "finding writers of "+types.ExprString(node), func() (map[string]constant.Value, bool) {
+		seen := make(map[ast.Expr]bool)
+		ok := q.sources(node, q.followWriter, func(src source) {
+			if seen[src.expr] {
+				return
+			}
+			seen[src.expr] = true
+			result.Writers = append(result.Writers, Writer{Stmt: s.enclosingStmt(src.expr), Expr: src.expr, Zero: src.zero})
+		})
+		return nil, ok
+	})
+	result.Complete, result.Why = res.Complete, res.Why
+	return result
+}
+
+// followWriter tells whether [Scanner.Writers] should look past expr
+// for the writers of its values:
+// expr is a variable,
+// or a call of a function whose body is available
+// (and that has no summary).
+func (q *query) followWriter(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return true
+
+	case *ast.CallExpr:
+		tv, ok := q.info.Types[expr.Fun]
+		if !ok || !tv.IsValue() {
+			// A conversion.
+			return ok && types.IsInterface(tv.Type)
+		}
+		if _, ok := ast.Unparen(expr.Fun).(*ast.FuncLit); ok {
+			return true
+		}
+		if _, ok := summaryFor(expr, q.info); ok {
+			return false
+		}
+		fn := calleeFunc(expr, q.info)
+		return fn != nil && q.calleeBody(fn) != nil
+	}
+	return false
+}
+
+// enclosingStmt returns the innermost statement in the Scanner's files containing expr,
+// or nil if there is none.
+func (s *Scanner) enclosingStmt(expr ast.Expr) ast.Stmt {
+	for _, file := range s.Files {
+		if expr.Pos() < file.Pos() || expr.End() > file.End() {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(file, expr.Pos(), expr.End())
+		for _, n := range path {
+			if stmt, ok := n.(ast.Stmt); ok {
+				return stmt
+			}
+		}
+	}
+	return nil
+}